@@ -2,9 +2,13 @@
 package main
 
 import (
+	"context"
+
+	"awesomeProject/internal/guardrails"
 	"awesomeProject/internal/handlers"
 	"awesomeProject/internal/routes"
-	"awesomeProject/internal/utils"
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/prompts"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -28,6 +32,10 @@ var (
 func init() {
 	// Sätt debug-loggning
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	// Personnummer, telefonnummer och e-postadresser maskeras i loggarna
+	if os.Getenv("GUARDRAIL_REDACT_LOGS") != "false" {
+		log.SetOutput(guardrails.RedactWriter(os.Stderr))
+	}
 	log.Printf("🚀 Startar applikationen...")
 	
 	// Registrera metrics
@@ -74,13 +82,25 @@ func init() {
 	log.Printf("🔧 Miljövariabler:")
 	aiProvider := os.Getenv("AI_PROVIDER")
 	log.Printf("AI_PROVIDER=%s", aiProvider)
+	log.Printf("AI_PROVIDER_CHAIN=%s", os.Getenv("AI_PROVIDER_CHAIN"))
 	log.Printf("HUGGINGFACE_API_KEY=%s", maskAPIKey(os.Getenv("HUGGINGFACE_API_KEY")))
 	log.Printf("HUGGINGFACE_MODEL_ID=%s", os.Getenv("HUGGINGFACE_MODEL_ID"))
 	log.Printf("GEMINI_API_KEY=%s", maskAPIKey(os.Getenv("GEMINI_API_KEY")))
-
-	// Initiera AI service och visa vilken som används
-	service := utils.GetAIService()
-	log.Printf("🤖 Använder AI service: %T", service)
+	log.Printf("OPENAI_API_KEY=%s", maskAPIKey(os.Getenv("OPENAI_API_KEY")))
+	log.Printf("OPENAI_BASE_URL=%s", os.Getenv("OPENAI_BASE_URL"))
+	log.Printf("LOCAL_LLM_BASE_URL=%s", os.Getenv("LOCAL_LLM_BASE_URL"))
+	log.Printf("LOCAL_LLM_MODEL=%s", os.Getenv("LOCAL_LLM_MODEL"))
+	log.Printf("PROMPT_LANGUAGE=%s", os.Getenv("PROMPT_LANGUAGE"))
+	log.Printf("PROMPT_VERSIONS=%s", os.Getenv("PROMPT_VERSIONS"))
+	log.Printf("AI_CACHE=%s", os.Getenv("AI_CACHE"))
+	log.Printf("GUARDRAIL_REDACT_LOGS=%s", os.Getenv("GUARDRAIL_REDACT_LOGS"))
+	log.Printf("AI_USAGE_FILE=%s", os.Getenv("AI_USAGE_FILE"))
+	log.Printf("AI_QUOTA_USER_DAILY_REQUESTS=%s", os.Getenv("AI_QUOTA_USER_DAILY_REQUESTS"))
+	log.Printf("AI_QUOTA_GLOBAL_DAILY_TOKENS=%s", os.Getenv("AI_QUOTA_GLOBAL_DAILY_TOKENS"))
+	log.Printf("ADMIN_TOKEN=%s", maskAPIKey(os.Getenv("ADMIN_TOKEN")))
+
+	// Initiera AI-leverantören och visa vilken som används
+	log.Printf("🤖 Använder AI-leverantör: %s (tillgängliga: %v)", llm.Default().Name(), llm.Providers())
 }
 
 // Hjälpfunktion för att maskera API-nycklar i loggen
@@ -128,13 +148,16 @@ func main() {
 	// CORS konfiguration
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"https://www.smidra.com"}
-	config.AllowMethods = []string{"GET", "POST", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "X-User-ID", "Cache-Control", "X-AI-Cache"}
 	router.Use(cors.New(config))
 
 	// Prometheus middleware
 	router.Use(prometheusMiddleware())
 
+	// Låt klienten gå förbi AI-cachen med X-AI-Cache eller Cache-Control
+	router.Use(handlers.AICacheControl())
+
 	// Health och metrics endpoints
 	router.GET("/health", handlers.HealthCheck)
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -142,6 +165,12 @@ func main() {
 	// Sätt upp alla routes från routes.go
 	routes.SetupRoutes(router)
 
+	// Starta bevakningen av sparade sökningar
+	handlers.StartSavedSearchScheduler(context.Background())
+
+	// Läs om promptarna när mallfilerna ändras
+	prompts.StartHotReload(context.Background())
+
 	// Starta servern
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"time"
+)
+
+// Entry är ett cachat värde. Meta kan användas för t.ex. ETag och
+// Last-Modified så att ett utgånget värde kan förnyas villkorligt.
+type Entry struct {
+	Value    []byte            `json:"value"`
+	StoredAt time.Time         `json:"stored_at"`
+	Meta     map[string]string `json:"meta,omitempty"`
+}
+
+// Fresh anger om värdet fortfarande är giltigt med given TTL
+func (e Entry) Fresh(ttl time.Duration) bool {
+	return ttl <= 0 || time.Since(e.StoredAt) < ttl
+}
+
+// Store är gränssnittet som alla cache-backends implementerar. Utgångna
+// värden tas inte bort automatiskt, det är upp till anroparen att avgöra
+// om ett gammalt värde ska förnyas eller kastas.
+type Store interface {
+	Get(key string) (Entry, bool)
+	Set(key string, entry Entry)
+	Delete(key string)
+	Len() int
+}
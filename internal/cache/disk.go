@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DiskStore sparar varje värde som en JSON-fil i en katalog så att cachen
+// överlever omstarter. När maxEntries överskrids tas de äldsta filerna bort.
+type DiskStore struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+	count      int
+}
+
+// NewDiskStore skapar katalogen om den saknas. maxEntries <= 0 betyder ingen gräns.
+func NewDiskStore(dir string, maxEntries int) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("kunde inte skapa cachekatalog %s: %v", dir, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte läsa cachekatalog %s: %v", dir, err)
+	}
+
+	return &DiskStore{
+		dir:        dir,
+		maxEntries: maxEntries,
+		count:      len(files),
+	}, nil
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *DiskStore) Get(key string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		log.Printf("⚠️ Trasig cachefil för nyckel %s: %v", key, err)
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+func (s *DiskStore) Set(key string, entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("⚠️ Kunde inte serialisera cachevärde för %s: %v", key, err)
+		return
+	}
+
+	path := s.path(key)
+	_, statErr := os.Stat(path)
+	isNew := os.IsNotExist(statErr)
+
+	// Skriv till en temporär fil först så att en läsare aldrig ser en halv fil
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		log.Printf("⚠️ Kunde inte skriva cachefil %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("⚠️ Kunde inte spara cachefil %s: %v", path, err)
+		os.Remove(tmp)
+		return
+	}
+
+	if isNew {
+		s.count++
+	}
+	if s.maxEntries > 0 && s.count > s.maxEntries {
+		s.evictOldest()
+	}
+}
+
+func (s *DiskStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(key)); err == nil {
+		s.count--
+	}
+}
+
+func (s *DiskStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// evictOldest tar bort de äldsta filerna tills vi är under gränsen igen.
+// Vi rensar ner till 90 % av gränsen för att slippa städa vid varje skrivning.
+func (s *DiskStore) evictOldest() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("⚠️ Kunde inte läsa cachekatalog %s: %v", s.dir, err)
+		return
+	}
+
+	type fileInfo struct {
+		name    string
+		modTime int64
+	}
+	var files []fileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{name: e.Name(), modTime: info.ModTime().UnixNano()})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+
+	target := s.maxEntries * 9 / 10
+	removed := 0
+	for len(files)-removed > target && removed < len(files) {
+		if err := os.Remove(filepath.Join(s.dir, files[removed].name)); err != nil {
+			log.Printf("⚠️ Kunde inte ta bort cachefil %s: %v", files[removed].name, err)
+		}
+		removed++
+	}
+	s.count = len(files) - removed
+}
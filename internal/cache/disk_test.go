@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDiskStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := time.Now().Truncate(time.Second)
+	store.Set("jobb/1", Entry{Value: []byte(`{"id":"1"}`), StoredAt: stored, Meta: map[string]string{"etag": `"abc"`}})
+	store.Set("jobb/1", Entry{Value: []byte(`{"id":"1","v":2}`), StoredAt: stored, Meta: map[string]string{"etag": `"def"`}})
+
+	// Värdena ska finnas kvar efter en omstart
+	store, err = NewDiskStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := store.Get("jobb/1")
+	if !ok || string(entry.Value) != `{"id":"1","v":2}` || entry.Meta["etag"] != `"def"` || !entry.StoredAt.Equal(stored) {
+		t.Fatalf("Get = %+v, %v", entry, ok)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len = %d, vill ha 1", store.Len())
+	}
+
+	store.Delete("jobb/1")
+	if _, ok := store.Get("jobb/1"); ok || store.Len() != 0 {
+		t.Errorf("värdet finns kvar efter Delete, Len = %d", store.Len())
+	}
+}
+
+func TestDiskStoreEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskStore(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Filernas ändringstid avgör vilka som är äldst
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("jobb-%d", i)
+		store.Set(key, Entry{Value: []byte("{}")})
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(store.path(key), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Set("jobb-10", Entry{Value: []byte("{}")})
+
+	// Över gränsen rensas ner till 90 %, de äldsta först
+	if store.Len() != 9 {
+		t.Fatalf("Len = %d, vill ha 9", store.Len())
+	}
+	for i := 0; i <= 10; i++ {
+		key := fmt.Sprintf("jobb-%d", i)
+		_, ok := store.Get(key)
+		if want := i >= 2; ok != want {
+			t.Errorf("%s finns = %v, vill ha %v", key, ok, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", Entry{Value: []byte("1")})
+	c.Set("b", Entry{Value: []byte("2")})
+
+	// a används och blir nyast, så b ska kastas när c läggs till
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a saknas")
+	}
+	c.Set("c", Entry{Value: []byte("3")})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b borde ha kastats")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%s saknas", key)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, vill ha 2", c.Len())
+	}
+}
+
+func TestLRUSetReplaces(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", Entry{Value: []byte("1")})
+	c.Set("b", Entry{Value: []byte("2")})
+	c.Set("a", Entry{Value: []byte("ny")})
+	c.Set("c", Entry{Value: []byte("3")})
+
+	if entry, ok := c.Get("a"); !ok || string(entry.Value) != "ny" {
+		t.Errorf("a = %q, %v", entry.Value, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("b borde ha kastats")
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("a finns kvar efter Delete, Len = %d", c.Len())
+	}
+}
+
+func TestEntryFresh(t *testing.T) {
+	entry := Entry{StoredAt: time.Now().Add(-time.Hour)}
+	if !entry.Fresh(2 * time.Hour) {
+		t.Error("värdet ska vara färskt inom TTL")
+	}
+	if entry.Fresh(30 * time.Minute) {
+		t.Error("värdet ska ha gått ut")
+	}
+	if !entry.Fresh(0) {
+		t.Error("TTL 0 betyder att värdet aldrig går ut")
+	}
+}
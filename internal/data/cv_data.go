@@ -18,7 +18,7 @@ type CVRequest struct {
 type KontaktItem struct {
     Typ   string `json:"typ"`
     Varde string `json:"varde"`
-    Ikon  string `json:"ikon"`
+    Ikon  string `json:"ikon,omitempty"`
 }
 
 type Sprak struct {
@@ -43,7 +43,7 @@ type Utbildning struct {
 type PersonligInfo struct {
     Namn    string       `json:"namn"`
     Titel   string       `json:"titel"`
-    Bild    string       `json:"bild"`
+    Bild    string       `json:"bild,omitempty"`
     Kontakt []KontaktItem `json:"kontakt"`
 }
 
@@ -56,6 +56,8 @@ type CVData struct {
     Utbildning          []Utbildning           `json:"utbildning"`
     Projekt             []string               `json:"projekt"`
     Certifieringar      []string               `json:"certifieringar"`
+    // PromptVersion anger vilken prompt ett AI-genererat CV kommer från
+    PromptVersion       string                 `json:"promptVersion,omitempty" schema:"-"`
 }
 
 type TemplateData struct {
@@ -0,0 +1,241 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Ett .docx-dokument är en zip-fil med Office Open XML. Dokumenten hålls i en
+// spalt med riktiga rubrik- och listformat så att rekryteringssystem kan läsa
+// dem; mallens accentfärg används för rubrikerna.
+
+// A4 och marginaler i twips (1/20 punkt)
+const (
+	docxPageWidth  = 11906
+	docxPageHeight = 16838
+	docxMargin     = 1021 // 18 mm, som i PDF:en
+	// docxTextWidth är platsen mellan marginalerna, för högerställda tabbar
+	docxTextWidth = docxPageWidth - 2*docxMargin
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+</Relationships>`
+
+// docxNumbering har en enda punktlista, numId 1
+const docxNumbering = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:abstractNum w:abstractNumId="0">
+<w:multiLevelType w:val="singleLevel"/>
+<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="360" w:hanging="360"/></w:pPr></w:lvl>
+</w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+</w:numbering>`
+
+// docxStyles tar accentfärgen som hex utan #
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="21"/><w:szCs w:val="21"/><w:color w:val="212529"/><w:lang w:val="sv-SE"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="60" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/><w:szCs w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Subtitle"><w:name w:val="Subtitle"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="120"/></w:pPr><w:rPr><w:color w:val="%[1]s"/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="280" w:after="100"/><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="%[1]s"/></w:pBdr><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:caps/><w:color w:val="%[1]s"/><w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:tabs><w:tab w:val="right" w:pos="%[2]d"/></w:tabs><w:spacing w:before="160" w:after="0"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/><w:szCs w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr><w:spacing w:after="20"/></w:pPr></w:style>
+<w:style w:type="character" w:styleId="Muted"><w:name w:val="Muted"/><w:rPr><w:color w:val="6C757D"/><w:sz w:val="18"/><w:szCs w:val="18"/></w:rPr></w:style>
+</w:styles>`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>%s</dc:title>
+<dc:creator>%s</dc:creator>
+<dc:language>sv-SE</dc:language>
+</cp:coreProperties>`
+
+// docxRun är text med samma formatering i ett stycke
+type docxRun struct {
+	text   string
+	bold   bool
+	italic bool
+	style  string // teckenformat, t.ex. "Muted"
+	color  string // hex utan #
+	// tab sätter en tabb före texten
+	tab bool
+}
+
+// docxDoc bygger upp word/document.xml
+type docxDoc struct {
+	style pdfStyle
+	body  strings.Builder
+}
+
+func newDOCXDoc(style pdfStyle) *docxDoc {
+	return &docxDoc{style: style}
+}
+
+// accent är mallens accentfärg som hex
+func (d *docxDoc) accent() string {
+	return hexColor(d.style.accent)
+}
+
+func hexColor(c rgb) string {
+	return fmt.Sprintf("%02X%02X%02X", c.r, c.g, c.b)
+}
+
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// paragraph skriver ett stycke med formatet style ("" för Normal) och
+// justeringen align ("" för vänster). Tomma stycken hoppas över.
+func (d *docxDoc) paragraph(style, align string, runs ...docxRun) {
+	empty := true
+	for _, r := range runs {
+		if strings.TrimSpace(r.text) != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return
+	}
+
+	d.body.WriteString("<w:p>")
+	if style != "" || align != "" {
+		d.body.WriteString("<w:pPr>")
+		if style != "" {
+			fmt.Fprintf(&d.body, `<w:pStyle w:val="%s"/>`, style)
+		}
+		if align != "" {
+			fmt.Fprintf(&d.body, `<w:jc w:val="%s"/>`, align)
+		}
+		d.body.WriteString("</w:pPr>")
+	}
+	for _, r := range runs {
+		d.run(r)
+	}
+	d.body.WriteString("</w:p>")
+}
+
+// run skriver en textbit; radbrytningar i texten blir w:br
+func (d *docxDoc) run(r docxRun) {
+	d.body.WriteString("<w:r>")
+	if r.bold || r.italic || r.style != "" || r.color != "" {
+		d.body.WriteString("<w:rPr>")
+		if r.style != "" {
+			fmt.Fprintf(&d.body, `<w:rStyle w:val="%s"/>`, r.style)
+		}
+		if r.bold {
+			d.body.WriteString("<w:b/>")
+		}
+		if r.italic {
+			d.body.WriteString("<w:i/>")
+		}
+		if r.color != "" {
+			fmt.Fprintf(&d.body, `<w:color w:val="%s"/>`, r.color)
+		}
+		d.body.WriteString("</w:rPr>")
+	}
+	if r.tab {
+		d.body.WriteString("<w:tab/>")
+	}
+	for i, line := range strings.Split(r.text, "\n") {
+		if i > 0 {
+			d.body.WriteString("<w:br/>")
+		}
+		fmt.Fprintf(&d.body, `<w:t xml:space="preserve">%s</w:t>`, escapeXML(line))
+	}
+	d.body.WriteString("</w:r>")
+}
+
+// text skriver ett vanligt stycke
+func (d *docxDoc) text(text string) {
+	d.paragraph("", "", docxRun{text: strings.TrimSpace(text)})
+}
+
+// paragraphs skriver text där tomma rader skiljer stycken åt
+func (d *docxDoc) paragraphs(text string) {
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		d.text(p)
+	}
+}
+
+func (d *docxDoc) heading(title string) {
+	d.paragraph("Heading1", "", docxRun{text: title})
+}
+
+func (d *docxDoc) bullet(text string) {
+	d.paragraph("ListBullet", "", docxRun{text: text})
+}
+
+// entry skriver en anställning eller utbildning med period i högerkanten
+func (d *docxDoc) entry(title, subtitle, period string, details []string) {
+	d.paragraph("Heading2", "",
+		docxRun{text: title},
+		docxRun{text: period, style: "Muted", tab: true},
+	)
+	d.paragraph("", "", docxRun{text: subtitle, italic: true, color: d.accent()})
+	for _, detail := range details {
+		d.bullet(detail)
+	}
+}
+
+// write packar dokumentet som en .docx-fil
+func (d *docxDoc) write(w io.Writer, title, author string) error {
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		d.body.String() +
+		fmt.Sprintf(`<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="%[3]d" w:right="%[3]d" w:bottom="%[3]d" w:left="%[3]d" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`,
+			docxPageWidth, docxPageHeight, docxMargin) +
+		`</w:body></w:document>`
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCore, escapeXML(title), escapeXML(author))},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/document.xml", document},
+		{"word/styles.xml", fmt.Sprintf(docxStyles, d.accent(), docxTextWidth)},
+		{"word/numbering.xml", docxNumbering},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("kunde inte skapa DOCX: %v", err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return fmt.Errorf("kunde inte skapa DOCX: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("kunde inte skapa DOCX: %v", err)
+	}
+	return nil
+}
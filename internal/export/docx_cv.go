@@ -0,0 +1,67 @@
+package export
+
+import (
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// WriteCVDOCX skriver cv som ett Word-dokument. Mallen templateID bestämmer
+// bara färgerna; uppställningen är en spalt oavsett mall.
+func WriteCVDOCX(w io.Writer, cv *data.TemplateData, templateID string) error {
+	style, ok := cvStyles[templateID]
+	if !ok {
+		style = cvStyles[""]
+	}
+	d := newDOCXDoc(style)
+
+	info := cv.PersonligInfo
+	d.paragraph("Title", "", docxRun{text: info.Namn})
+	d.paragraph("Subtitle", "", docxRun{text: info.Titel})
+	for _, line := range contacts(info) {
+		label, value, _ := strings.Cut(line, ": ")
+		d.paragraph("", "", docxRun{text: label + ": ", style: "Muted"}, docxRun{text: value})
+	}
+
+	if strings.TrimSpace(cv.Profil) != "" {
+		d.heading("Profil")
+		d.paragraphs(cv.Profil)
+	}
+	if len(cv.Arbetslivserfarenhet) > 0 {
+		d.heading("Arbetslivserfarenhet")
+		for _, exp := range cv.Arbetslivserfarenhet {
+			d.entry(exp.Titel, exp.Foretag, exp.Period, exp.Beskrivning)
+		}
+	}
+	if len(cv.Utbildning) > 0 {
+		d.heading("Utbildning")
+		for _, edu := range cv.Utbildning {
+			d.entry(edu.Examen, edu.Skola, edu.Period, edu.Beskrivning)
+		}
+	}
+	if len(cv.Fardigheter) > 0 {
+		d.heading("Färdigheter")
+		d.text(strings.Join(cv.Fardigheter, " · "))
+	}
+	if lines := languages(cv.Sprak); len(lines) > 0 {
+		d.heading("Språk")
+		for _, line := range lines {
+			d.bullet(line)
+		}
+	}
+	if len(cv.Projekt) > 0 {
+		d.heading("Projekt")
+		for _, p := range cv.Projekt {
+			d.bullet(p)
+		}
+	}
+	if len(cv.Certifieringar) > 0 {
+		d.heading("Certifieringar")
+		for _, c := range cv.Certifieringar {
+			d.bullet(c)
+		}
+	}
+
+	return d.write(w, "CV "+info.Namn, info.Namn)
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// WriteCoverLetterDOCX skriver brevet som ett Word-dokument i mallens färger
+func WriteCoverLetterDOCX(w io.Writer, letter *data.CoverLetterData, templateID string) error {
+	style, ok := letterStyles[templateID]
+	if !ok {
+		style = letterStyles[""]
+	}
+	d := newDOCXDoc(style)
+
+	sender := letter.PersonligInfo
+	d.paragraph("Title", "", docxRun{text: sender.Namn})
+	d.paragraph("Subtitle", "", docxRun{text: sender.Titel})
+	d.paragraph("", "", docxRun{text: strings.Join(contacts(sender), "\n"), style: "Muted"})
+
+	d.paragraph("", "right", docxRun{text: letter.Datum, style: "Muted"})
+	recipient := letter.Mottagare
+	var lines []string
+	for _, line := range []string{recipient.Namn, recipient.Position, recipient.Foretag, recipient.Adress, recipient.PostOrt} {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	d.text(strings.Join(lines, "\n"))
+
+	if letter.Jobb.Titel != "" {
+		d.paragraph("Heading2", "", docxRun{text: fmt.Sprintf("Ansökan: %s", letter.Jobb.Titel), color: d.accent()})
+	}
+
+	content := letter.Innehall
+	for _, part := range []string{content.Inledning, content.Huvudtext, content.Avslutning} {
+		d.paragraphs(part)
+	}
+	d.text(content.Halsningsfras)
+	d.paragraph("", "", docxRun{text: sender.Namn, bold: true})
+
+	return d.write(w, "Personligt brev "+letter.Jobb.Titel, sender.Namn)
+}
@@ -0,0 +1,208 @@
+// Package export gör om renderade CV och personliga brev till filer som
+// användaren kan ladda ned. Mallarna har samma ID:n som HTML-mallarna så
+// att en PDF ser ut som det CV användaren redan valt.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// Format är ett filformat som CV och brev kan exporteras till
+type Format string
+
+const (
+	// FormatHTML är standard; handlers svarar med JSON som innehåller HTML
+	FormatHTML Format = "html"
+	FormatPDF  Format = "pdf"
+	FormatDOCX Format = "docx"
+	// FormatText är vanlig text för rekryteringssystem (ATS)
+	FormatText       Format = "text"
+	FormatMarkdown   Format = "markdown"
+	FormatJSONResume Format = "jsonresume"
+)
+
+// formats är de format som kan väljas med format=, i den ordning de nämns i
+// felmeddelanden
+var formats = []struct {
+	format      Format
+	contentType string
+	extension   string
+	// letter anger om även personliga brev kan exporteras i formatet
+	letter bool
+}{
+	{FormatHTML, "text/html; charset=utf-8", "html", true},
+	{FormatPDF, "application/pdf", "pdf", true},
+	{FormatDOCX, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "docx", true},
+	{FormatText, "text/plain; charset=utf-8", "txt", false},
+	{FormatMarkdown, "text/markdown; charset=utf-8", "md", false},
+	{FormatJSONResume, "application/json", "json", false},
+}
+
+// ParseFormat tolkar format=. Tomt ger FormatHTML.
+func ParseFormat(value string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(value)))
+	if format == "" {
+		return FormatHTML, nil
+	}
+
+	var names []string
+	for _, f := range formats {
+		if f.format == format {
+			return format, nil
+		}
+		names = append(names, string(f.format))
+	}
+	last := len(names) - 1
+	return "", fmt.Errorf("okänt format '%s' (använd %s eller %s)", value, strings.Join(names[:last], ", "), names[last])
+}
+
+// ContentType är formatets MIME-typ
+func (f Format) ContentType() string {
+	for _, format := range formats {
+		if format.format == f {
+			return format.contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Extension är formatets filändelse utan punkt
+func (f Format) Extension() string {
+	for _, format := range formats {
+		if format.format == f {
+			return format.extension
+		}
+	}
+	return string(f)
+}
+
+// ForCoverLetter anger om personliga brev kan exporteras i formatet. De
+// textbaserade formaten finns bara för CV.
+func (f Format) ForCoverLetter() bool {
+	for _, format := range formats {
+		if format.format == f {
+			return format.letter
+		}
+	}
+	return false
+}
+
+// Filename sätter ihop ett filnamn för nedladdning, t.ex. "cv-anna-andersson.pdf"
+func (f Format) Filename(base string, parts ...string) string {
+	name := base
+	for _, part := range parts {
+		if slug := slugify(part); slug != "" {
+			name += "-" + slug
+		}
+	}
+	return name + "." + f.Extension()
+}
+
+// WriteCV skriver cv som en fil i formatet. HTML renderas av handlers.
+func WriteCV(w io.Writer, format Format, cv *data.TemplateData, templateID string) error {
+	switch format {
+	case FormatPDF:
+		return WriteCVPDF(w, cv, templateID)
+	case FormatDOCX:
+		return WriteCVDOCX(w, cv, templateID)
+	case FormatText:
+		return WriteCVText(w, cvData(cv))
+	case FormatMarkdown:
+		return WriteCVMarkdown(w, cvData(cv))
+	case FormatJSONResume:
+		return WriteCVJSONResume(w, cvData(cv))
+	}
+	return fmt.Errorf("CV kan inte exporteras som %s", format)
+}
+
+// cvData gör om mallarnas data till CVData för de textbaserade formaten
+func cvData(t *data.TemplateData) *data.CVData {
+	return &data.CVData{
+		PersonligInfo:        t.PersonligInfo,
+		Fardigheter:          t.Fardigheter,
+		Sprak:                t.Sprak,
+		Profil:               t.Profil,
+		Arbetslivserfarenhet: t.Arbetslivserfarenhet,
+		Utbildning:           t.Utbildning,
+		Projekt:              t.Projekt,
+		Certifieringar:       t.Certifieringar,
+	}
+}
+
+// WriteCoverLetter skriver brevet som en fil i formatet. HTML renderas av handlers.
+func WriteCoverLetter(w io.Writer, format Format, letter *data.CoverLetterData, templateID string) error {
+	switch format {
+	case FormatPDF:
+		return WriteCoverLetterPDF(w, letter, templateID)
+	case FormatDOCX:
+		return WriteCoverLetterDOCX(w, letter, templateID)
+	}
+	return fmt.Errorf("brevet kan inte exporteras som %s", format)
+}
+
+// slugify gör om text till gemena ASCII-ord med bindestreck
+func slugify(text string) string {
+	replacer := strings.NewReplacer("å", "a", "ä", "a", "ö", "o", "é", "e", "ü", "u")
+	text = replacer.Replace(strings.ToLower(text))
+
+	var b strings.Builder
+	dash := false
+	for _, r := range text {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// kontaktLabels är rubrikerna för kontaktuppgifterna. HTML-mallarna visar
+// emoji, som varken finns i PDF:ens teckensnitt eller passar i Word.
+var kontaktLabels = map[string]string{
+	"email":     "E-post",
+	"telefon":   "Telefon",
+	"adress":    "Adress",
+	"linkedin":  "LinkedIn",
+	"github":    "GitHub",
+	"portfolio": "Portfolio",
+}
+
+// contacts returnerar kontaktuppgifterna som "Rubrik: värde". Platshållare
+// som "LinkedIn" utan adress hoppas över.
+func contacts(info data.PersonligInfo) []string {
+	var lines []string
+	for _, item := range info.Kontakt {
+		value := strings.TrimSpace(item.Varde)
+		label := kontaktLabels[item.Typ]
+		if label == "" {
+			label = item.Typ
+		}
+		if value == "" || strings.EqualFold(value, label) {
+			continue
+		}
+		lines = append(lines, label+": "+value)
+	}
+	return lines
+}
+
+// languages returnerar språken som "Svenska – Modersmål"
+func languages(sprak []data.Sprak) []string {
+	var lines []string
+	for _, s := range sprak {
+		switch {
+		case s.Sprak == "":
+		case s.Niva == "":
+			lines = append(lines, s.Sprak)
+		default:
+			lines = append(lines, s.Sprak+" – "+s.Niva)
+		}
+	}
+	return lines
+}
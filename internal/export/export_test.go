@@ -0,0 +1,218 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/data"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Format
+		err   bool
+	}{
+		{"", FormatHTML, false},
+		{"html", FormatHTML, false},
+		{" PDF ", FormatPDF, false},
+		{"docx", FormatDOCX, false},
+		{"Markdown", FormatMarkdown, false},
+		{"jsonresume", FormatJSONResume, false},
+		{"odt", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.value)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v", tt.value, got, err)
+		}
+	}
+
+	if _, err := ParseFormat("odt"); err == nil || !strings.Contains(err.Error(), "html, pdf, docx, text, markdown eller jsonresume") {
+		t.Errorf("felet ska lista formaten: %v", err)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	if got := FormatPDF.Filename("cv", "Åsa Öberg-Ängström", ""); got != "cv-asa-oberg-angstrom.pdf" {
+		t.Errorf("Filename = %q", got)
+	}
+	if got := FormatPDF.Filename("personligt-brev", "!!!"); got != "personligt-brev.pdf" {
+		t.Errorf("Filename = %q", got)
+	}
+}
+
+// longCV har fler uppgifter än som får plats på en sida, både i huvudspalten
+// och i sidospalten
+func longCV() *data.TemplateData {
+	cv := &data.TemplateData{
+		PersonligInfo: data.PersonligInfo{
+			Namn:  "Åsa Öberg",
+			Titel: "Systemutvecklare",
+			Kontakt: []data.KontaktItem{
+				{Typ: "email", Varde: "asa@example.se", Ikon: "📧"},
+				{Typ: "telefon", Varde: "070-000 00 00", Ikon: "📱"},
+				{Typ: "linkedin", Varde: "LinkedIn", Ikon: "🔗"},
+			},
+		},
+		Profil: "Erfaren utvecklare från Göteborg.\n\nTrivs med både backend och drift.",
+		Sprak:  []data.Sprak{{Sprak: "Svenska", Niva: "Modersmål"}, {Sprak: "Engelska"}},
+	}
+	for i := 0; i < 12; i++ {
+		cv.Arbetslivserfarenhet = append(cv.Arbetslivserfarenhet, data.Arbetslivserfarenhet{
+			Titel:       "Utvecklare",
+			Foretag:     fmt.Sprintf("Företag %d AB", i),
+			Period:      "2015 – 2020",
+			Beskrivning: []string{"Byggde tjänster i Go.", "Förbättrade övervakningen av plattformen."},
+		})
+	}
+	for i := 0; i < 40; i++ {
+		cv.Fardigheter = append(cv.Fardigheter, fmt.Sprintf("Färdighet %d", i))
+	}
+	cv.Utbildning = []data.Utbildning{{Examen: "Civilingenjör", Skola: "Chalmers", Period: "2010 – 2015"}}
+	cv.Certifieringar = []string{"Certifiering A", "Certifiering B"}
+	return cv
+}
+
+func TestWriteCVPDF(t *testing.T) {
+	for _, id := range []string{"", "modern", "creative", "cv3", "okänd"} {
+		var buf bytes.Buffer
+		if err := WriteCVPDF(&buf, longCV(), id); err != nil {
+			t.Fatalf("mall %q: %v", id, err)
+		}
+		pdf := buf.String()
+		if !strings.HasPrefix(pdf, "%PDF-") {
+			t.Errorf("mall %q: svaret är ingen PDF", id)
+		}
+		if !strings.Contains(pdf, "/FontFile2") {
+			t.Errorf("mall %q: teckensnitten är inte inbäddade", id)
+		}
+		if strings.Count(pdf, "/Type /Page\n") < 2 {
+			t.Errorf("mall %q: det långa CV:t får plats på en sida", id)
+		}
+	}
+}
+
+func TestWriteCoverLetterPDF(t *testing.T) {
+	letter := &data.CoverLetterData{
+		PersonligInfo: data.PersonligInfo{Namn: "Åsa Öberg", Titel: "Systemutvecklare"},
+		Mottagare:     data.Mottagare{Namn: "Rekryteraren", Foretag: "Exempelbolaget AB"},
+		Innehall: data.Innehall{
+			Inledning:     "Hej!",
+			Huvudtext:     "Jag söker tjänsten.\n\nJag har arbetat med Go i många år.",
+			Avslutning:    "Jag ser fram emot att höra av er.",
+			Halsningsfras: "Med vänliga hälsningar",
+		},
+		Datum: "2024-01-01",
+		Jobb:  data.Jobb{Titel: "Backendutvecklare"},
+	}
+	for _, id := range []string{"", "creative", "v2"} {
+		var buf bytes.Buffer
+		if err := WriteCoverLetterPDF(&buf, letter, id); err != nil {
+			t.Fatalf("mall %q: %v", id, err)
+		}
+		if pdf := buf.String(); !strings.HasPrefix(pdf, "%PDF-") || !strings.Contains(pdf, "/FontFile2") {
+			t.Errorf("mall %q: ingen PDF med inbäddade teckensnitt", id)
+		}
+	}
+}
+
+// docxParts packar upp en .docx-fil och kontrollerar att alla delar är
+// välformad XML
+func docxParts(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("ingen zip-fil: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s är inte giltig XML: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/numbering.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("%s saknas", name)
+		}
+	}
+	return parts
+}
+
+func TestWriteCVDOCX(t *testing.T) {
+	cv := longCV()
+	cv.Profil = "Gillar <Go> & \"Rust\""
+
+	var buf bytes.Buffer
+	if err := WriteCVDOCX(&buf, cv, "creative"); err != nil {
+		t.Fatal(err)
+	}
+	parts := docxParts(t, buf.Bytes())
+
+	document := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Åsa Öberg</w:t>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Arbetslivserfarenhet</w:t>`,
+		`<w:pStyle w:val="ListBullet"/></w:pPr><w:r><w:t xml:space="preserve">Byggde tjänster i Go.</w:t>`,
+		`<w:t xml:space="preserve">E-post: </w:t></w:r><w:r><w:t xml:space="preserve">asa@example.se</w:t>`,
+		"Gillar &lt;Go&gt; &amp; &#34;Rust&#34;",
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("dokumentet saknar %s", want)
+		}
+	}
+	if strings.Contains(document, "LinkedIn") {
+		t.Error("platshållaren för LinkedIn ska inte med")
+	}
+	if !strings.Contains(parts["word/styles.xml"], `w:color w:val="8E44AD"`) {
+		t.Error("rubrikerna har inte mallens färg")
+	}
+	if !strings.Contains(parts["docProps/core.xml"], "<dc:title>CV Åsa Öberg</dc:title>") {
+		t.Error("dokumentet saknar titel")
+	}
+}
+
+func TestWriteCoverLetterDOCX(t *testing.T) {
+	letter := &data.CoverLetterData{
+		PersonligInfo: data.PersonligInfo{Namn: "Åsa Öberg", Kontakt: []data.KontaktItem{{Typ: "email", Varde: "asa@example.se"}}},
+		Mottagare:     data.Mottagare{Foretag: "Exempelbolaget AB", PostOrt: "Göteborg"},
+		Innehall:      data.Innehall{Inledning: "Hej!", Huvudtext: "Första stycket.\n\nAndra stycket.", Halsningsfras: "Med vänliga hälsningar"},
+		Datum:         "2024-01-01",
+		Jobb:          data.Jobb{Titel: "Backendutvecklare"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCoverLetterDOCX(&buf, letter, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	document := docxParts(t, buf.Bytes())["word/document.xml"]
+	for _, want := range []string{
+		"Exempelbolaget AB</w:t><w:br/><w:t xml:space=\"preserve\">Göteborg",
+		"Ansökan: Backendutvecklare",
+		`<w:p><w:r><w:t xml:space="preserve">Andra stycket.</w:t></w:r></w:p>`,
+		`<w:jc w:val="right"/>`,
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("brevet saknar %s", want)
+		}
+	}
+}
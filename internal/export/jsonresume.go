@@ -0,0 +1,381 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// JSONResumeSchema är schemat som exporterade filer hänvisar till
+const JSONResumeSchema = "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json"
+
+// JSONResume är de delar av JSON Resume (https://jsonresume.org/schema) som
+// motsvarar data.CVData. Övriga fält i en importerad fil ignoreras.
+type JSONResume struct {
+	Schema       string                  `json:"$schema,omitempty"`
+	Basics       JSONResumeBasics        `json:"basics"`
+	Work         []JSONResumeWork        `json:"work,omitempty"`
+	Education    []JSONResumeEducation   `json:"education,omitempty"`
+	Skills       []JSONResumeSkill       `json:"skills,omitempty"`
+	Languages    []JSONResumeLanguage    `json:"languages,omitempty"`
+	Certificates []JSONResumeCertificate `json:"certificates,omitempty"`
+	Projects     []JSONResumeProject     `json:"projects,omitempty"`
+}
+
+type JSONResumeBasics struct {
+	Name     string              `json:"name,omitempty"`
+	Label    string              `json:"label,omitempty"`
+	Image    string              `json:"image,omitempty"`
+	Email    string              `json:"email,omitempty"`
+	Phone    string              `json:"phone,omitempty"`
+	URL      string              `json:"url,omitempty"`
+	Summary  string              `json:"summary,omitempty"`
+	Location *JSONResumeLocation `json:"location,omitempty"`
+	Profiles []JSONResumeProfile `json:"profiles,omitempty"`
+}
+
+type JSONResumeLocation struct {
+	Address     string `json:"address,omitempty"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	City        string `json:"city,omitempty"`
+	CountryCode string `json:"countryCode,omitempty"`
+	Region      string `json:"region,omitempty"`
+}
+
+type JSONResumeProfile struct {
+	Network  string `json:"network,omitempty"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type JSONResumeWork struct {
+	Name       string   `json:"name,omitempty"`
+	Position   string   `json:"position,omitempty"`
+	StartDate  string   `json:"startDate,omitempty"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+type JSONResumeEducation struct {
+	Institution string   `json:"institution,omitempty"`
+	Area        string   `json:"area,omitempty"`
+	StudyType   string   `json:"studyType,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	Courses     []string `json:"courses,omitempty"`
+}
+
+type JSONResumeSkill struct {
+	Name     string   `json:"name,omitempty"`
+	Level    string   `json:"level,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type JSONResumeLanguage struct {
+	Language string `json:"language,omitempty"`
+	Fluency  string `json:"fluency,omitempty"`
+}
+
+type JSONResumeCertificate struct {
+	Name   string `json:"name,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Issuer string `json:"issuer,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type JSONResumeProject struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Highlights  []string `json:"highlights,omitempty"`
+}
+
+// profileNetworks är kontakttyperna som blir profiler i JSON Resume
+var profileNetworks = map[string]string{
+	"linkedin": "LinkedIn",
+	"github":   "GitHub",
+}
+
+// ToJSONResume gör om cv till JSON Resume. Perioder som "2019 – nu" blir
+// startDate och endDate; perioder som inte går att tolka som datum utelämnas.
+func ToJSONResume(cv *data.CVData) *JSONResume {
+	info := cv.PersonligInfo
+	resume := &JSONResume{
+		Schema: JSONResumeSchema,
+		Basics: JSONResumeBasics{
+			Name:    info.Namn,
+			Label:   info.Titel,
+			Summary: strings.TrimSpace(cv.Profil),
+		},
+	}
+	// Platshållarbilden från AI-flödet hör inte hemma i en export
+	if !strings.Contains(info.Bild, "placeholder") {
+		resume.Basics.Image = info.Bild
+	}
+
+	for _, item := range info.Kontakt {
+		value := strings.TrimSpace(item.Varde)
+		if value == "" || strings.EqualFold(value, kontaktLabels[item.Typ]) {
+			continue
+		}
+		switch item.Typ {
+		case "email":
+			resume.Basics.Email = value
+		case "telefon":
+			resume.Basics.Phone = value
+		case "adress":
+			resume.Basics.Location = &JSONResumeLocation{Address: value}
+		case "portfolio":
+			resume.Basics.URL = value
+		case "linkedin", "github":
+			profile := JSONResumeProfile{Network: profileNetworks[item.Typ]}
+			if strings.Contains(value, "/") {
+				profile.URL = value
+			} else {
+				profile.Username = value
+			}
+			resume.Basics.Profiles = append(resume.Basics.Profiles, profile)
+		}
+	}
+
+	for _, exp := range cv.Arbetslivserfarenhet {
+		start, end := parsePeriod(exp.Period)
+		resume.Work = append(resume.Work, JSONResumeWork{
+			Name:       exp.Foretag,
+			Position:   exp.Titel,
+			StartDate:  start,
+			EndDate:    end,
+			Highlights: exp.Beskrivning,
+		})
+	}
+	for _, edu := range cv.Utbildning {
+		start, end := parsePeriod(edu.Period)
+		resume.Education = append(resume.Education, JSONResumeEducation{
+			Institution: edu.Skola,
+			StudyType:   edu.Examen,
+			StartDate:   start,
+			EndDate:     end,
+			Courses:     edu.Beskrivning,
+		})
+	}
+	for _, skill := range cv.Fardigheter {
+		resume.Skills = append(resume.Skills, JSONResumeSkill{Name: skill})
+	}
+	for _, s := range cv.Sprak {
+		resume.Languages = append(resume.Languages, JSONResumeLanguage{Language: s.Sprak, Fluency: s.Niva})
+	}
+	for _, c := range cv.Certifieringar {
+		resume.Certificates = append(resume.Certificates, JSONResumeCertificate{Name: c})
+	}
+	for _, p := range cv.Projekt {
+		resume.Projects = append(resume.Projects, JSONResumeProject{Name: p})
+	}
+
+	return resume
+}
+
+// FromJSONResume gör om en JSON Resume till CVData. Uppgifter som saknar
+// motsvarighet i CVData, som projektens länkar, följer inte med.
+func FromJSONResume(resume *JSONResume) *data.CVData {
+	basics := resume.Basics
+	cv := &data.CVData{
+		PersonligInfo: data.PersonligInfo{
+			Namn:    strings.TrimSpace(basics.Name),
+			Titel:   strings.TrimSpace(basics.Label),
+			Bild:    basics.Image,
+			Kontakt: []data.KontaktItem{},
+		},
+		Profil:               strings.TrimSpace(basics.Summary),
+		Fardigheter:          []string{},
+		Sprak:                []data.Sprak{},
+		Arbetslivserfarenhet: []data.Arbetslivserfarenhet{},
+		Utbildning:           []data.Utbildning{},
+		Projekt:              []string{},
+		Certifieringar:       []string{},
+	}
+
+	kontakt := func(typ, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			cv.PersonligInfo.Kontakt = append(cv.PersonligInfo.Kontakt, data.KontaktItem{Typ: typ, Varde: value})
+		}
+	}
+	kontakt("email", basics.Email)
+	kontakt("telefon", basics.Phone)
+	if loc := basics.Location; loc != nil {
+		kontakt("adress", joinNonEmpty(", ", loc.Address, joinNonEmpty(" ", loc.PostalCode, loc.City)))
+	}
+	for _, profile := range basics.Profiles {
+		for typ, network := range profileNetworks {
+			if strings.EqualFold(profile.Network, network) {
+				kontakt(typ, firstNonEmpty(profile.URL, profile.Username))
+			}
+		}
+	}
+	kontakt("portfolio", basics.URL)
+
+	for _, work := range resume.Work {
+		cv.Arbetslivserfarenhet = append(cv.Arbetslivserfarenhet, data.Arbetslivserfarenhet{
+			Titel:       strings.TrimSpace(work.Position),
+			Foretag:     strings.TrimSpace(work.Name),
+			Period:      formatPeriod(work.StartDate, work.EndDate),
+			Beskrivning: nonEmpty(append([]string{work.Summary}, work.Highlights...)),
+		})
+	}
+	for _, edu := range resume.Education {
+		examen := strings.TrimSpace(edu.StudyType)
+		if area := strings.TrimSpace(edu.Area); area != "" {
+			examen = joinNonEmpty(" i ", examen, area)
+		}
+		cv.Utbildning = append(cv.Utbildning, data.Utbildning{
+			Examen:      examen,
+			Skola:       strings.TrimSpace(edu.Institution),
+			Period:      formatPeriod(edu.StartDate, edu.EndDate),
+			Beskrivning: nonEmpty(edu.Courses),
+		})
+	}
+	for _, skill := range resume.Skills {
+		name := strings.TrimSpace(skill.Name)
+		if keywords := joinNonEmpty(", ", skill.Keywords...); keywords != "" {
+			name = joinNonEmpty(": ", name, keywords)
+		}
+		if name != "" {
+			cv.Fardigheter = append(cv.Fardigheter, name)
+		}
+	}
+	for _, lang := range resume.Languages {
+		if language := strings.TrimSpace(lang.Language); language != "" {
+			cv.Sprak = append(cv.Sprak, data.Sprak{Sprak: language, Niva: strings.TrimSpace(lang.Fluency)})
+		}
+	}
+	for _, c := range resume.Certificates {
+		if name := joinNonEmpty(", ", c.Name, c.Issuer); name != "" {
+			cv.Certifieringar = append(cv.Certifieringar, name)
+		}
+	}
+	for _, p := range resume.Projects {
+		if project := joinNonEmpty(": ", p.Name, p.Description); project != "" {
+			cv.Projekt = append(cv.Projekt, project)
+		}
+	}
+
+	return cv
+}
+
+// WriteCVJSONResume skriver cv som en JSON Resume-fil
+func WriteCVJSONResume(w io.Writer, cv *data.CVData) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(ToJSONResume(cv)); err != nil {
+		return fmt.Errorf("kunde inte skapa JSON Resume: %v", err)
+	}
+	return nil
+}
+
+// ReadJSONResume läser en JSON Resume-fil och gör om den till CVData
+func ReadJSONResume(r io.Reader) (*data.CVData, error) {
+	var resume JSONResume
+	if err := json.NewDecoder(r).Decode(&resume); err != nil {
+		return nil, fmt.Errorf("ogiltig JSON Resume: %v", err)
+	}
+	return FromJSONResume(&resume), nil
+}
+
+var (
+	// isoDate är de datumformat som JSON Resume tillåter: år, år-månad och
+	// år-månad-dag
+	isoDate = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+	// monthYear är t.ex. "jan 2019" eller "januari 2019"
+	monthYear = regexp.MustCompile(`^(?i)(\pL+)\.?\s+(\d{4})$`)
+	// periodSeparator delar "2015 – 2020" och "jan 2019 - nu"
+	periodSeparator = regexp.MustCompile(`\s*[–—]\s*|\s+-\s+`)
+	// yearRange är "2015-2020" och "2019-nu" utan blanksteg, där bindestrecket
+	// annars kunde höra till ett datum som "2019-05"
+	yearRange = regexp.MustCompile(`^(\d{4})-(\d{4}|\pL+)$`)
+	ongoing   = map[string]bool{"nu": true, "idag": true, "pågående": true, "present": true, "now": true, "current": true, "ongoing": true}
+)
+
+var months = map[string]string{
+	"jan": "01", "feb": "02", "mar": "03", "apr": "04", "maj": "05", "may": "05", "jun": "06",
+	"jul": "07", "aug": "08", "sep": "09", "okt": "10", "oct": "10", "nov": "11", "dec": "12",
+}
+
+// parsePeriod delar en period som "2015 – 2020" eller "jan 2019 - nu" i
+// datum enligt ISO 8601. Pågående perioder får inget slutdatum.
+func parsePeriod(period string) (start, end string) {
+	period = strings.TrimSpace(period)
+	if period == "" {
+		return "", ""
+	}
+
+	parts := periodSeparator.Split(period, 2)
+	if m := yearRange.FindStringSubmatch(period); m != nil {
+		parts = m[1:]
+	}
+
+	start = parseDate(parts[0])
+	if len(parts) == 2 {
+		if end = parseDate(parts[1]); end == "" && !ongoing[strings.ToLower(strings.TrimSpace(parts[1]))] {
+			return "", ""
+		}
+	}
+	if start == "" {
+		return "", ""
+	}
+	return start, end
+}
+
+// parseDate tolkar "2019", "2019-03" eller "mars 2019"
+func parseDate(text string) string {
+	text = strings.TrimSpace(text)
+	if isoDate.MatchString(text) {
+		return text
+	}
+	if m := monthYear.FindStringSubmatch(text); m != nil {
+		name := []rune(strings.ToLower(m[1]))
+		if len(name) >= 3 {
+			if month, ok := months[string(name[:3])]; ok {
+				return m[2] + "-" + month
+			}
+		}
+	}
+	return ""
+}
+
+// formatPeriod skriver ett datumintervall som CV-mallarna visar det
+func formatPeriod(start, end string) string {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	switch {
+	case start == "" && end == "":
+		return ""
+	case start == "":
+		return end
+	case end == "":
+		return start + " – nu"
+	}
+	return start + " – " + end
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// nonEmpty returnerar de icke-tomma strängarna, aldrig nil
+func nonEmpty(values []string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/data"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		period, start, end string
+	}{
+		{"2015 – 2020", "2015", "2020"},
+		{"2015-2020", "2015", "2020"},
+		{"2019-03 - 2021-11", "2019-03", "2021-11"},
+		{"jan 2020 – nu", "2020-01", ""},
+		{"Mars 2018 — oktober 2019", "2018-03", "2019-10"},
+		{"2019-pågående", "2019", ""},
+		{"2021", "2021", ""},
+		{"sommaren 2018", "", ""},
+		{"2015 – ett tag", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if start, end := parsePeriod(tt.period); start != tt.start || end != tt.end {
+			t.Errorf("parsePeriod(%q) = %q, %q, vill ha %q, %q", tt.period, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestToJSONResume(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCVJSONResume(&buf, sampleCV()); err != nil {
+		t.Fatal(err)
+	}
+
+	var resume JSONResume
+	if err := json.Unmarshal(buf.Bytes(), &resume); err != nil {
+		t.Fatal(err)
+	}
+	if resume.Schema != JSONResumeSchema || resume.Basics.Name != "Åsa Öberg" || resume.Basics.Email != "asa@example.se" {
+		t.Errorf("basics = %+v", resume.Basics)
+	}
+	if loc := resume.Basics.Location; loc == nil || loc.Address != "Göteborg" {
+		t.Errorf("location = %+v", loc)
+	}
+	// GitHub är bara en platshållare
+	if want := []JSONResumeProfile{{Network: "LinkedIn", URL: "linkedin.com/in/asaoberg"}}; !reflect.DeepEqual(resume.Basics.Profiles, want) {
+		t.Errorf("profiles = %+v", resume.Basics.Profiles)
+	}
+	work := resume.Work[0]
+	if work.Name != "Exempelbolaget AB" || work.Position != "Backendutvecklare" || work.StartDate != "2020-01" || work.EndDate != "" {
+		t.Errorf("work = %+v", work)
+	}
+	if len(resume.Skills) != 2 || resume.Skills[1].Name != "C#" {
+		t.Errorf("skills = %+v", resume.Skills)
+	}
+	if len(resume.Languages) != 1 || resume.Languages[0].Fluency != "Modersmål" {
+		t.Errorf("languages = %+v", resume.Languages)
+	}
+	if strings.Contains(buf.String(), `\u00`) {
+		t.Error("å, ä och ö ska inte kodas om")
+	}
+}
+
+func TestJSONResumeRoundTrip(t *testing.T) {
+	cv := sampleCV()
+	cv.PersonligInfo.Kontakt = cv.PersonligInfo.Kontakt[:3]
+
+	got := FromJSONResume(ToJSONResume(cv))
+	// Perioderna skrivs om till samma form som mallarna visar
+	cv.Arbetslivserfarenhet[0].Period = "2020-01 – nu"
+	cv.Arbetslivserfarenhet[1].Period = "2015 – 2019"
+	cv.Arbetslivserfarenhet[1].Beskrivning = []string{}
+	cv.Utbildning[0].Beskrivning = []string{}
+	cv.Projekt = []string{}
+
+	if !reflect.DeepEqual(got, cv) {
+		t.Errorf("efter export och import:\n%+v\nvill ha\n%+v", got, cv)
+	}
+}
+
+func TestReadJSONResume(t *testing.T) {
+	input := `{
+		"basics": {
+			"name": "Richard Hendriks",
+			"label": "Programmer",
+			"email": "richard@example.com",
+			"url": "https://richardhendriks.example.com",
+			"summary": "Richard hails from Tulsa.",
+			"location": {"address": "2712 Broadway St", "postalCode": "CA 94115", "city": "San Francisco", "countryCode": "US"},
+			"profiles": [{"network": "Twitter", "username": "neutralthoughts"}, {"network": "github", "username": "richard"}]
+		},
+		"work": [{"name": "Pied Piper", "position": "CEO/President", "startDate": "2013-12-01", "summary": "Pied Piper is a compression company.", "highlights": ["Build an algorithm"]}],
+		"volunteer": [{"organization": "CoderDojo"}],
+		"education": [{"institution": "University", "area": "Information Technology", "studyType": "Bachelor", "startDate": "2011-01-01", "endDate": "2013-01-01", "courses": ["DB1101 - Basic SQL"]}],
+		"skills": [{"name": "Web Development", "level": "Master", "keywords": ["HTML", "CSS"]}],
+		"languages": [{"language": "English", "fluency": "Native speaker"}],
+		"certificates": [{"name": "Certificate", "issuer": "Company"}],
+		"projects": [{"name": "Miss Direction", "description": "A mapping engine"}]
+	}`
+
+	cv, err := ReadJSONResume(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &data.CVData{
+		PersonligInfo: data.PersonligInfo{
+			Namn:  "Richard Hendriks",
+			Titel: "Programmer",
+			Kontakt: []data.KontaktItem{
+				{Typ: "email", Varde: "richard@example.com"},
+				{Typ: "adress", Varde: "2712 Broadway St, CA 94115 San Francisco"},
+				{Typ: "github", Varde: "richard"},
+				{Typ: "portfolio", Varde: "https://richardhendriks.example.com"},
+			},
+		},
+		Profil:               "Richard hails from Tulsa.",
+		Fardigheter:          []string{"Web Development: HTML, CSS"},
+		Sprak:                []data.Sprak{{Sprak: "English", Niva: "Native speaker"}},
+		Arbetslivserfarenhet: []data.Arbetslivserfarenhet{{Titel: "CEO/President", Foretag: "Pied Piper", Period: "2013-12-01 – nu", Beskrivning: []string{"Pied Piper is a compression company.", "Build an algorithm"}}},
+		Utbildning:           []data.Utbildning{{Examen: "Bachelor i Information Technology", Skola: "University", Period: "2011-01-01 – 2013-01-01", Beskrivning: []string{"DB1101 - Basic SQL"}}},
+		Projekt:              []string{"Miss Direction: A mapping engine"},
+		Certifieringar:       []string{"Certificate, Company"},
+	}
+	if !reflect.DeepEqual(cv, want) {
+		t.Errorf("CV =\n%+v\nvill ha\n%+v", cv, want)
+	}
+
+	if _, err := ReadJSONResume(strings.NewReader(`{"basics": []}`)); err == nil {
+		t.Error("ogiltig JSON Resume ska ge fel")
+	}
+}
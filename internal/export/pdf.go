@@ -0,0 +1,202 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/gofont/gosmallcaps"
+)
+
+// Teckensnitten bäddas in i varje PDF. Go-typsnitten täcker hela Latin-1,
+// alltså även å, ä och ö, och följer med som Go-paket.
+const (
+	fontText      = "Go"
+	fontSmallCaps = "GoSmallCaps"
+)
+
+// A4 i millimeter
+const (
+	pageWidth  = 210.0
+	pageHeight = 297.0
+	margin     = 18.0
+)
+
+type rgb struct{ r, g, b int }
+
+var (
+	textColor  = rgb{33, 37, 41}
+	mutedColor = rgb{108, 117, 125}
+	white      = rgb{255, 255, 255}
+)
+
+// pdfLayout är de olika sätten att ställa upp sidan
+type pdfLayout int
+
+const (
+	layoutClassic pdfLayout = iota // en spalt med namnet överst
+	layoutSidebar                  // färgad sidospalt med kontakt och färdigheter
+	layoutBanner                   // färgat fält överst med namnet
+	layoutMinimal                  // kapitäler och tunna linjer
+)
+
+// pdfStyle motsvarar en HTML-mall
+type pdfStyle struct {
+	layout pdfLayout
+	accent rgb
+	// tint är sidospaltens bakgrund
+	tint rgb
+}
+
+// cvStyles har samma ID:n som CV-mallarna i handlers
+var cvStyles = map[string]pdfStyle{
+	"":         {layout: layoutClassic, accent: rgb{44, 62, 80}},
+	"modern":   {layout: layoutSidebar, accent: rgb{52, 152, 219}, tint: rgb{235, 244, 251}},
+	"creative": {layout: layoutBanner, accent: rgb{142, 68, 173}},
+	"cv3":      {layout: layoutMinimal, accent: rgb{73, 80, 87}},
+}
+
+// letterStyles har samma ID:n som brevmallarna; standard är den kreativa
+var letterStyles = map[string]pdfStyle{
+	"":         {layout: layoutBanner, accent: rgb{142, 68, 173}},
+	"creative": {layout: layoutBanner, accent: rgb{142, 68, 173}},
+	"v2":       {layout: layoutClassic, accent: rgb{44, 62, 80}},
+}
+
+// pdfDoc skriver text i en spalt som börjar på x och är w bred
+type pdfDoc struct {
+	pdf   *fpdf.Fpdf
+	style pdfStyle
+	x, w  float64
+}
+
+func newPDFDoc(style pdfStyle, title, author string) *pdfDoc {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontText, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontText, "B", gobold.TTF)
+	pdf.AddUTF8FontFromBytes(fontText, "I", goitalic.TTF)
+	pdf.AddUTF8FontFromBytes(fontText, "BI", gobolditalic.TTF)
+	pdf.AddUTF8FontFromBytes(fontSmallCaps, "", gosmallcaps.TTF)
+	pdf.SetTitle(title, true)
+	pdf.SetAuthor(author, true)
+	pdf.SetCreator("awesomeProject", true)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+
+	return &pdfDoc{pdf: pdf, style: style, x: margin, w: pageWidth - 2*margin}
+}
+
+func (d *pdfDoc) color(c rgb) {
+	d.pdf.SetTextColor(c.r, c.g, c.b)
+}
+
+func (d *pdfDoc) font(family, style string, size float64) {
+	d.pdf.SetFont(family, style, size)
+}
+
+// column flyttar skrivpositionen till en spalt; nya sidor börjar i samma spalt
+func (d *pdfDoc) column(x, w float64) {
+	d.x, d.w = x, w
+	d.pdf.SetLeftMargin(x)
+	d.pdf.SetRightMargin(pageWidth - x - w)
+	d.pdf.SetX(x)
+}
+
+// text skriver ett stycke med radbrytning
+func (d *pdfDoc) text(text string, size float64, style string, c rgb) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	d.font(fontText, style, size)
+	d.color(c)
+	d.pdf.SetX(d.x)
+	d.pdf.MultiCell(d.w, size*0.5, text, "", "L", false)
+}
+
+// paragraphs skriver text där tomma rader skiljer stycken åt
+func (d *pdfDoc) paragraphs(text string) {
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		d.text(p, 10.5, "", textColor)
+		d.pdf.Ln(2.5)
+	}
+}
+
+// heading skriver en rubrik för ett avsnitt
+func (d *pdfDoc) heading(title string) {
+	d.pdf.Ln(3)
+	// Rubriken ska inte hamna ensam längst ned på en sida
+	if d.pdf.GetY() > pageHeight-margin-25 {
+		d.pdf.AddPage()
+	}
+	d.pdf.SetX(d.x)
+	d.color(d.style.accent)
+
+	switch d.style.layout {
+	case layoutMinimal:
+		d.font(fontSmallCaps, "", 12)
+		d.pdf.CellFormat(d.w, 7, title, "", 1, "L", false, 0, "")
+		d.pdf.SetDrawColor(206, 212, 218)
+		d.pdf.SetLineWidth(0.2)
+	default:
+		d.font(fontText, "B", 12)
+		d.pdf.CellFormat(d.w, 7, strings.ToUpper(title), "", 1, "L", false, 0, "")
+		d.pdf.SetDrawColor(d.style.accent.r, d.style.accent.g, d.style.accent.b)
+		d.pdf.SetLineWidth(0.4)
+	}
+	y := d.pdf.GetY()
+	d.pdf.Line(d.x, y, d.x+d.w, y)
+	d.pdf.Ln(2.5)
+}
+
+// bullet skriver en punkt i en lista
+func (d *pdfDoc) bullet(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	d.font(fontText, "", 10)
+	d.color(textColor)
+	d.pdf.SetX(d.x)
+	d.pdf.CellFormat(4, 5, "•", "", 0, "L", false, 0, "")
+	d.pdf.SetLeftMargin(d.x + 4)
+	d.pdf.MultiCell(d.w-4, 5, text, "", "L", false)
+	d.pdf.SetLeftMargin(d.x)
+}
+
+// entry skriver en anställning eller utbildning med period till höger
+func (d *pdfDoc) entry(title, subtitle, period string, details []string) {
+	if d.pdf.GetY() > pageHeight-margin-20 {
+		d.pdf.AddPage()
+	}
+	d.font(fontText, "", 9)
+	periodWidth := d.pdf.GetStringWidth(period) + 2
+
+	d.pdf.SetX(d.x)
+	d.font(fontText, "B", 11)
+	d.color(textColor)
+	d.pdf.CellFormat(d.w-periodWidth, 6, title, "", 0, "L", false, 0, "")
+	d.font(fontText, "", 9)
+	d.color(mutedColor)
+	d.pdf.CellFormat(periodWidth, 6, period, "", 1, "R", false, 0, "")
+
+	if subtitle != "" {
+		d.text(subtitle, 10, "I", d.style.accent)
+	}
+	d.pdf.Ln(1)
+	for _, detail := range details {
+		d.bullet(detail)
+	}
+	d.pdf.Ln(2.5)
+}
+
+// fits anger om h millimeter till får plats på sidan
+func (d *pdfDoc) fits(h float64) bool {
+	return d.pdf.GetY()+h <= pageHeight-margin
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// WriteCVPDF skriver cv som en A4-PDF i mallen templateID ("", "modern",
+// "creative" eller "cv3"). Okända mallar ger standardmallen, som i HTML.
+func WriteCVPDF(w io.Writer, cv *data.TemplateData, templateID string) error {
+	style, ok := cvStyles[templateID]
+	if !ok {
+		style = cvStyles[""]
+	}
+	d := newPDFDoc(style, "CV "+cv.PersonligInfo.Namn, cv.PersonligInfo.Namn)
+
+	if style.layout == layoutSidebar {
+		d.cvSidebar(cv)
+	} else {
+		d.pdf.AddPage()
+		d.cvHeader(cv.PersonligInfo)
+		d.cvSections(cv, true)
+	}
+
+	if err := d.pdf.Output(w); err != nil {
+		return fmt.Errorf("kunde inte skapa PDF: %v", err)
+	}
+	return nil
+}
+
+// cvHeader skriver namn, titel och kontaktuppgifter överst på sidan
+func (d *pdfDoc) cvHeader(info data.PersonligInfo) {
+	contactLine := strings.Join(contacts(info), "   ·   ")
+
+	switch d.style.layout {
+	case layoutBanner:
+		d.pdf.SetFillColor(d.style.accent.r, d.style.accent.g, d.style.accent.b)
+		d.pdf.Rect(0, 0, pageWidth, 44, "F")
+		d.pdf.SetY(12)
+		d.text(info.Namn, 24, "B", white)
+		d.pdf.Ln(1)
+		d.text(info.Titel, 13, "", white)
+		d.pdf.Ln(1)
+		d.text(contactLine, 8.5, "", white)
+		d.pdf.SetY(52)
+
+	case layoutMinimal:
+		d.font(fontSmallCaps, "", 24)
+		d.color(textColor)
+		d.pdf.CellFormat(d.w, 11, info.Namn, "", 1, "C", false, 0, "")
+		d.font(fontText, "", 12)
+		d.color(mutedColor)
+		d.pdf.CellFormat(d.w, 7, info.Titel, "", 1, "C", false, 0, "")
+		d.font(fontText, "", 8.5)
+		d.pdf.MultiCell(d.w, 4.5, contactLine, "", "C", false)
+		d.pdf.Ln(3)
+
+	default:
+		d.text(info.Namn, 24, "B", textColor)
+		d.pdf.Ln(1)
+		d.text(info.Titel, 13, "", d.style.accent)
+		d.pdf.Ln(1)
+		d.text(contactLine, 8.5, "", mutedColor)
+		d.pdf.Ln(2)
+	}
+}
+
+// cvSections skriver avsnitten i en spalt. Med all skrivs även färdigheter,
+// språk och certifieringar, som annars står i sidospalten.
+func (d *pdfDoc) cvSections(cv *data.TemplateData, all bool) {
+	if strings.TrimSpace(cv.Profil) != "" {
+		d.heading("Profil")
+		d.paragraphs(cv.Profil)
+	}
+
+	if len(cv.Arbetslivserfarenhet) > 0 {
+		d.heading("Arbetslivserfarenhet")
+		for _, exp := range cv.Arbetslivserfarenhet {
+			d.entry(exp.Titel, exp.Foretag, exp.Period, exp.Beskrivning)
+		}
+	}
+
+	if len(cv.Utbildning) > 0 {
+		d.heading("Utbildning")
+		for _, edu := range cv.Utbildning {
+			d.entry(edu.Examen, edu.Skola, edu.Period, edu.Beskrivning)
+		}
+	}
+
+	if all && len(cv.Fardigheter) > 0 {
+		d.heading("Färdigheter")
+		d.text(strings.Join(cv.Fardigheter, "  ·  "), 10, "", textColor)
+	}
+	if lines := languages(cv.Sprak); all && len(lines) > 0 {
+		d.heading("Språk")
+		for _, line := range lines {
+			d.bullet(line)
+		}
+	}
+	if len(cv.Projekt) > 0 {
+		d.heading("Projekt")
+		for _, p := range cv.Projekt {
+			d.bullet(p)
+		}
+	}
+	if all && len(cv.Certifieringar) > 0 {
+		d.heading("Certifieringar")
+		for _, c := range cv.Certifieringar {
+			d.bullet(c)
+		}
+	}
+}
+
+// sidebarWidth är bredden på den färgade sidospalten i mallen "modern"
+const sidebarWidth = 68.0
+
+// cvSidebar ställer upp CV:t med kontakt, färdigheter, språk och
+// certifieringar i en sidospalt. Det som inte får plats i spalten på första
+// sidan flyttas till huvudspalten så att inget försvinner.
+func (d *pdfDoc) cvSidebar(cv *data.TemplateData) {
+	tint := d.style.tint
+	d.pdf.SetHeaderFunc(func() {
+		d.pdf.SetFillColor(tint.r, tint.g, tint.b)
+		d.pdf.Rect(0, 0, sidebarWidth, pageHeight, "F")
+	})
+	d.pdf.AddPage()
+
+	type section struct {
+		title string
+		items []string
+	}
+	sections := []section{
+		{"Kontakt", contacts(cv.PersonligInfo)},
+		{"Färdigheter", cv.Fardigheter},
+		{"Språk", languages(cv.Sprak)},
+		{"Certifieringar", cv.Certifieringar},
+	}
+
+	// Sidospalten får inte byta sida mitt i
+	d.pdf.SetAutoPageBreak(false, margin)
+	d.column(8, sidebarWidth-16)
+	d.pdf.SetY(margin)
+	d.text(cv.PersonligInfo.Namn, 18, "B", textColor)
+	d.pdf.Ln(1)
+	d.text(cv.PersonligInfo.Titel, 11, "", d.style.accent)
+
+	var overflow []section
+	for _, s := range sections {
+		if len(s.items) == 0 {
+			continue
+		}
+		// heading byter sida när det är mindre än 25 mm kvar
+		if len(overflow) > 0 || !d.fits(30) {
+			overflow = append(overflow, s)
+			continue
+		}
+		d.heading(s.title)
+		for i, item := range s.items {
+			if !d.fits(10) {
+				overflow = append(overflow, section{s.title, s.items[i:]})
+				break
+			}
+			d.bullet(item)
+		}
+	}
+
+	d.pdf.SetAutoPageBreak(true, margin)
+	d.column(sidebarWidth+8, pageWidth-sidebarWidth-8-margin)
+	d.pdf.SetY(margin)
+	d.cvSections(cv, false)
+	for _, s := range overflow {
+		d.heading(s.title)
+		for _, item := range s.items {
+			d.bullet(item)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// WriteCoverLetterPDF skriver brevet som en A4-PDF i mallen templateID
+// ("creative" eller "v2"). Okända mallar ger den kreativa, som i HTML.
+func WriteCoverLetterPDF(w io.Writer, letter *data.CoverLetterData, templateID string) error {
+	style, ok := letterStyles[templateID]
+	if !ok {
+		style = letterStyles[""]
+	}
+	d := newPDFDoc(style, "Personligt brev "+letter.Jobb.Titel, letter.PersonligInfo.Namn)
+	d.pdf.AddPage()
+
+	sender := letter.PersonligInfo
+	if style.layout == layoutBanner {
+		d.pdf.SetFillColor(style.accent.r, style.accent.g, style.accent.b)
+		d.pdf.Rect(0, 0, pageWidth, 38, "F")
+		d.pdf.SetY(11)
+		d.text(sender.Namn, 20, "B", white)
+		d.pdf.Ln(1)
+		d.text(sender.Titel, 12, "", white)
+		d.pdf.Ln(1)
+		d.text(strings.Join(contacts(sender), "   ·   "), 8.5, "", white)
+		d.pdf.SetY(48)
+	} else {
+		d.text(sender.Namn, 16, "B", textColor)
+		d.text(sender.Titel, 11, "", style.accent)
+		for _, line := range contacts(sender) {
+			d.text(line, 9, "", mutedColor)
+		}
+		d.pdf.Ln(6)
+	}
+
+	// Mottagare till vänster och datum till höger på samma höjd
+	top := d.pdf.GetY()
+	d.font(fontText, "", 10)
+	d.color(mutedColor)
+	d.pdf.SetXY(d.x, top)
+	d.pdf.CellFormat(d.w, 5, letter.Datum, "", 1, "R", false, 0, "")
+	d.pdf.SetY(top)
+	recipient := letter.Mottagare
+	for _, line := range []string{recipient.Namn, recipient.Position, recipient.Foretag, recipient.Adress, recipient.PostOrt} {
+		d.text(line, 10, "", textColor)
+	}
+	d.pdf.Ln(8)
+
+	if letter.Jobb.Titel != "" {
+		d.text(fmt.Sprintf("Ansökan: %s", letter.Jobb.Titel), 13, "B", style.accent)
+		d.pdf.Ln(4)
+	}
+
+	content := letter.Innehall
+	for _, part := range []string{content.Inledning, content.Huvudtext, content.Avslutning} {
+		d.paragraphs(part)
+	}
+
+	d.pdf.Ln(4)
+	d.text(content.Halsningsfras, 10.5, "", textColor)
+	d.pdf.Ln(8)
+	d.text(sender.Namn, 11, "B", textColor)
+
+	if err := d.pdf.Output(w); err != nil {
+		return fmt.Errorf("kunde inte skapa PDF: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/data"
+)
+
+// plainStyle är skillnaderna mellan de textbaserade formaten. Båda skriver
+// CV:t linjärt utan spalter eller tabeller, som rekryteringssystem läser bäst.
+type plainStyle struct {
+	name    func(name string) string
+	heading func(title string) string
+	// entry är raden med titel, organisation och period för en anställning
+	// eller utbildning
+	entry  func(title, organisation, period string) string
+	bullet string
+	escape func(text string) string
+	// lineBreak avslutar rader som ska brytas inom ett stycke
+	lineBreak string
+}
+
+// atsText är vanlig text med versala rubriker och en rad per uppgift
+var atsText = plainStyle{
+	name:    strings.ToUpper,
+	heading: strings.ToUpper,
+	entry: func(title, organisation, period string) string {
+		return joinNonEmpty(" | ", title, organisation, period)
+	},
+	bullet: "- ",
+	escape: func(text string) string { return text },
+}
+
+// markdown är CommonMark med rubriker på två nivåer
+var markdown = plainStyle{
+	name:    func(name string) string { return "# " + markdownEscape(name) },
+	heading: func(title string) string { return "## " + title },
+	entry: func(title, organisation, period string) string {
+		line := "### " + joinNonEmpty(", ", markdownEscape(title), markdownEscape(organisation))
+		if period != "" {
+			line += "\n\n*" + markdownEscape(period) + "*"
+		}
+		return line
+	},
+	bullet: "- ",
+	escape: markdownEscape,
+	// Markdown slår ihop rader i ett stycke; två blanksteg ger radbrytning
+	lineBreak: "  ",
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`,
+)
+
+// markdownEscape ser till att text från CV:t inte tolkas som formatering
+func markdownEscape(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
+
+// WriteCVText skriver cv som vanlig text för rekryteringssystem (ATS)
+func WriteCVText(w io.Writer, cv *data.CVData) error {
+	return writeCVPlain(w, cv, atsText)
+}
+
+// WriteCVMarkdown skriver cv som Markdown
+func WriteCVMarkdown(w io.Writer, cv *data.CVData) error {
+	return writeCVPlain(w, cv, markdown)
+}
+
+func writeCVPlain(w io.Writer, cv *data.CVData, style plainStyle) error {
+	bw := bufio.NewWriter(w)
+	// block skriver ett stycke med en tom rad före, utom först i filen
+	first := true
+	block := func(lines ...string) {
+		var nonEmpty []string
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				nonEmpty = append(nonEmpty, line)
+			}
+		}
+		if len(nonEmpty) == 0 {
+			return
+		}
+		if !first {
+			bw.WriteString("\n")
+		}
+		first = false
+		bw.WriteString(strings.Join(nonEmpty, "\n") + "\n")
+	}
+	list := func(items []string) string {
+		var lines []string
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				lines = append(lines, style.bullet+style.escape(item))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	info := cv.PersonligInfo
+	var details []string
+	for _, line := range append([]string{info.Titel}, contacts(info)...) {
+		if line != "" {
+			details = append(details, style.escape(line))
+		}
+	}
+	for i := range details {
+		if i < len(details)-1 {
+			details[i] += style.lineBreak
+		}
+	}
+	header := details
+	if info.Namn != "" {
+		header = append([]string{style.name(info.Namn)}, details...)
+	}
+	block(header...)
+
+	if strings.TrimSpace(cv.Profil) != "" {
+		block(style.heading("Profil"))
+		for _, p := range strings.Split(strings.ReplaceAll(cv.Profil, "\r\n", "\n"), "\n\n") {
+			block(style.escape(strings.TrimSpace(p)))
+		}
+	}
+
+	if len(cv.Arbetslivserfarenhet) > 0 {
+		block(style.heading("Arbetslivserfarenhet"))
+		for _, exp := range cv.Arbetslivserfarenhet {
+			block(style.entry(exp.Titel, exp.Foretag, exp.Period), list(exp.Beskrivning))
+		}
+	}
+	if len(cv.Utbildning) > 0 {
+		block(style.heading("Utbildning"))
+		for _, edu := range cv.Utbildning {
+			block(style.entry(edu.Examen, edu.Skola, edu.Period), list(edu.Beskrivning))
+		}
+	}
+	if len(cv.Fardigheter) > 0 {
+		block(style.heading("Färdigheter"), style.escape(joinNonEmpty(", ", cv.Fardigheter...)))
+	}
+	if lines := languages(cv.Sprak); len(lines) > 0 {
+		block(style.heading("Språk"), list(lines))
+	}
+	if len(cv.Projekt) > 0 {
+		block(style.heading("Projekt"), list(cv.Projekt))
+	}
+	if len(cv.Certifieringar) > 0 {
+		block(style.heading("Certifieringar"), list(cv.Certifieringar))
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/data"
+)
+
+func sampleCV() *data.CVData {
+	return &data.CVData{
+		PersonligInfo: data.PersonligInfo{
+			Namn:  "Åsa Öberg",
+			Titel: "Systemutvecklare",
+			Kontakt: []data.KontaktItem{
+				{Typ: "email", Varde: "asa@example.se"},
+				{Typ: "adress", Varde: "Göteborg"},
+				{Typ: "linkedin", Varde: "linkedin.com/in/asaoberg"},
+				{Typ: "github", Varde: "GitHub"},
+			},
+		},
+		Profil: "Utvecklare med fokus på *drift*.",
+		Arbetslivserfarenhet: []data.Arbetslivserfarenhet{
+			{Titel: "Backendutvecklare", Foretag: "Exempelbolaget AB", Period: "jan 2020 – nu", Beskrivning: []string{"Byggde tjänster i Go"}},
+			{Titel: "Konsult", Foretag: "Konsultbolaget", Period: "2015-2019"},
+		},
+		Utbildning:     []data.Utbildning{{Examen: "Civilingenjör", Skola: "Chalmers", Period: "2010 – 2015"}},
+		Fardigheter:    []string{"Go", "C#"},
+		Sprak:          []data.Sprak{{Sprak: "Svenska", Niva: "Modersmål"}},
+		Certifieringar: []string{"Certified Kubernetes Application Developer"},
+	}
+}
+
+func TestWriteCVText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCVText(&buf, sampleCV()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `ÅSA ÖBERG
+Systemutvecklare
+E-post: asa@example.se
+Adress: Göteborg
+LinkedIn: linkedin.com/in/asaoberg
+
+PROFIL
+
+Utvecklare med fokus på *drift*.
+
+ARBETSLIVSERFARENHET
+
+Backendutvecklare | Exempelbolaget AB | jan 2020 – nu
+- Byggde tjänster i Go
+
+Konsult | Konsultbolaget | 2015-2019
+
+UTBILDNING
+
+Civilingenjör | Chalmers | 2010 – 2015
+
+FÄRDIGHETER
+Go, C#
+
+SPRÅK
+- Svenska – Modersmål
+
+CERTIFIERINGAR
+- Certified Kubernetes Application Developer
+`
+	if got := buf.String(); got != want {
+		t.Errorf("texten =\n%s\nvill ha\n%s", got, want)
+	}
+}
+
+func TestWriteCVMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCVMarkdown(&buf, sampleCV()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"# Åsa Öberg\nSystemutvecklare  \nE-post: asa@example.se  \n",
+		"## Profil\n\nUtvecklare med fokus på \\*drift\\*.\n",
+		"### Backendutvecklare, Exempelbolaget AB\n\n*jan 2020 – nu*\n- Byggde tjänster i Go\n",
+		"## Färdigheter\nGo, C\\#\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown saknar %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package grounding kontrollerar att ett AI-genererat CV håller sig till
+// kandidatens egna uppgifter. Arbetsgivare, skolor, examina och
+// certifieringar i CV:t som inte går att hitta i underlaget flaggas.
+package grounding
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"awesomeProject/internal/data"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var issuesFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cv_grounding_issues_total",
+	Help: "Uppgifter i AI-genererade CV som inte finns i kandidatens underlag, per typ",
+}, []string{"kind"})
+
+func init() {
+	prometheus.MustRegister(issuesFound)
+}
+
+// Typerna av uppgifter som kontrolleras
+const (
+	KindEmployer      = "employer"
+	KindSchool        = "school"
+	KindDegree        = "degree"
+	KindCertification = "certification"
+)
+
+// Issue är en uppgift i CV:t som inte finns i underlaget
+type Issue struct {
+	// Field är sökvägen i CV:t, t.ex. "arbetslivserfarenhet[0].foretag"
+	Field   string `json:"field"`
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// Report är resultatet av en kontroll
+type Report struct {
+	Verified bool    `json:"verified"`
+	Issues   []Issue `json:"issues"`
+}
+
+// genericWords räknas inte när en uppgift jämförs med underlaget. Bolagsformer
+// och ord som "högskola" skiljer inte en arbetsgivare eller skola från en annan.
+var genericWords = map[string]bool{
+	"ab": true, "aktiebolag": true, "hb": true, "kb": true, "inc": true, "ltd": true, "llc": true,
+	"gmbh": true, "as": true, "asa": true, "oy": true, "plc": true, "co": true, "corp": true, "group": true,
+	"i": true, "och": true, "på": true, "för": true, "vid": true, "av": true, "med": true,
+	"of": true, "in": true, "the": true, "and": true, "at": true, "for": true,
+	"universitet": true, "universitetet": true, "university": true, "högskola": true, "högskolan": true,
+	"tekniska": true, "college": true, "institute": true, "institutet": true, "school": true, "skola": true,
+}
+
+// stemLength är hur många tecken i början av ett ord som räcker för att två
+// böjningar ska räknas som samma ord, t.ex. "civilingenjör" och "civilingenjörsexamen"
+const stemLength = 5
+
+// Verify kontrollerar cv mot underlaget, all text som kandidaten skickat in.
+// Arbetsgivare och skolor ska finnas med i underlaget; examina och
+// certifieringar får vara omformulerade men minst två tredjedelar av orden
+// ska känns igen.
+func Verify(cv *data.CVData, source string) *Report {
+	index := newIndex(source)
+	report := &Report{Issues: []Issue{}}
+
+	check := func(field, kind, value string) {
+		if index.grounded(kind, value) {
+			return
+		}
+		issuesFound.WithLabelValues(kind).Inc()
+		report.Issues = append(report.Issues, Issue{
+			Field:   field,
+			Kind:    kind,
+			Value:   value,
+			Message: fmt.Sprintf("%s finns inte i dina uppgifter", describe(kind, value)),
+		})
+	}
+
+	for i, exp := range cv.Arbetslivserfarenhet {
+		check(fmt.Sprintf("arbetslivserfarenhet[%d].foretag", i), KindEmployer, exp.Foretag)
+	}
+	for i, edu := range cv.Utbildning {
+		check(fmt.Sprintf("utbildning[%d].skola", i), KindSchool, edu.Skola)
+		check(fmt.Sprintf("utbildning[%d].examen", i), KindDegree, edu.Examen)
+	}
+	for i, cert := range cv.Certifieringar {
+		check(fmt.Sprintf("certifieringar[%d]", i), KindCertification, cert)
+	}
+
+	report.Verified = len(report.Issues) == 0
+	return report
+}
+
+func describe(kind, value string) string {
+	switch kind {
+	case KindEmployer:
+		return fmt.Sprintf("Arbetsgivaren %q", value)
+	case KindSchool:
+		return fmt.Sprintf("Skolan %q", value)
+	case KindDegree:
+		return fmt.Sprintf("Examen %q", value)
+	default:
+		return fmt.Sprintf("Certifieringen %q", value)
+	}
+}
+
+// index är orden i underlaget
+type index struct {
+	words map[string]bool
+	stems map[string]bool
+}
+
+func newIndex(source string) *index {
+	idx := &index{words: make(map[string]bool), stems: make(map[string]bool)}
+	for _, w := range words(source) {
+		idx.words[w] = true
+		if s, ok := stem(w); ok {
+			idx.stems[s] = true
+		}
+	}
+	return idx
+}
+
+// grounded avgör om value finns i underlaget. Tomma värden och värden med
+// bara generiska ord räknas som grundade.
+func (idx *index) grounded(kind, value string) bool {
+	var significant []string
+	for _, w := range words(value) {
+		if !genericWords[w] {
+			significant = append(significant, w)
+		}
+	}
+	if len(significant) == 0 {
+		return true
+	}
+
+	found := 0
+	for _, w := range significant {
+		if idx.words[w] {
+			found++
+			continue
+		}
+		if s, ok := stem(w); ok && (kind == KindDegree || kind == KindCertification) && idx.stems[s] {
+			found++
+		}
+	}
+
+	if kind == KindEmployer || kind == KindSchool {
+		return found == len(significant)
+	}
+	return found*3 >= len(significant)*2
+}
+
+// words delar upp texten i gemena ord
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stem är ordets första stemLength tecken, om ordet är så långt
+func stem(word string) (string, bool) {
+	runes := []rune(word)
+	if len(runes) < stemLength {
+		return "", false
+	}
+	return string(runes[:stemLength]), true
+}
@@ -0,0 +1,49 @@
+package grounding
+
+import (
+	"testing"
+
+	"awesomeProject/internal/data"
+)
+
+func TestVerify(t *testing.T) {
+	source := `Backendutvecklare på Exempelbolaget AB 2020 - nu, Go och PostgreSQL.
+Civilingenjörsexamen i datateknik vid Chalmers 2015 - 2020.
+Certifieringar: CKAD (Kubernetes Application Developer)`
+
+	cv := &data.CVData{
+		Arbetslivserfarenhet: []data.Arbetslivserfarenhet{
+			{Titel: "Backendutvecklare", Foretag: "Exempelbolaget"},
+			{Titel: "Utvecklare", Foretag: "Påhittat Konsult AB"},
+		},
+		Utbildning: []data.Utbildning{
+			{Examen: "Civilingenjör i datateknik", Skola: "Chalmers tekniska högskola"},
+			{Examen: "Masterexamen i ekonomi", Skola: "Handelshögskolan i Stockholm"},
+		},
+		Certifieringar: []string{"Certified Kubernetes Application Developer", "AWS Solutions Architect", ""},
+	}
+
+	report := Verify(cv, source)
+	if report.Verified {
+		t.Fatal("rapporten ska inte vara verifierad")
+	}
+
+	want := map[string]string{
+		"arbetslivserfarenhet[1].foretag": KindEmployer,
+		"utbildning[1].skola":             KindSchool,
+		"utbildning[1].examen":            KindDegree,
+		"certifieringar[1]":               KindCertification,
+	}
+	if len(report.Issues) != len(want) {
+		t.Errorf("issues = %+v, vill ha %d", report.Issues, len(want))
+	}
+	for _, issue := range report.Issues {
+		if want[issue.Field] != issue.Kind {
+			t.Errorf("oväntad flagga %+v", issue)
+		}
+	}
+
+	if report := Verify(&data.CVData{Arbetslivserfarenhet: cv.Arbetslivserfarenhet[:1]}, source); !report.Verified {
+		t.Errorf("ett CV med bara underlagets uppgifter ska verifieras: %+v", report.Issues)
+	}
+}
@@ -0,0 +1,109 @@
+// Package guardrails skyddar AI-flödet mot text som användare och annonsörer
+// skickar in. Indata städas från HTML och inbäddade instruktioner innan den
+// hamnar i en prompt, personuppgifter maskeras i loggarna och modellens svar
+// granskas innan det når mallarna.
+package guardrails
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var events = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "guardrail_events_total",
+	Help: "Antal ingrepp av skyddet per steg (input eller output) och typ",
+}, []string{"stage", "kind"})
+
+func init() {
+	prometheus.MustRegister(events)
+}
+
+// Removed ersätter instruktioner som tagits bort ur indata
+const Removed = "[borttaget]"
+
+// Avgränsarna runt text som ska behandlas som data och inte som instruktioner
+const (
+	DataStart = "<<<"
+	DataEnd   = ">>>"
+)
+
+var (
+	scriptPattern = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	tagPattern    = regexp.MustCompile(`(?s)<[^<>]*>`)
+	spaces        = regexp.MustCompile(`[ \t]+`)
+	blankLines    = regexp.MustCompile(`\n\s*\n(\s*\n)+`)
+
+	// injectionPatterns är vanliga försök att ge modellen nya instruktioner,
+	// på svenska och engelska
+	injectionPatterns = []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{
+		{"override", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+)?(the\s+|your\s+)?(previous|prior|above|earlier|system)\s+(instructions?|prompts?|rules|messages?)`)},
+		{"override", regexp.MustCompile(`(?i)(ignorera|glöm|strunta\s+i|bortse\s+från)\s+(alla\s+)?(dina\s+)?(tidigare|föregående|ovanstående|dina)\s+(instruktioner(na)?|regler(na)?|meddelanden(a)?)`)},
+		{"override", regexp.MustCompile(`(?i)\b(new|nya)\s+(instructions|instruktioner)\s*:`)},
+		{"role", regexp.MustCompile(`(?i)\b(you\s+are\s+now|du\s+är\s+nu|from\s+now\s+on\s+you|från\s+och\s+med\s+nu\s+ska\s+du)\b`)},
+		{"role", regexp.MustCompile(`(?im)^\s*(system|assistant|user|developer|assistent|användare)\s*:`)},
+		{"leak", regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|visa|skriv\s+ut|upprepa)\s+(me\s+|mig\s+)?(the\s+|your\s+|din\s+|dina\s+)?(systemprompten?|system\s*prompt|instruktioner(na)?|instructions)`)},
+		{"token", regexp.MustCompile(`(?i)<\|[^|<>]{1,40}\|>|\[/?INST\]|<</?SYS>>|###\s*(instruction|system)\b`)},
+	}
+
+	// invisible är styrtecken som kan gömma text för läsaren men inte för modellen
+	invisible = strings.NewReplacer(
+		"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "",
+		"\u202a", "", "\u202b", "", "\u202c", "", "\u202d", "", "\u202e", "",
+		"\u2066", "", "\u2067", "", "\u2068", "", "\u2069", "",
+	)
+)
+
+// Clean städar text från användare eller annonser innan den hamnar i en
+// prompt: HTML tas bort, dolda tecken rensas och inbäddade instruktioner
+// ersätts med Removed.
+func Clean(text string) string {
+	cleaned, _ := CleanInput(text)
+	return cleaned
+}
+
+// CleanInput fungerar som Clean men returnerar även vilka typer av
+// instruktioner som togs bort
+func CleanInput(text string) (string, []string) {
+	if text == "" {
+		return text, nil
+	}
+
+	text = scriptPattern.ReplaceAllString(text, " ")
+	text = tagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = invisible.Replace(text)
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+
+	// Användaren ska inte kunna avsluta datablocket i förtid
+	text = strings.NewReplacer(DataStart, "", DataEnd, "").Replace(text)
+
+	var found []string
+	for _, p := range injectionPatterns {
+		if p.pattern.MatchString(text) {
+			text = p.pattern.ReplaceAllString(text, Removed)
+			found = append(found, p.kind)
+			events.WithLabelValues("input", p.kind).Inc()
+		}
+	}
+
+	text = spaces.ReplaceAllString(text, " ")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text), found
+}
+
+// Quote städar texten och sätter den inom DataStart och DataEnd så att
+// prompten kan be modellen behandla den som data
+func Quote(text string) string {
+	return DataStart + Clean(text) + DataEnd
+}
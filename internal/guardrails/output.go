@@ -0,0 +1,89 @@
+package guardrails
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// leakWindow är hur många ord i följd från instruktionerna som räknas som
+// ett läckage. Kortare fraser förekommer naturligt i svaren.
+const leakWindow = 12
+
+var (
+	// unsafeMarkup är HTML och länkar som inte hör hemma i ett CV eller brev
+	unsafeMarkup = regexp.MustCompile(`(?i)<\s*/?\s*(script|iframe|object|embed|style|form|input|meta|link|svg)\b|javascript\s*:|vbscript\s*:|data\s*:\s*text/html|\bon[a-z]+\s*=\s*["']`)
+	// leakMarkers är fraser där modellen berättar om sina instruktioner
+	leakMarkers = regexp.MustCompile(`(?i)\b(my\s+(system\s+)?instructions\s+(are|say)|system\s*prompt|mina\s+instruktioner\s+(är|säger)|systemprompt(en)?)\b|<\|[^|<>]{1,40}\|>|\[/?INST\]`)
+)
+
+// ScanOutput granskar modellens svar innan det når mallarna. instructions är
+// systemmeddelandena i anropet; om svaret återger en längre bit av dem
+// räknas det som att prompten läckt. Returnerar en beskrivning per problem
+// som kan skickas tillbaka till modellen.
+func ScanOutput(text string, instructions []string) []string {
+	var problems []string
+	report := func(kind, problem string) {
+		events.WithLabelValues("output", kind).Inc()
+		problems = append(problems, problem)
+	}
+
+	if m := unsafeMarkup.FindString(text); m != "" {
+		report("markup", fmt.Sprintf("svaret innehåller otillåten HTML eller skript (%q), skriv bara vanlig text", m))
+	}
+	if m := leakMarkers.FindString(text); m != "" {
+		report("leak", fmt.Sprintf("svaret refererar till instruktionerna (%q), skriv bara det efterfrågade innehållet", m))
+	}
+	if leaksInstructions(text, instructions) {
+		report("leak", "svaret återger delar av instruktionerna, skriv bara det efterfrågade innehållet")
+	}
+	if word := blockedWord(text); word != "" {
+		report("blocklist", fmt.Sprintf("svaret innehåller det otillåtna ordet %q", word))
+	}
+	return problems
+}
+
+// leaksInstructions avgör om text innehåller leakWindow ord i följd ur
+// någon av instruktionerna
+func leaksInstructions(text string, instructions []string) bool {
+	ow := words(text)
+	if len(ow) < leakWindow {
+		return false
+	}
+	output := " " + strings.Join(ow, " ") + " "
+
+	for _, instruction := range instructions {
+		iw := words(instruction)
+		for i := 0; i+leakWindow <= len(iw); i++ {
+			if strings.Contains(output, " "+strings.Join(iw[i:i+leakWindow], " ")+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// words delar upp texten i gemena ord utan skiljetecken
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// blockedWord returnerar det första ordet från GUARDRAIL_BLOCKLIST
+// (kommaseparerad) som finns i texten
+func blockedWord(text string) string {
+	list := os.Getenv("GUARDRAIL_BLOCKLIST")
+	if list == "" {
+		return ""
+	}
+	output := " " + strings.Join(words(text), " ") + " "
+	for _, word := range strings.Split(list, ",") {
+		if w := strings.Join(words(word), " "); w != "" && strings.Contains(output, " "+w+" ") {
+			return strings.TrimSpace(word)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package guardrails
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Personnummer och samordningsnummer, med eller utan sekel och bindestreck
+	personnummerPattern = regexp.MustCompile(`\b(?:19|20)?\d{6}[-+]?\d{4}\b`)
+	// Svenska telefonnummer: +46, 0046 eller inledande nolla följt av 7-10 siffror
+	phonePattern = regexp.MustCompile(`(?:\+46|\b0046|\b0)[\s\-]?\(?0?\)?\d{1,3}(?:[\s\-]?\d){5,8}\b`)
+)
+
+// Redact maskerar personnummer, e-postadresser och telefonnummer
+func Redact(text string) string {
+	text = emailPattern.ReplaceAllString(text, "[e-post]")
+	text = personnummerPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if !validPersonnummer(match) {
+			return match
+		}
+		return "[personnummer]"
+	})
+	return phonePattern.ReplaceAllString(text, "[telefon]")
+}
+
+// validPersonnummer kontrollerar kontrollsiffran (Luhn) så att andra tiosiffriga
+// nummer, t.ex. annons-ID:n, inte maskeras i onödan
+func validPersonnummer(match string) bool {
+	var digits []int
+	for _, r := range match {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) == 12 {
+		digits = digits[2:]
+	}
+	if len(digits) != 10 {
+		return false
+	}
+
+	sum := 0
+	for i, d := range digits {
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// redactWriter maskerar personuppgifter i allt som skrivs till w
+type redactWriter struct {
+	w io.Writer
+}
+
+// RedactWriter returnerar en writer som maskerar personuppgifter innan de
+// skrivs till w. Används som utdata för loggarna; log skriver en rad per anrop.
+func RedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	redacted := Redact(string(p))
+	if redacted == string(p) {
+		return r.w.Write(p)
+	}
+	events.WithLabelValues("log", "pii").Inc()
+	if _, err := io.Copy(r.w, bytes.NewBufferString(redacted)); err != nil {
+		return 0, err
+	}
+	// Anroparen ska se att hela p skrevs även om texten blev kortare
+	return len(p), nil
+}
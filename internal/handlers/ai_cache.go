@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"strings"
+
+	"awesomeProject/internal/llm"
+	"github.com/gin-gonic/gin"
+)
+
+// AICacheControl låter klienten styra AI-cachen per förfrågan:
+//
+//	X-AI-Cache: refresh  eller  Cache-Control: no-cache   ny AI-fråga, svaret sparas
+//	X-AI-Cache: off      eller  Cache-Control: no-store   ny AI-fråga, inget sparas
+func AICacheControl() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := llm.CacheDefault
+		cacheControl := strings.ToLower(c.GetHeader("Cache-Control"))
+		switch {
+		case strings.EqualFold(c.GetHeader("X-AI-Cache"), "off"), strings.Contains(cacheControl, "no-store"):
+			mode = llm.CacheOff
+		case strings.EqualFold(c.GetHeader("X-AI-Cache"), "refresh"), strings.Contains(cacheControl, "no-cache"):
+			mode = llm.CacheRefresh
+		}
+
+		if mode != llm.CacheDefault {
+			c.Request = c.Request.WithContext(llm.WithCacheMode(c.Request.Context(), mode))
+		}
+		c.Next()
+	}
+}
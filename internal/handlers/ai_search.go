@@ -2,13 +2,13 @@ package handlers
 
 import (
 	"log"
-	"os"
 
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/platsbanken"
 	"awesomeProject/internal/utils"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"sync"
 )
 
 func AnalyzeSearchQuery(c *gin.Context) {
@@ -27,14 +27,11 @@ func AnalyzeSearchQuery(c *gin.Context) {
 	}
 
 	// Logga vilken AI-tjänst som används
-	aiProvider := os.Getenv("AI_PROVIDER")
-	if aiProvider == "" {
-		aiProvider = "huggingface" // Default till Hugging Face om ingen är specificerad
-	}
+	aiProvider := llm.Default().Name()
 	log.Printf("Använder AI-tjänst: %s för analys av sökfråga: '%s'", aiProvider, requestBody.Query)
 
 	// Analysera sökfrågan med AI-tjänsten
-	analysis, err := utils.AnalyzeSearchQuery(requestBody.Query)
+	analysis, err := utils.AnalyzeSearchQuery(c.Request.Context(), requestBody.Query)
 	if err != nil {
 		log.Printf("Error analyzing search query with %s: %v", aiProvider, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to analyze search query: %v", err)})
@@ -49,48 +46,33 @@ func AnalyzeSearchQuery(c *gin.Context) {
 	log.Printf("Söker efter jobb med term: '%s' i kommun: '%s'", searchTerm, analysis.Municipality)
 
 	// Använd standard sökfunktionen med den extraherade söktermen och kommun
-	apiURL, jobDetailURL, maxRecords, defaultMaxJobs, maxRetries, retryDelay := getConfig()
+	client := newPlatsbankenClient()
+
+	// Översätt analysen till en vanlig sökförfrågan
+	request := SearchRequest{
+		SearchTerm:         searchTerm,
+		Municipality:       analysis.Municipality,
+		RequiresExperience: analysis.RequiresExperience,
+	}
 
 	// Lägg till workExtent i sökningen om det finns
-	var workExtentFilter map[string]string
-	if analysis.WorkExtent != "" {
-		workExtentFilter = map[string]string{
-			"type":  "workExtent",
-			"value": analysis.WorkExtent,
-		}
+	if _, ok := resolveWorkExtent(analysis.WorkExtent); ok {
+		request.WorkExtent = analysis.WorkExtent
 	}
 
 	// Lägg till remote i sökningen om det finns
-	var remoteFilter map[string]string
 	if analysis.Remote == "true" {
-		remoteFilter = map[string]string{
-			"type":  "remote",
-			"value": "true",
-		}
+		remote := true
+		request.Remote = &remote
 	}
 
 	// Lägg till körkortskrav i sökningen om det finns
-	var drivingLicenseFilter map[string]string
 	if analysis.DrivingLicense == "false" {
-		drivingLicenseFilter = map[string]string{
-			"type":  "drivingLicenseRequired",
-			"value": "false",
-		}
+		drivingLicenseRequired := false
+		request.DrivingLicenseRequired = &drivingLicenseRequired
 	}
 
-	// Skapa en slice med alla filter
-	var filters []map[string]string
-	if workExtentFilter != nil {
-		filters = append(filters, workExtentFilter)
-	}
-	if remoteFilter != nil {
-		filters = append(filters, remoteFilter)
-	}
-	if drivingLicenseFilter != nil {
-		filters = append(filters, drivingLicenseFilter)
-	}
-
-	jobs, err := fetchAllJobs(c.Request.Context(), apiURL, searchTerm, analysis.Municipality, defaultMaxJobs, maxRecords, maxRetries, retryDelay, filters)
+	jobs, err := fetchAllJobs(c.Request.Context(), client, request, client.Config().DefaultMaxJobs)
 	if err != nil {
 		log.Printf("Fel vid jobbsökning: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch jobs: %v", err)})
@@ -100,60 +82,28 @@ func AnalyzeSearchQuery(c *gin.Context) {
 	log.Printf("Hittade %d jobb i initial sökning", len(jobs))
 
 	// Hämta detaljerad information för varje jobb
-	var jobDetails []map[string]interface{}
-	jobDetailsChan := make(chan map[string]interface{}, len(jobs))
-	
-	// Starta goroutines för att hämta jobbdetaljer
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 10)
-	
-	for _, job := range jobs {
-		wg.Add(1)
-		go func(job map[string]interface{}) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			
-			jobID, _ := job["id"].(string)
-			details, err := fetchJobDetails(c.Request.Context(), jobDetailURL, jobID, maxRetries, retryDelay)
-			if err != nil {
-				log.Printf("Kunde inte hämta detaljer för jobb %s: %v", jobID, err)
-				return
-			}
-			jobDetailsChan <- details
-		}(job)
-	}
-	
-	// Vänta på att alla goroutines är klara
-	go func() {
-		wg.Wait()
-		close(jobDetailsChan)
-	}()
-	
-	// Samla alla jobbdetaljer och filtrera
-	filteredCount := 0
-	totalCount := 0
-	
-	for detail := range jobDetailsChan {
-		totalCount++
-		requiresExp, _ := detail["requiresExperience"].(bool)
-		
-		if analysis.RequiresExperience != nil && *analysis.RequiresExperience == false {
-			if requiresExp {
-				continue
-			}
-		}
-		
-		filteredCount++
+	var jobDetails []*platsbanken.JobDetail
+	for detail := range fetchJobDetailsConcurrently(c.Request.Context(), client, adIDs(jobs)) {
 		jobDetails = append(jobDetails, detail)
 	}
-	
+	totalCount := len(jobDetails)
+
+	// Filtrera med samma pipeline som SearchJobs
+	jobDetails, removed := request.filterPipeline().Apply(jobDetails)
+
 	log.Printf("\n=== SÖKRESULTAT ===")
 	log.Printf("Totalt antal jobb: %d", totalCount)
-	log.Printf("Antal jobb efter filtrering: %d", filteredCount)
-	
+	log.Printf("Antal jobb efter filtrering: %d", len(jobDetails))
+
 	c.JSON(http.StatusOK, gin.H{
 		"jobs":     jobDetails,
 		"analysis": analysis,
+		"debug": gin.H{
+			"totalJobsBeforeFilter": len(jobs),
+			"totalJobsAfterFilter":  len(jobDetails),
+			"removedByFilter":       removed,
+			"searchQuery":           request.SearchTerm,
+			"municipality":          request.Municipality,
+		},
 	})
 }
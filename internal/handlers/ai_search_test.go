@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/cache"
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/platsbanken"
+	"github.com/gin-gonic/gin"
+)
+
+// platsbankenStub svarar som Platsbankens /search och /job/{id} och sparar
+// de sökningar som gjorts
+type platsbankenStub struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	searches []platsbanken.SearchRequest
+}
+
+// stubJobs är annonserna som stubben känner till
+var stubJobs = []map[string]interface{}{
+	{
+		"id":                  "1001",
+		"title":               "Backendutvecklare",
+		"description":         "Vi söker en utvecklare med Go.",
+		"company":             map[string]interface{}{"name": "Exempelbolaget AB"},
+		"requiresExperience":  false,
+		"lastApplicationDate": "2099-12-31T23:59:59",
+	},
+	{
+		"id":                  "1002",
+		"title":               "Senior utvecklare",
+		"description":         "Minst tio års erfarenhet krävs.",
+		"company":             map[string]interface{}{"name": "Storföretaget AB"},
+		"requiresExperience":  true,
+		"lastApplicationDate": "2099-12-31T23:59:59",
+	},
+}
+
+func newPlatsbankenStub() *platsbankenStub {
+	s := &platsbankenStub{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *platsbankenStub) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/search":
+		var req platsbanken.SearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.searches = append(s.searches, req)
+		s.mu.Unlock()
+
+		var ads []map[string]interface{}
+		for i, job := range stubJobs {
+			if i >= req.StartIndex && (req.MaxRecords == 0 || i < req.StartIndex+req.MaxRecords) {
+				ads = append(ads, map[string]interface{}{"id": job["id"], "title": job["title"]})
+			}
+		}
+		// Den första annonsen har två tjänster
+		json.NewEncoder(w).Encode(map[string]interface{}{"ads": ads, "numberOfAds": len(stubJobs), "positions": len(stubJobs) + 1})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/job/"):
+		id := strings.TrimPrefix(r.URL.Path, "/job/")
+		for _, job := range stubJobs {
+			if job["id"] == id {
+				json.NewEncoder(w).Encode(job)
+				return
+			}
+		}
+		http.NotFound(w, r)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// lastSearch returnerar den senaste sökningen och nollställer listan
+func (s *platsbankenStub) lastSearch() (platsbanken.SearchRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.searches) == 0 {
+		return platsbanken.SearchRequest{}, false
+	}
+	last := s.searches[len(s.searches)-1]
+	s.searches = nil
+	return last, true
+}
+
+func TestAnalyzeSearchQuery(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	w := postJSON(t, AnalyzeSearchQuery, map[string]string{"query": "jobb som utvecklare i göteborg utan erfarenhet"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, vill ha 200: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	analysis, _ := body["analysis"].(map[string]interface{})
+	if analysis["job"] != "utvecklare" || analysis["municipality"] != "Göteborg" {
+		t.Errorf("analys = %v", analysis)
+	}
+
+	// Jobbet som kräver erfarenhet ska ha filtrerats bort
+	jobs, _ := body["jobs"].([]interface{})
+	if len(jobs) != 1 {
+		t.Fatalf("antal jobb = %d, vill ha 1: %v", len(jobs), jobs)
+	}
+	if job, _ := jobs[0].(map[string]interface{}); job["id"] != "1001" {
+		t.Errorf("jobb = %v, vill ha 1001", job["id"])
+	}
+
+	search, ok := pbStub.lastSearch()
+	if !ok {
+		t.Fatal("ingen sökning mot Platsbanken")
+	}
+	want := map[platsbanken.FilterType]string{
+		platsbanken.FilterFreetext:     "utvecklare",
+		platsbanken.FilterMunicipality: data.GetMunicipalityID("Göteborg"),
+	}
+	for _, f := range search.Filters {
+		if v, ok := want[f.Type]; ok && v == f.Value {
+			delete(want, f.Type)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("filter saknas i sökningen: %v (skickade %v)", want, search.Filters)
+	}
+
+	requests := fakeAI.Requests()
+	if len(requests) != 1 || requests[0].Feature != "search_analysis" {
+		t.Errorf("AI-anrop = %+v, vill ha ett search_analysis-anrop", requests)
+	}
+}
+
+func TestAnalyzeSearchQueryRetriesEmptyAnalysis(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+	fakeAI.SetResponse("search_analysis", `{"job": "", "municipality": "", "requiresExperience": null}`)
+
+	w := postJSON(t, AnalyzeSearchQuery, map[string]string{"query": "något jobb"})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, vill ha 500: %s", w.Code, w.Body.String())
+	}
+
+	// Första försöket och det översättande försöket
+	if n := len(fakeAI.Requests()); n != 2 {
+		t.Errorf("antal AI-anrop = %d, vill ha 2", n)
+	}
+	if _, ok := pbStub.lastSearch(); ok {
+		t.Error("Platsbanken ska inte anropas utan analys")
+	}
+}
+
+func TestAnalyzeSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario llm.Scenario
+		body     string
+		status   int
+	}{
+		{"ogiltig JSON", llm.ScenarioOK, `{`, http.StatusBadRequest},
+		{"tom fråga", llm.ScenarioOK, `{"query": ""}`, http.StatusBadRequest},
+		{"trasig JSON från AI", llm.ScenarioMalformed, `{"query": "utvecklare"}`, http.StatusInternalServerError},
+		{"AI svarar inte", llm.ScenarioTimeout, `{"query": "utvecklare"}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useScenario(t, tt.scenario)
+
+			if w := postJSON(t, AnalyzeSearchQuery, tt.body); w.Code != tt.status {
+				t.Fatalf("status = %d, vill ha %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if _, ok := pbStub.lastSearch(); ok {
+				t.Error("Platsbanken ska inte anropas när analysen misslyckas")
+			}
+		})
+	}
+}
+
+func TestAnalyzeSearchQueryCache(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+	llm.SetDefaultCache(llm.NewResponseCache(cache.NewLRU(10), time.Hour))
+	t.Cleanup(func() { llm.SetDefaultCache(nil) })
+
+	router := gin.New()
+	router.Use(AICacheControl())
+	router.POST("/", AnalyzeSearchQuery)
+	analyze := func(query, cacheHeader string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if cacheHeader != "" {
+			req.Header.Set("X-AI-Cache", cacheHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// Samma fråga med annan skrivning ska besvaras från cachen
+	analyze("Utvecklare i Göteborg", "")
+	analyze("utvecklare i göteborg!", "")
+	if n := len(fakeAI.Requests()); n != 1 {
+		t.Errorf("antal AI-anrop = %d, vill ha 1", n)
+	}
+
+	analyze("utvecklare i göteborg", "refresh")
+	if n := len(fakeAI.Requests()); n != 2 {
+		t.Errorf("antal AI-anrop efter refresh = %d, vill ha 2", n)
+	}
+}
@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"github.com/gin-gonic/gin"
 	"awesomeProject/internal/utils"
-	"awesomeProject/internal/prompts"
 	"awesomeProject/internal/data"
+	"awesomeProject/internal/export"
 	"log"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type CoverLetterRequest struct {
@@ -27,6 +30,53 @@ type CoverLetterAIRequest struct {
 	JobDescription string                 `json:"jobDescription"`
 	Job            map[string]interface{} `json:"job"`
 	TemplateStyle  string                 `json:"templateStyle"`
+	Language       string                 `json:"language"`
+}
+
+// companyName extraherar företagsnamn från job-objektet
+func (r CoverLetterAIRequest) companyName() string {
+	if company, ok := r.Job["company"].(map[string]interface{}); ok {
+		if name, ok := company["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// contentPrompt är underlaget för AI:ns stycken i brevet
+func (r CoverLetterAIRequest) contentPrompt() utils.CoverLetterContentPrompt {
+	return utils.CoverLetterContentPrompt{
+		JobTitle:       r.JobTitle,
+		JobDescription: r.JobDescription,
+		CompanyName:    r.companyName(),
+		Language:       r.Language,
+	}
+}
+
+// validate kontrollerar att förfrågan har ett jobb att skriva brevet till
+// och returnerar alla problem
+func (r CoverLetterAIRequest) validate() []string {
+	var problems []string
+	if strings.TrimSpace(r.JobTitle) == "" && strings.TrimSpace(r.JobDescription) == "" {
+		problems = append(problems, "jobTitle: ange jobTitle eller jobDescription")
+	}
+	if utf8.RuneCountInString(r.JobTitle) > maxCVFieldLength {
+		problems = append(problems, fmt.Sprintf("jobTitle: får vara högst %d tecken", maxCVFieldLength))
+	}
+	if utf8.RuneCountInString(r.JobDescription) > maxCVInputLength {
+		problems = append(problems, fmt.Sprintf("jobDescription: får vara högst %d tecken", maxCVInputLength))
+	}
+	return problems
+}
+
+// validateCoverLetterAIRequest svarar med 400 och en lista över problemen
+// om förfrågan inte går att skriva ett brev från
+func validateCoverLetterAIRequest(c *gin.Context, req CoverLetterAIRequest) bool {
+	if problems := req.validate(); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan", "details": problems})
+		return false
+	}
+	return true
 }
 
 // GenerateAICoverLetter hanterar AI-generering av personligt brev innehåll
@@ -42,46 +92,17 @@ func GenerateAICoverLetter(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
-
-	// Extrahera företagsnamn från job-objektet
-	companyName := ""
-	if company, ok := req.Job["company"].(map[string]interface{}); ok {
-		if name, ok := company["name"].(string); ok {
-			companyName = name
-		}
-	}
-
-	// Skapa prompten med jobinformation
-	userPrompt := fmt.Sprintf(prompts.CoverLetterUserPrompt,
-		req.JobTitle,
-		req.JobDescription,
-		companyName,
-	)
-
-	// Skapa en prompt för AI
-	prompt := utils.CVPrompt{
-		JobTitle:       req.JobTitle,
-		JobDescription: req.JobDescription,
-		SystemPrompt:   prompts.CoverLetterSystemPrompt,
-		UserPrompt:     userPrompt,
+	if !validateCoverLetterAIRequest(c, req) {
+		return
 	}
 
-	// Använd den gemensamma AI-tjänsten
-	aiService := utils.GetAIService()
-	aiResponse, err := aiService.GenerateContent(prompt)
+	// Generera brevets stycken med den valda AI-leverantören
+	content, err := utils.GenerateCoverLetterContent(c.Request.Context(), req.contentPrompt())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("AI error: %v", err)})
 		return
 	}
 
-	// Extrahera innehåll från AI-svaret och skicka som JSON
-	content := map[string]string{
-		"introduction": utils.GetStringValue(aiResponse["introduction"]),
-		"experience":   utils.GetStringValue(aiResponse["experience"]),
-		"motivation":   utils.GetStringValue(aiResponse["motivation"]),
-		"closing":      utils.GetStringValue(aiResponse["closing"]),
-	}
-
 	response, err := json.Marshal(content)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal response"})
@@ -91,18 +112,30 @@ func GenerateAICoverLetter(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", response)
 }
 
+// GenerateCoverLetter skapar ett personligt brev och svarar med HTML eller,
+// med format=pdf eller format=docx, en fil (se exportFormat)
 func GenerateCoverLetter(c *gin.Context) {
 	var request struct {
 		TemplateId      string `json:"templateId"`
 		JobTitle        string `json:"jobTitle"`
 		JobDescription  string `json:"jobDescription"`
 		CompanyName     string `json:"companyName"`
+		Language        string `json:"language"`
+		Format          string `json:"format"`
 	}
 
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(400, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
 		return
 	}
+	format, ok := exportFormat(c, request.Format)
+	if !ok {
+		return
+	}
+	if !format.ForCoverLetter() {
+		c.JSON(400, gin.H{"error": "Ogiltig förfrågan: formatet '" + string(format) + "' finns bara för CV"})
+		return
+	}
 
 	// Skapa CoverLetterPrompt med jobbinformation
 	prompt := utils.CoverLetterPrompt{
@@ -110,10 +143,11 @@ func GenerateCoverLetter(c *gin.Context) {
 		JobTitle:    request.JobTitle,
 		JobDesc:     request.JobDescription,
 		CompanyName: request.CompanyName,
+		Language:    request.Language,
 	}
 
 	// Generera innehåll med AI
-	aiResponse, err := utils.GeneratePersonalLetter(prompt)
+	aiResponse, err := utils.GeneratePersonalLetter(c.Request.Context(), prompt)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Kunde inte generera personligt brev: " + err.Error()})
 		return
@@ -122,38 +156,38 @@ func GenerateCoverLetter(c *gin.Context) {
 	// Skapa template data struktur
 	templateData := data.CoverLetterData{
 		PersonligInfo: data.PersonligInfo{
-			Namn:    utils.GetStringValue(aiResponse["namn"]),
-			Titel:   utils.GetStringValue(aiResponse["titel"]),
+			Namn:    aiResponse.Namn,
+			Titel:   aiResponse.Titel,
 			Kontakt: []data.KontaktItem{
 				{
 					Typ:   "email",
-					Varde: utils.GetStringValue(aiResponse["email"]),
+					Varde: aiResponse.Email,
 					Ikon:  "📧",
 				},
 				{
 					Typ:   "telefon",
-					Varde: utils.GetStringValue(aiResponse["telefon"]),
+					Varde: aiResponse.Telefon,
 					Ikon:  "📱",
 				},
 				{
 					Typ:   "adress",
-					Varde: utils.GetStringValue(aiResponse["adress"]),
+					Varde: aiResponse.Adress,
 					Ikon:  "📍",
 				},
 			},
 		},
 		Mottagare: data.Mottagare{
-			Namn:     utils.GetStringValue(aiResponse["mottagare_namn"]),
+			Namn:     aiResponse.MottagareNamn,
 			Foretag:  request.CompanyName,
-			Position: utils.GetStringValue(aiResponse["mottagare_position"]),
-			Adress:   utils.GetStringValue(aiResponse["mottagare_adress"]),
-			PostOrt:  utils.GetStringValue(aiResponse["mottagare_postort"]),
+			Position: aiResponse.MottagarePosition,
+			Adress:   aiResponse.MottagareAdress,
+			PostOrt:  aiResponse.MottagarePostort,
 		},
 		Innehall: data.Innehall{
-			Inledning:     utils.GetStringValue(aiResponse["inledning"]),
-			Huvudtext:     utils.GetStringValue(aiResponse["huvudtext"]),
-			Avslutning:    utils.GetStringValue(aiResponse["avslutning"]),
-			Halsningsfras: utils.GetStringValue(aiResponse["halsningsfras"]),
+			Inledning:     aiResponse.Inledning,
+			Huvudtext:     aiResponse.Huvudtext,
+			Avslutning:    aiResponse.Avslutning,
+			Halsningsfras: aiResponse.Halsningsfras,
 		},
 		Datum: time.Now().Format("2006-01-02"),
 		Jobb: data.Jobb{
@@ -163,6 +197,13 @@ func GenerateCoverLetter(c *gin.Context) {
 		},
 	}
 
+	if format != export.FormatHTML {
+		sendFile(c, format, format.Filename("personligt-brev", request.CompanyName), aiResponse.PromptVersion, func(w io.Writer) error {
+			return export.WriteCoverLetter(w, format, &templateData, request.TemplateId)
+		})
+		return
+	}
+
 	// Välj mall baserat på templateId
 	var templateFile string
 	switch request.TemplateId {
@@ -188,6 +229,7 @@ func GenerateCoverLetter(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"html": buf.String(),
+		"html":          buf.String(),
+		"promptVersion": aiResponse.PromptVersion,
 	})
 } 
\ No newline at end of file
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/llm"
+)
+
+var coverLetterRequest = map[string]string{
+	"templateId":     "v2",
+	"jobTitle":       "Backendutvecklare",
+	"jobDescription": "Vi söker en utvecklare med erfarenhet av Go.",
+	"companyName":    "Exempelbolaget AB",
+}
+
+func TestGenerateCoverLetter(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	w := postJSON(t, GenerateCoverLetter, coverLetterRequest)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, vill ha 200: %s", w.Code, w.Body.String())
+	}
+
+	html, _ := decodeBody(t, w)["html"].(string)
+	for _, want := range []string{"Anna Andersson", "Jag söker tjänsten som backendutvecklare hos er."} {
+		if !strings.Contains(html, want) {
+			t.Errorf("brevet saknar %q", want)
+		}
+	}
+
+	requests := fakeAI.Requests()
+	if len(requests) != 1 || requests[0].Feature != "cover_letter" {
+		t.Fatalf("AI-anrop = %+v, vill ha ett cover_letter-anrop", requests)
+	}
+	if !strings.Contains(promptText(requests[0]), "Exempelbolaget AB") {
+		t.Error("prompten saknar företaget")
+	}
+}
+
+func TestGenerateCoverLetterExport(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	for _, format := range []string{"pdf", "docx"} {
+		for _, id := range []string{"creative", "v2"} {
+			req := map[string]string{"templateId": id, "jobTitle": "Backendutvecklare", "companyName": "Exempelbolaget AB", "format": format}
+			w := postJSON(t, GenerateCoverLetter, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s, mall %q: status = %d: %s", format, id, w.Code, w.Body.String())
+			}
+			if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="personligt-brev-exempelbolaget-ab.`+format+`"` {
+				t.Errorf("%s, mall %q: Content-Disposition = %q", format, id, cd)
+			}
+			if w.Body.Len() == 0 || strings.HasPrefix(w.Body.String(), "{") {
+				t.Errorf("%s, mall %q: svaret är ingen fil", format, id)
+			}
+		}
+	}
+}
+
+func TestGenerateCoverLetterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario llm.Scenario
+		body     interface{}
+		status   int
+	}{
+		{"ogiltig JSON", llm.ScenarioOK, `[]`, http.StatusBadRequest},
+		{"okänt format", llm.ScenarioOK, `{"jobTitle":"Utvecklare","format":"odt"}`, http.StatusBadRequest},
+		{"format bara för CV", llm.ScenarioOK, `{"jobTitle":"Utvecklare","format":"markdown"}`, http.StatusBadRequest},
+		{"trasig JSON från AI", llm.ScenarioMalformed, coverLetterRequest, http.StatusInternalServerError},
+		{"AI svarar inte", llm.ScenarioTimeout, coverLetterRequest, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useScenario(t, tt.scenario)
+
+			if w := postJSON(t, GenerateCoverLetter, tt.body); w.Code != tt.status {
+				t.Fatalf("status = %d, vill ha %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGenerateAICoverLetter(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	w := postJSON(t, GenerateAICoverLetter, map[string]interface{}{
+		"jobTitle":       "Backendutvecklare",
+		"jobDescription": "Go och Kubernetes",
+		"job": map[string]interface{}{
+			"company": map[string]interface{}{"name": "Exempelbolaget AB"},
+		},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, vill ha 200: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	for _, key := range []string{"introduction", "experience", "motivation", "closing"} {
+		if s, _ := body[key].(string); s == "" {
+			t.Errorf("%s är tomt", key)
+		}
+	}
+
+	requests := fakeAI.Requests()
+	if len(requests) != 1 || requests[0].Feature != "cover_letter_content" {
+		t.Fatalf("AI-anrop = %+v, vill ha ett cover_letter_content-anrop", requests)
+	}
+	prompt := promptText(requests[0])
+	if !strings.Contains(prompt, "Exempelbolaget AB") || !strings.Contains(prompt, "Backendutvecklare") {
+		t.Errorf("prompten saknar jobbet: %s", prompt)
+	}
+}
+
+func TestGenerateAICoverLetterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario llm.Scenario
+		body     string
+		status   int
+	}{
+		{"ogiltig JSON", llm.ScenarioOK, `{`, http.StatusBadRequest},
+		{"jobb saknas", llm.ScenarioOK, `{"language":"sv"}`, http.StatusBadRequest},
+		{"trasig JSON från AI", llm.ScenarioMalformed, `{"jobTitle":"Utvecklare"}`, http.StatusInternalServerError},
+		{"AI svarar inte", llm.ScenarioTimeout, `{"jobTitle":"Utvecklare"}`, http.StatusInternalServerError},
+		{"AI ligger nere", llm.ScenarioError, `{"jobTitle":"Utvecklare"}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useScenario(t, tt.scenario)
+
+			if w := postJSON(t, GenerateAICoverLetter, tt.body); w.Code != tt.status {
+				t.Fatalf("status = %d, vill ha %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
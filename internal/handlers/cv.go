@@ -2,110 +2,234 @@
 package handlers
 
 import (
+	"encoding/json"
+
 	"awesomeProject/internal/data"
+	"awesomeProject/internal/export"
+	"awesomeProject/internal/grounding"
 	"awesomeProject/internal/utils"
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-func GenerateCV(c *gin.Context) {
-	var request struct {
-		Name           string `json:"name"`
-		JobTitle       string `json:"jobTitle"`
-		JobDescription string `json:"jobDescription"`
-		Experience     string `json:"experience"`
-		Education      string `json:"education"`
-		Skills         string `json:"skills"`
-		Certifications string `json:"certifications"`
-		Bio           string `json:"bio"`
-		Email         string `json:"email"`
-		Phone         string `json:"phone"`
-		Location      string `json:"location"`
-		TemplateId    string `json:"templateId"`
+// CVRequest är underlaget för ett AI-genererat CV
+type CVRequest struct {
+	Name           string `json:"name"`
+	JobTitle       string `json:"jobTitle"`
+	JobDescription string `json:"jobDescription"`
+	Experience     string `json:"experience"`
+	Education      string `json:"education"`
+	Skills         string `json:"skills"`
+	Certifications string `json:"certifications"`
+	Bio            string `json:"bio"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Location       string `json:"location"`
+	TemplateId     string `json:"templateId"`
+	Language       string `json:"language"`
+	// Mode är "creative" (standard), där AI:n fyller på med eget innehåll,
+	// eller "faithful", där den bara får använda uppgifterna ovan
+	Mode string `json:"mode"`
+	// CV är kandidatens uppgifter i samma struktur som data.CVData. Det
+	// ersätter fritextfälten Experience, Education, Skills och Certifications
+	// och skickas till AI:n utan att kortas ned.
+	CV *data.CVData `json:"cv"`
+	// Format är "html" (standard) eller ett av exportformaten i
+	// export.ParseFormat. Query-parametern format går före.
+	Format string `json:"format"`
+}
+
+// Lägen för CV-generering
+const (
+	cvModeCreative = "creative"
+	cvModeFaithful = "faithful"
+)
+
+func (r CVRequest) faithful() bool {
+	return r.Mode == cvModeFaithful
+}
+
+// source är kandidatens egna uppgifter som ett troget CV ska hålla sig till
+func (r CVRequest) source() string {
+	parts := []string{r.Experience, r.Education, r.Skills, r.Certifications, r.Bio}
+	if r.CV != nil {
+		if b, err := json.Marshal(r.CV); err == nil {
+			parts = append(parts, string(b))
+		}
 	}
+	return strings.Join(parts, "\n")
+}
 
+// verify kontrollerar ett troget CV mot kandidatens uppgifter. Returnerar
+// nil i kreativt läge där CV:t får innehålla påhittade uppgifter.
+func (r CVRequest) verify(cv *data.CVData) *grounding.Report {
+	if !r.faithful() {
+		return nil
+	}
+	report := grounding.Verify(cv, r.source())
+	if !report.Verified {
+		log.Printf("⚠️ Det trogna CV:t innehåller %d uppgifter som inte finns i underlaget", len(report.Issues))
+	}
+	return report
+}
+
+// prompt skapar CVPrompt med alla fält inklusive jobTitle och jobDescription
+func (r CVRequest) prompt() utils.CVPrompt {
+	return utils.CVPrompt{
+		Name:           r.Name,
+		JobTitle:       r.JobTitle,
+		JobDescription: r.JobDescription,
+		Experience:     r.Experience,
+		Education:      r.Education,
+		Skills:         r.Skills,
+		Certifications: r.Certifications,
+		Bio:            r.Bio,
+		Email:          r.Email,
+		Phone:          r.Phone,
+		Location:       r.Location,
+		Language:       r.Language,
+		Faithful:       r.faithful(),
+		CV:             r.CV,
+	}
+}
+
+// GenerateCV skapar ett CV med AI och svarar med HTML eller, med format=,
+// en fil i något av exportformaten (se exportFormat)
+func GenerateCV(c *gin.Context) {
+	var request CVRequest
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(400, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
 		return
 	}
-
-	// Skapa CVPrompt med alla fält inklusive jobTitle och jobDescription
-	prompt := utils.CVPrompt{
-		Name:           request.Name,
-		JobTitle:       request.JobTitle,
-		JobDescription: request.JobDescription,
-		Experience:     request.Experience,
-		Education:      request.Education,
-			Skills:         request.Skills,
-		Certifications: request.Certifications,
-		Bio:           request.Bio,
-			Email:         request.Email,
-		Phone:         request.Phone,
-		Location:      request.Location,
+	if !validateCVRequest(c, &request) {
+		return
+	}
+	format, ok := exportFormat(c, request.Format)
+	if !ok {
+		return
 	}
 
 	// Generera AI-innehåll
-	aiResponse, err := utils.GenerateAIContent(prompt)
+	aiResponse, err := utils.GenerateAIContent(c.Request.Context(), request.prompt())
 	if err != nil {
 		log.Printf("Fel vid AI-generering: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kunde inte generera CV-innehåll"})
 		return
 	}
 
-	// Extrahera personlig information från AI-svaret
-	personligInfoMap, _ := aiResponse["personlig_info"].(map[string]interface{})
-	kontaktMap, _ := personligInfoMap["kontakt"].(map[string]interface{})
+	report := request.verify(aiResponse)
 
-	templateData := data.TemplateData{
+	if format != export.FormatHTML {
+		templateData := cvTemplateData(request, aiResponse)
+		if report != nil {
+			c.Header("X-Grounding-Verified", strconv.FormatBool(report.Verified))
+		}
+		sendFile(c, format, format.Filename("cv", templateData.PersonligInfo.Namn), aiResponse.PromptVersion, func(w io.Writer) error {
+			return export.WriteCV(w, format, &templateData, request.TemplateId)
+		})
+		return
+	}
+
+	html, err := renderCV(request, aiResponse)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"html":          html,
+		"promptVersion": aiResponse.PromptVersion,
+	}
+	if report != nil {
+		response["grounding"] = report
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// cvTemplateData gör om AI:ns CV till mallarnas data
+func cvTemplateData(request CVRequest, aiResponse *data.CVData) data.TemplateData {
+	// Kontaktuppgifter från AI-svaret, med förfrågans värden som reserv. I
+	// trogna läget går kandidatens egna uppgifter före AI:ns.
+	kontakt := make(map[string]string)
+	for _, item := range aiResponse.PersonligInfo.Kontakt {
+		if item.Varde != "" {
+			kontakt[item.Typ] = item.Varde
+		}
+	}
+	kontaktValue := func(typ, defaultValue string) string {
+		if request.faithful() && defaultValue != "" {
+			return defaultValue
+		}
+		if value, ok := kontakt[typ]; ok {
+			return value
+		}
+		return defaultValue
+	}
+	personligInfo := aiResponse.PersonligInfo
+	if request.faithful() && request.Name != "" {
+		personligInfo.Namn = request.Name
+	}
+
+	return data.TemplateData{
 		PersonligInfo: data.PersonligInfo{
-			Namn:    utils.GetStringValueFromMap(personligInfoMap, "namn", request.Name),
-			Titel:   utils.GetStringValueFromMap(personligInfoMap, "titel", request.JobTitle),
-			Bild:    utils.GetStringValueFromMap(personligInfoMap, "bild", "https://via.placeholder.com/150"),
+			Namn:  utils.GetStringValueWithDefault(personligInfo.Namn, request.Name),
+			Titel: utils.GetStringValueWithDefault(personligInfo.Titel, request.JobTitle),
+			Bild:  utils.GetStringValueWithDefault(personligInfo.Bild, "https://via.placeholder.com/150"),
 			Kontakt: []data.KontaktItem{
 				{
 					Typ:   "email",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "email", request.Email),
+					Varde: kontaktValue("email", request.Email),
 					Ikon:  "📧",
 				},
 				{
 					Typ:   "telefon",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "telefon", request.Phone),
+					Varde: kontaktValue("telefon", request.Phone),
 					Ikon:  "📱",
 				},
 				{
 					Typ:   "adress",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "adress", request.Location),
+					Varde: kontaktValue("adress", request.Location),
 					Ikon:  "📍",
 				},
 				{
 					Typ:   "linkedin",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "linkedin", "LinkedIn"),
+					Varde: kontaktValue("linkedin", "LinkedIn"),
 					Ikon:  "🔗",
 				},
 				{
 					Typ:   "github",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "github", "GitHub"),
+					Varde: kontaktValue("github", "GitHub"),
 					Ikon:  "💻",
 				},
 				{
 					Typ:   "portfolio",
-					Varde: utils.GetStringValueFromMap(kontaktMap, "portfolio", "Portfolio"),
+					Varde: kontaktValue("portfolio", "Portfolio"),
 					Ikon:  "🌐",
 				},
 			},
 		},
-		Fardigheter:         convertToStringSlice(aiResponse["fardigheter"]),
-		Sprak:               parseAISprak(aiResponse["sprak"]),
-		Profil:              utils.GetStringValue(aiResponse["profil"]),
-		Arbetslivserfarenhet: parseAIExperience(aiResponse["arbetslivserfarenhet"]),
-		Utbildning:          parseAIEducation(aiResponse["utbildning"]),
-		Projekt:             convertToStringSlice(aiResponse["projekt"]),
-		Certifieringar:      convertToStringSlice(aiResponse["certifieringar"]),
+		Fardigheter:          nonNilStrings(aiResponse.Fardigheter),
+		Sprak:                filterAISprak(aiResponse.Sprak),
+		Profil:               aiResponse.Profil,
+		Arbetslivserfarenhet: filterAIExperience(aiResponse.Arbetslivserfarenhet),
+		Utbildning:           filterAIEducation(aiResponse.Utbildning),
+		Projekt:              nonNilStrings(aiResponse.Projekt),
+		Certifieringar:       nonNilStrings(aiResponse.Certifieringar),
 	}
+}
+
+// renderCV fyller den valda mallen med AI:ns CV. Felen är formulerade för
+// klienten; detaljerna loggas.
+func renderCV(request CVRequest, aiResponse *data.CVData) (string, error) {
+	templateData := cvTemplateData(request, aiResponse)
 
 	// Välj mall baserat på template-ID
 	templateFile := "cv_template.html" // Default mall
@@ -129,149 +253,59 @@ func GenerateCV(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Fel vid parsing av template: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kunde inte ladda CV-mall"})
-		return
+		return "", errors.New("Kunde inte ladda CV-mall")
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, templateData); err != nil {
 		log.Printf("Fel vid rendering av template: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kunde inte generera CV"})
-		return
+		return "", errors.New("Kunde inte generera CV")
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"html": buf.String(),
-	})
+	return buf.String(), nil
 }
 
-// Hjälpfunktioner för att konvertera AI-svar
-func convertToStringSlice(input interface{}) []string {
+// Hjälpfunktioner för att städa AI-svar inför mallarna
+func nonNilStrings(input []string) []string {
 	if input == nil {
 		return []string{}
 	}
-	
-	slice, ok := input.([]interface{})
-	if !ok {
-		return []string{}
-	}
-
-	result := make([]string, len(slice))
-	for i, v := range slice {
-		result[i] = v.(string)
-	}
-	return result
+	return input
 }
 
-func parseAIExperience(input interface{}) []data.Arbetslivserfarenhet {
-	if input == nil {
-		return []data.Arbetslivserfarenhet{}
-	}
-
-	experiences, ok := input.([]interface{})
-	if !ok {
-		return []data.Arbetslivserfarenhet{}
-	}
-
+func filterAIExperience(input []data.Arbetslivserfarenhet) []data.Arbetslivserfarenhet {
 	var result []data.Arbetslivserfarenhet
-	for _, exp := range experiences {
-		expMap, ok := exp.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		erfarenhet := data.Arbetslivserfarenhet{
-			Titel:   getStringValueFromMap(expMap, "titel", ""),
-			Foretag: getStringValueFromMap(expMap, "foretag", ""),
-			Period:  getStringValueFromMap(expMap, "period", ""),
-		}
-
-		// Hantera beskrivning som kan vara antingen sträng eller array
-		beskrivning := expMap["beskrivning"]
-		switch v := beskrivning.(type) {
-		case string:
-			erfarenhet.Beskrivning = []string{v}
-		case []interface{}:
-			erfarenhet.Beskrivning = convertToStringSlice(v)
-		}
-
+	for _, erfarenhet := range input {
 		if erfarenhet.Titel != "" {
 			result = append(result, erfarenhet)
 		}
 	}
-
 	return result
 }
 
-func parseAIEducation(input interface{}) []data.Utbildning {
-	if input == nil {
-		return []data.Utbildning{}
-	}
-
-	eduSlice, ok := input.([]interface{})
-	if !ok {
-		return []data.Utbildning{}
-	}
-
+func filterAIEducation(input []data.Utbildning) []data.Utbildning {
 	var utbildningar []data.Utbildning
-	for _, edu := range eduSlice {
-		eduMap, ok := edu.(map[string]interface{})
-		if !ok {
+	for _, utbildning := range input {
+		// Lägg endast till om vi har meningsfull data
+		if utbildning.Examen == "" && utbildning.Skola == "" {
 			continue
 		}
 
 		// Sätt default-värden om data saknas
-		utbildning := data.Utbildning{
-			Examen:      getStringValueFromMap(eduMap, "examen", "Examen saknas"),
-			Skola:       getStringValueFromMap(eduMap, "universitet", "Universitet saknas"),
-			Period:      getStringValueFromMap(eduMap, "period", "Period saknas"),
-			Beskrivning: []string{getStringValueFromMap(eduMap, "beskrivning", "")},
-		}
-
-		// Lägg endast till om vi har meningsfull data
-		if utbildning.Examen != "Examen saknas" || utbildning.Skola != "Universitet saknas" {
-			utbildningar = append(utbildningar, utbildning)
-		}
+		utbildning.Examen = utils.GetStringValueWithDefault(utbildning.Examen, "Examen saknas")
+		utbildning.Skola = utils.GetStringValueWithDefault(utbildning.Skola, "Universitet saknas")
+		utbildning.Period = utils.GetStringValueWithDefault(utbildning.Period, "Period saknas")
+		utbildningar = append(utbildningar, utbildning)
 	}
-
 	return utbildningar
 }
 
-func parseAISprak(input interface{}) []data.Sprak {
-	if input == nil {
-		return []data.Sprak{}
-	}
-
-	sprakList, ok := input.([]interface{})
-	if !ok {
-		return []data.Sprak{}
-	}
-
+func filterAISprak(input []data.Sprak) []data.Sprak {
 	var result []data.Sprak
-	for _, sprak := range sprakList {
-		sprakMap, ok := sprak.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		
-		sprakItem := data.Sprak{
-			Sprak: getStringValueFromMap(sprakMap, "sprak", ""),
-			Niva:  getStringValueFromMap(sprakMap, "niva", ""),
-		}
-		
+	for _, sprakItem := range input {
 		if sprakItem.Sprak != "" && sprakItem.Niva != "" {
 			result = append(result, sprakItem)
 		}
 	}
 	return result
 }
-
-// Lägg till en ny funktion för att hämta strängvärden från map med default-värde
-func getStringValueFromMap(m map[string]interface{}, key, defaultValue string) string {
-	if val, exists := m[key]; exists && val != nil {
-		if strVal, ok := val.(string); ok {
-			return strVal
-		}
-	}
-	return defaultValue
-}
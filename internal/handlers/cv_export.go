@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/export"
+	"github.com/gin-gonic/gin"
+)
+
+// CVExportRequest är ett färdigt CV som ska exporteras utan AI
+type CVExportRequest struct {
+	CV         *data.CVData `json:"cv"`
+	TemplateId string       `json:"templateId"`
+	Format     string       `json:"format"`
+}
+
+// ExportCV gör om ett färdigt CV, t.ex. ett importerat eller redigerat, till
+// något av exportformaten. Formatet väljs som för GenerateCV.
+func ExportCV(c *gin.Context) {
+	var request CVExportRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
+		return
+	}
+	if request.CV == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan: cv saknas"})
+		return
+	}
+	if problems := validateCVInput(request.CV); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt CV-underlag", "details": problems})
+		return
+	}
+	format, ok := exportFormat(c, request.Format)
+	if !ok {
+		return
+	}
+
+	cvRequest := CVRequest{TemplateId: request.TemplateId}
+	if format == export.FormatHTML {
+		html, err := renderCV(cvRequest, request.CV)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"html": html})
+		return
+	}
+
+	templateData := cvTemplateData(cvRequest, request.CV)
+	sendFile(c, format, format.Filename("cv", templateData.PersonligInfo.Namn), "", func(w io.Writer) error {
+		return export.WriteCV(w, format, &templateData, request.TemplateId)
+	})
+}
+
+// ImportJSONResume läser ett CV i JSON Resume-format och svarar med det som
+// CVData, redo att skickas som cv till GenerateCV eller ExportCV
+func ImportJSONResume(c *gin.Context) {
+	cv, err := export.ReadJSONResume(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
+		return
+	}
+	if problems := validateCVInput(cv); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt CV-underlag", "details": problems})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cv": cv})
+}
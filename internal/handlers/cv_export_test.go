@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/llm"
+)
+
+var exportCV = &data.CVData{
+	PersonligInfo: data.PersonligInfo{
+		Namn:    "Anna Andersson",
+		Titel:   "Backendutvecklare",
+		Kontakt: []data.KontaktItem{{Typ: "email", Varde: "anna@example.se"}},
+	},
+	Arbetslivserfarenhet: []data.Arbetslivserfarenhet{
+		{Titel: "Backendutvecklare", Foretag: "Exempelbolaget AB", Period: "2020 – nu", Beskrivning: []string{"Byggde tjänster i Go"}},
+	},
+	Fardigheter: []string{"Go", "PostgreSQL"},
+}
+
+func TestExportCV(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	tests := []struct {
+		format, contentType, filename, want string
+	}{
+		{"text", "text/plain; charset=utf-8", "cv-anna-andersson.txt", "Backendutvecklare | Exempelbolaget AB | 2020 – nu\n- Byggde tjänster i Go"},
+		{"markdown", "text/markdown; charset=utf-8", "cv-anna-andersson.md", "# Anna Andersson\n"},
+		{"jsonresume", "application/json", "cv-anna-andersson.json", `"startDate": "2020"`},
+	}
+	for _, tt := range tests {
+		w := postJSON(t, ExportCV, map[string]interface{}{"cv": exportCV, "format": tt.format})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.format, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type = %q", tt.format, ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="`+tt.filename+`"` {
+			t.Errorf("%s: Content-Disposition = %q", tt.format, cd)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: svaret saknar %q:\n%s", tt.format, tt.want, w.Body.String())
+		}
+	}
+
+	if n := len(fakeAI.Requests()); n != 0 {
+		t.Errorf("antal AI-anrop = %d, export ska inte anropa AI", n)
+	}
+}
+
+func TestExportCVHTML(t *testing.T) {
+	w := postJSON(t, ExportCV, map[string]interface{}{"cv": exportCV})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if html, _ := decodeBody(t, w)["html"].(string); !strings.Contains(html, "Exempelbolaget AB") {
+		t.Error("HTML-svaret saknar CV:t")
+	}
+}
+
+func TestExportCVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{"cv saknas", map[string]string{"format": "text"}},
+		{"okänt format", map[string]interface{}{"cv": exportCV, "format": "odt"}},
+		{"ogiltigt CV", map[string]interface{}{"cv": map[string]interface{}{"fardigheter": []string{""}}, "format": "text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postJSON(t, ExportCV, tt.body); w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, vill ha 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportJSONResume(t *testing.T) {
+	w := postJSON(t, ImportJSONResume, map[string]interface{}{
+		"basics": map[string]interface{}{"name": "Anna Andersson", "email": "anna@example.se"},
+		"work": []map[string]interface{}{
+			{"name": "Exempelbolaget AB", "position": "Backendutvecklare", "startDate": "2020-01", "highlights": []string{"Byggde tjänster i Go"}},
+		},
+		"skills": []map[string]interface{}{{"name": "Go"}},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		CV data.CVData `json:"cv"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	exp := body.CV.Arbetslivserfarenhet
+	if len(exp) != 1 || exp[0].Foretag != "Exempelbolaget AB" || exp[0].Period != "2020-01 – nu" {
+		t.Errorf("arbetslivserfarenhet = %+v", exp)
+	}
+
+	// Det importerade CV:t går att skicka vidare till GenerateCV
+	useScenario(t, llm.ScenarioOK)
+	if w := postJSON(t, GenerateCV, map[string]interface{}{"cv": body.CV}); w.Code != http.StatusOK {
+		t.Errorf("GenerateCV med importerat CV: status = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestImportJSONResumeErrors(t *testing.T) {
+	for _, body := range []string{`{"basics":`, `{"basics": {"name": "Anna"}}`} {
+		if w := postJSON(t, ImportJSONResume, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, vill ha 400", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Gränser för det strukturerade CV-underlaget. De är generösa; syftet är att
+// stoppa uppenbart felaktiga förfrågningar, inte att korta ned långa karriärer.
+const (
+	maxCVEntries     = 50
+	maxCVListItems   = 100
+	maxCVFieldLength = 300
+	maxCVTextLength  = 2000
+	// maxCVInputLength är hur mycket av underlaget som CV-promptarna tar med
+	maxCVInputLength = 20000
+)
+
+// kontaktTyper är typerna som CV-mallarna kan visa
+var kontaktTyper = map[string]bool{
+	"email": true, "telefon": true, "adress": true, "linkedin": true, "github": true, "portfolio": true,
+}
+
+// cvValidationError listar alla problem i ett CV-underlag
+type cvValidationError struct {
+	Problems []string
+}
+
+func (e *cvValidationError) Error() string {
+	return "ogiltigt CV-underlag: " + strings.Join(e.Problems, "; ")
+}
+
+// validate kontrollerar läget och det strukturerade CV-underlaget och
+// returnerar ett *cvValidationError som listar samtliga problem
+func (r CVRequest) validate() error {
+	var problems []string
+
+	switch r.Mode {
+	case "", cvModeCreative, cvModeFaithful:
+	default:
+		problems = append(problems, fmt.Sprintf("mode: ska vara '%s' eller '%s'", cvModeCreative, cvModeFaithful))
+	}
+
+	if r.CV != nil {
+		if r.hasFreeText() {
+			problems = append(problems, "cv: använd antingen cv eller fritextfälten experience, education, skills och certifications, inte båda")
+		}
+		problems = append(problems, validateCVInput(r.CV)...)
+	}
+
+	if len(problems) > 0 {
+		return &cvValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateCVRequest svarar med 400 och en lista över problemen om förfrågan
+// inte går att generera ett CV från. Personuppgifter som bara finns i det
+// strukturerade underlaget kopieras till förfrågans fält.
+func validateCVRequest(c *gin.Context, request *CVRequest) bool {
+	if err := request.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ogiltigt CV-underlag",
+			"details": err.(*cvValidationError).Problems,
+		})
+		return false
+	}
+
+	if request.CV != nil {
+		info := request.CV.PersonligInfo
+		request.Name = utils.GetStringValueWithDefault(request.Name, info.Namn)
+		request.JobTitle = utils.GetStringValueWithDefault(request.JobTitle, info.Titel)
+		for _, item := range info.Kontakt {
+			switch item.Typ {
+			case "email":
+				request.Email = utils.GetStringValueWithDefault(request.Email, item.Varde)
+			case "telefon":
+				request.Phone = utils.GetStringValueWithDefault(request.Phone, item.Varde)
+			case "adress":
+				request.Location = utils.GetStringValueWithDefault(request.Location, item.Varde)
+			}
+		}
+	}
+	return true
+}
+
+// hasFreeText anger om något av fritextfälten för CV-underlaget är ifyllt
+func (r CVRequest) hasFreeText() bool {
+	return r.Experience != "" || r.Education != "" || r.Skills != "" || r.Certifications != ""
+}
+
+// validateCVInput kontrollerar ett strukturerat CV-underlag och returnerar
+// alla problem med sökvägar som "cv.arbetslivserfarenhet[0].foretag"
+func validateCVInput(cv *data.CVData) []string {
+	var problems []string
+	problem := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	field := func(path, value string, required bool, max int) {
+		switch {
+		case required && strings.TrimSpace(value) == "":
+			problem(path, "saknas")
+		case utf8.RuneCountInString(value) > max:
+			problem(path, "får vara högst %d tecken (är %d)", max, utf8.RuneCountInString(value))
+		}
+	}
+	list := func(path string, values []string, max int) {
+		if len(values) > maxCVListItems {
+			problem(path, "får ha högst %d poster (har %d)", maxCVListItems, len(values))
+		}
+		for i, value := range values {
+			field(fmt.Sprintf("%s[%d]", path, i), value, true, max)
+		}
+	}
+	entries := func(path string, n int) bool {
+		if n > maxCVEntries {
+			problem(path, "får ha högst %d poster (har %d)", maxCVEntries, n)
+			return false
+		}
+		return true
+	}
+
+	if len(cv.Arbetslivserfarenhet) == 0 && len(cv.Utbildning) == 0 && len(cv.Fardigheter) == 0 {
+		problems = append(problems, "cv: ange minst en arbetslivserfarenhet, utbildning eller färdighet")
+	}
+
+	info := cv.PersonligInfo
+	field("cv.personlig_info.namn", info.Namn, false, maxCVFieldLength)
+	field("cv.personlig_info.titel", info.Titel, false, maxCVFieldLength)
+	for i, item := range info.Kontakt {
+		path := fmt.Sprintf("cv.personlig_info.kontakt[%d]", i)
+		if !kontaktTyper[item.Typ] {
+			problem(path+".typ", "okänd typ '%s' (använd email, telefon, adress, linkedin, github eller portfolio)", item.Typ)
+		}
+		field(path+".varde", item.Varde, true, maxCVFieldLength)
+		if item.Typ == "email" && item.Varde != "" {
+			if _, err := mail.ParseAddress(item.Varde); err != nil {
+				problem(path+".varde", "ogiltig e-postadress '%s'", item.Varde)
+			}
+		}
+	}
+
+	field("cv.profil", cv.Profil, false, maxCVTextLength)
+
+	if entries("cv.arbetslivserfarenhet", len(cv.Arbetslivserfarenhet)) {
+		for i, exp := range cv.Arbetslivserfarenhet {
+			path := fmt.Sprintf("cv.arbetslivserfarenhet[%d]", i)
+			field(path+".titel", exp.Titel, true, maxCVFieldLength)
+			field(path+".foretag", exp.Foretag, true, maxCVFieldLength)
+			field(path+".period", exp.Period, false, maxCVFieldLength)
+			list(path+".beskrivning", exp.Beskrivning, maxCVTextLength)
+		}
+	}
+
+	if entries("cv.utbildning", len(cv.Utbildning)) {
+		for i, edu := range cv.Utbildning {
+			path := fmt.Sprintf("cv.utbildning[%d]", i)
+			if strings.TrimSpace(edu.Examen) == "" && strings.TrimSpace(edu.Skola) == "" {
+				problem(path, "ange examen eller skola")
+			}
+			field(path+".examen", edu.Examen, false, maxCVFieldLength)
+			field(path+".skola", edu.Skola, false, maxCVFieldLength)
+			field(path+".period", edu.Period, false, maxCVFieldLength)
+			list(path+".beskrivning", edu.Beskrivning, maxCVTextLength)
+		}
+	}
+
+	if entries("cv.sprak", len(cv.Sprak)) {
+		for i, sprak := range cv.Sprak {
+			path := fmt.Sprintf("cv.sprak[%d]", i)
+			field(path+".sprak", sprak.Sprak, true, maxCVFieldLength)
+			field(path+".niva", sprak.Niva, false, maxCVFieldLength)
+		}
+	}
+
+	list("cv.fardigheter", cv.Fardigheter, maxCVFieldLength)
+	list("cv.projekt", cv.Projekt, maxCVTextLength)
+	list("cv.certifieringar", cv.Certifieringar, maxCVFieldLength)
+
+	if b, err := json.MarshalIndent(cv, "", "  "); err == nil && utf8.RuneCount(b) > maxCVInputLength {
+		problems = append(problems, fmt.Sprintf("cv: underlaget är för stort (%d tecken, max %d)", utf8.RuneCount(b), maxCVInputLength))
+	}
+
+	return problems
+}
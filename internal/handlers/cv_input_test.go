@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/llm"
+)
+
+// structuredCV är ett CV-underlag med en lång karriär
+func structuredCV() *data.CVData {
+	cv := &data.CVData{
+		PersonligInfo: data.PersonligInfo{
+			Namn:    "Anna Andersson",
+			Kontakt: []data.KontaktItem{{Typ: "email", Varde: "anna@example.se"}},
+		},
+		Fardigheter: []string{"Go", "PostgreSQL"},
+		Utbildning:  []data.Utbildning{{Examen: "Civilingenjör i datateknik", Skola: "Chalmers", Period: "2015 - 2020"}},
+	}
+	for i := 0; i < 12; i++ {
+		cv.Arbetslivserfarenhet = append(cv.Arbetslivserfarenhet, data.Arbetslivserfarenhet{
+			Titel:       "Utvecklare",
+			Foretag:     "Exempelbolaget AB",
+			Period:      "2000 - 2020",
+			Beskrivning: []string{strings.Repeat("Byggde tjänster i Go. ", 10)},
+		})
+	}
+	cv.Arbetslivserfarenhet[11].Foretag = "Sista Arbetsgivaren AB"
+	return cv
+}
+
+func TestGenerateCVStructured(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	w := postJSON(t, GenerateCV, map[string]interface{}{
+		"jobTitle":   "Backendutvecklare",
+		"templateId": "modern",
+		"cv":         structuredCV(),
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	prompt := promptText(fakeAI.Requests()[0])
+	if !strings.Contains(prompt, "Sista Arbetsgivaren AB") || !strings.Contains(prompt, `"arbetslivserfarenhet"`) {
+		t.Errorf("hela underlaget ska skickas som JSON:\n%s", prompt)
+	}
+}
+
+func TestGenerateCVStructuredFaithful(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	cv := structuredCV()
+	cv.Certifieringar = []string{"Certified Kubernetes Application Developer"}
+	w := postJSON(t, GenerateCV, map[string]interface{}{"jobTitle": "Backendutvecklare", "mode": "faithful", "cv": cv})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if report, _ := body["grounding"].(map[string]interface{}); report["verified"] != true {
+		t.Errorf("fixturens CV finns i underlaget men flaggades: %v", report)
+	}
+	if html, _ := body["html"].(string); !strings.Contains(html, "anna@example.se") {
+		t.Error("kontaktuppgifterna i underlaget ska gå före AI:ns")
+	}
+}
+
+func TestGenerateCVStructuredValidation(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	cv := structuredCV()
+	cv.Arbetslivserfarenhet[1].Foretag = ""
+	cv.Utbildning = append(cv.Utbildning, data.Utbildning{Period: "2010"})
+	cv.PersonligInfo.Kontakt = append(cv.PersonligInfo.Kontakt, data.KontaktItem{Typ: "fax", Varde: "123"})
+	cv.Fardigheter = append(cv.Fardigheter, " ")
+
+	w := postJSON(t, GenerateCV, map[string]interface{}{"cv": cv, "experience": "Fem år med Go"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, vill ha 400", w.Code)
+	}
+
+	details, _ := decodeBody(t, w)["details"].([]interface{})
+	want := []string{
+		"cv: använd antingen cv eller fritextfälten",
+		"cv.arbetslivserfarenhet[1].foretag: saknas",
+		"cv.utbildning[1]: ange examen eller skola",
+		"cv.personlig_info.kontakt[1].typ: okänd typ 'fax'",
+		"cv.fardigheter[2]: saknas",
+	}
+	if len(details) != len(want) {
+		t.Errorf("details = %v", details)
+	}
+	for _, w := range want {
+		found := false
+		for _, d := range details {
+			found = found || strings.HasPrefix(d.(string), w)
+		}
+		if !found {
+			t.Errorf("details saknar %q: %v", w, details)
+		}
+	}
+	if len(fakeAI.Requests()) != 0 {
+		t.Error("ett ogiltigt underlag ska inte skickas till AI:n")
+	}
+}
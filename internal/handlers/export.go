@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"awesomeProject/internal/export"
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormat läser önskat filformat från query-parametern format eller,
+// om den saknas, från förfrågans fält. Svarar med 400 vid okänt format.
+//
+// Utan format svarar handlers med JSON som innehåller HTML. pdf ger A4 med
+// inbäddade teckensnitt och samma mall-ID:n som HTML-mallarna, docx ett
+// Word-dokument i en spalt. CV kan även exporteras som text för
+// rekryteringssystem, markdown och jsonresume (https://jsonresume.org/schema).
+func exportFormat(c *gin.Context, bodyFormat string) (export.Format, bool) {
+	value := c.Query("format")
+	if value == "" {
+		value = bodyFormat
+	}
+	format, err := export.ParseFormat(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
+		return "", false
+	}
+	return format, true
+}
+
+// sendFile svarar med en fil för nedladdning. Filen skrivs till en buffert
+// först så att ett fel kan besvaras med JSON i stället för en halv fil.
+// Filnamnet skickas i Content-Disposition och promptversionen, när filen
+// kommer från AI, i X-Prompt-Version.
+func sendFile(c *gin.Context, format export.Format, filename, promptVersion string, write func(w io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		log.Printf("❌ Fel vid export till %s: %v", format, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kunde inte skapa " + strings.ToUpper(string(format)) + "-filen"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if promptVersion != "" {
+		c.Header("X-Prompt-Version", promptVersion)
+	}
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
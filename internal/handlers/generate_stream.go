@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateCVStream fungerar som GenerateCV men skickar modellens text som
+// Server-Sent Events medan CV:t skrivs. Flödet består av:
+//
+//	event: start  {"provider": <leverantör(er)>}
+//	event: token  {"text": "..."} för varje ny textbit
+//	event: retry  {"attempt": n, "errors": [...]} när svaret var ogiltigt och
+//	              modellen skriver om det; texten som strömmats ska kastas
+//	event: done   {"cv": <validerat CV>, "html": <renderat CV>} och i trogna
+//	              läget även "grounding" med uppgifter som saknas i underlaget
+//	event: error  {"error": "..."} om genereringen misslyckas
+func GenerateCVStream(c *gin.Context) {
+	var request CVRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig förfrågan: " + err.Error()})
+		return
+	}
+	if !validateCVRequest(c, &request) {
+		return
+	}
+
+	startStream(c)
+	log.Printf("📡 Streamar CV för '%s'", request.JobTitle)
+
+	cv, err := utils.GenerateAIContentStream(c.Request.Context(), request.prompt(), streamCallbacks(c))
+	if err != nil {
+		log.Printf("Fel vid AI-generering: %v", err)
+		sendEvent(c, "error", gin.H{"error": "Kunde inte generera CV-innehåll"})
+		return
+	}
+
+	html, err := renderCV(request, cv)
+	if err != nil {
+		sendEvent(c, "error", gin.H{"error": err.Error()})
+		return
+	}
+
+	done := gin.H{"cv": cv, "html": html}
+	if report := request.verify(cv); report != nil {
+		done["grounding"] = report
+	}
+	sendEvent(c, "done", done)
+}
+
+// GenerateAICoverLetterStream fungerar som GenerateAICoverLetter men
+// strömmar texten med samma event som GenerateCVStream. Det avslutande
+// done-eventet har samma innehåll som GenerateAICoverLetters svar.
+func GenerateAICoverLetterStream(c *gin.Context) {
+	var req CoverLetterAIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !validateCoverLetterAIRequest(c, req) {
+		return
+	}
+
+	startStream(c)
+	log.Printf("📡 Streamar personligt brev för '%s'", req.JobTitle)
+
+	content, err := utils.GenerateCoverLetterContentStream(c.Request.Context(), req.contentPrompt(), streamCallbacks(c))
+	if err != nil {
+		log.Printf("Fel vid AI-generering av personligt brev: %v", err)
+		sendEvent(c, "error", gin.H{"error": "Kunde inte generera personligt brev"})
+		return
+	}
+
+	sendEvent(c, "done", content)
+}
+
+// startStream sätter SSE-huvudena och skickar start-eventet
+func startStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stäng av buffring i nginx
+
+	sendEvent(c, "start", gin.H{"provider": llm.Default().Name()})
+}
+
+// streamCallbacks skickar modellens text och omförsök vidare som event
+func streamCallbacks(c *gin.Context) llm.StreamCallbacks {
+	return llm.StreamCallbacks{
+		Token: func(text string) {
+			sendEvent(c, "token", gin.H{"text": text})
+		},
+		Retry: func(attempt int, errors []string) {
+			sendEvent(c, "retry", gin.H{"attempt": attempt, "errors": errors})
+		},
+	}
+}
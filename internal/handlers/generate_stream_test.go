@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/llm"
+)
+
+type sseEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+// parseEvents delar upp ett SSE-svar i event med JSON-data
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event.name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event.data); err != nil {
+					t.Fatalf("ogiltig data i %q: %v", block, err)
+				}
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// streamedText sätter ihop token-eventen efter det sista retry-eventet
+func streamedText(events []sseEvent) string {
+	var b strings.Builder
+	for _, e := range events {
+		switch e.name {
+		case "retry":
+			b.Reset()
+		case "token":
+			text, _ := e.data["text"].(string)
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+func TestGenerateCVStream(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	w := postJSON(t, GenerateCVStream, cvRequest)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, vill ha 200: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	events := parseEvents(t, w.Body.String())
+	if first := events[0]; first.name != "start" || first.data["provider"] != "fake" {
+		t.Errorf("första eventet = %+v, vill ha start från fake", first)
+	}
+	tokens := 0
+	for _, e := range events {
+		if e.name == "token" {
+			tokens++
+		}
+	}
+	if tokens < 2 {
+		t.Errorf("antal token-event = %d, vill ha flera", tokens)
+	}
+	if !json.Valid([]byte(streamedText(events))) {
+		t.Error("de strömmade bitarna blir inte ett helt JSON-svar")
+	}
+
+	last := events[len(events)-1]
+	if last.name != "done" {
+		t.Fatalf("sista eventet = %+v, vill ha done", last)
+	}
+	if html, _ := last.data["html"].(string); !strings.Contains(html, "Exempelbolaget AB") {
+		t.Error("done saknar renderat CV")
+	}
+	cv, _ := last.data["cv"].(map[string]interface{})
+	if info, _ := cv["personlig_info"].(map[string]interface{}); info["namn"] == nil {
+		t.Errorf("done saknar det tolkade CV:t: %v", last.data["cv"])
+	}
+}
+
+func TestGenerateCVStreamRetry(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+	fakeAI.SetResponse("cv",
+		`{"personlig_info": {"namn": "Anna"}}`,
+		`{"personlig_info": {"namn": "Anna Korrigerad", "titel": "Utvecklare", "kontakt": []}, "fardigheter": [], "sprak": [], "profil": "", "arbetslivserfarenhet": [], "utbildning": [], "projekt": [], "certifieringar": []}`,
+	)
+
+	w := postJSON(t, GenerateCVStream, cvRequest)
+	events := parseEvents(t, w.Body.String())
+
+	var retry *sseEvent
+	for i := range events {
+		if events[i].name == "retry" {
+			retry = &events[i]
+		}
+	}
+	if retry == nil {
+		t.Fatalf("inget retry-event: %s", w.Body.String())
+	}
+	if errs, _ := retry.data["errors"].([]interface{}); len(errs) == 0 {
+		t.Error("retry saknar valideringsfelen")
+	}
+	if text := streamedText(events); !strings.Contains(text, "Anna Korrigerad") {
+		t.Errorf("texten efter retry = %q", text)
+	}
+	if last := events[len(events)-1]; last.name != "done" {
+		t.Errorf("sista eventet = %+v, vill ha done", last)
+	}
+}
+
+func TestGenerateAICoverLetterStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario llm.Scenario
+		last     string
+	}{
+		{"ok", llm.ScenarioOK, "done"},
+		{"trasig JSON från AI", llm.ScenarioMalformed, "error"},
+		{"AI ligger nere", llm.ScenarioError, "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useScenario(t, tt.scenario)
+
+			w := postJSON(t, GenerateAICoverLetterStream, map[string]interface{}{
+				"jobTitle": "Backendutvecklare",
+				"job": map[string]interface{}{
+					"company": map[string]interface{}{"name": "Exempelbolaget AB"},
+				},
+			})
+			events := parseEvents(t, w.Body.String())
+			last := events[len(events)-1]
+			if last.name != tt.last {
+				t.Fatalf("sista eventet = %+v, vill ha %s", last, tt.last)
+			}
+			if tt.last == "done" {
+				if s, _ := last.data["introduction"].(string); s == "" {
+					t.Error("done saknar brevets stycken")
+				}
+				if !strings.Contains(promptText(fakeAI.Requests()[0]), "Exempelbolaget AB") {
+					t.Error("prompten saknar företaget")
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCVStreamInvalidRequest(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	if w := postJSON(t, GenerateCVStream, `{"name":`); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, vill ha 400", w.Code)
+	}
+}
+
+func TestGenerateAICoverLetterStreamInvalidRequest(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+
+	for _, body := range []string{`{"jobTitle":`, `{}`, `{"jobTitle": "  "}`} {
+		w := postJSON(t, GenerateAICoverLetterStream, body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, vill ha 400", body, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "text/event-stream") {
+			t.Errorf("%s: strömmen startades för en ogiltig förfrågan", body)
+		}
+	}
+	if n := len(fakeAI.Requests()); n != 0 {
+		t.Errorf("antal AI-anrop = %d, vill ha 0", n)
+	}
+}
+
+// Leverantörens felmeddelande ska inte nå klienten
+func TestGenerateAICoverLetterStreamHidesProviderError(t *testing.T) {
+	useScenario(t, llm.ScenarioError)
+
+	w := postJSON(t, GenerateAICoverLetterStream, map[string]string{"jobTitle": "Backendutvecklare"})
+	events := parseEvents(t, w.Body.String())
+	last := events[len(events)-1]
+	if last.name != "error" || last.data["error"] != "Kunde inte generera personligt brev" {
+		t.Errorf("sista eventet = %+v", last)
+	}
+}
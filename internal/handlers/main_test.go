@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAI är leverantören som alla AI-handlers använder i testerna
+var fakeAI = llm.NewFake()
+
+// pbStub är en stubbe för Platsbankens API
+var pbStub *platsbankenStub
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	// Mallarna laddas relativt till projektets rot
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+
+	pbStub = newPlatsbankenStub()
+	os.Setenv("PLATSBANKEN_API_URL", pbStub.server.URL+"/")
+	os.Setenv("PLATSBANKEN_JOB_DETAIL_URL", pbStub.server.URL+"/job/")
+	os.Setenv("PLATSBANKEN_CACHE", "none")
+	os.Setenv("PLATSBANKEN_MAX_RETRIES", "1")
+
+	// Kedjan ger samma tidsgräns som i drift, men kort. Brytaren ska inte
+	// öppnas av att testerna medvetet låter leverantören misslyckas.
+	llm.SetDefault(llm.NewChain(llm.ChainConfig{
+		BreakerThreshold: 1000,
+		BreakerCooldown:  time.Millisecond,
+		Timeout:          100 * time.Millisecond,
+	}, fakeAI))
+
+	// Varje test ska nå den falska leverantören; cachen testas för sig
+	llm.SetDefaultCache(nil)
+
+	// Förbrukningen bokförs bara i minnet så att testerna inte skriver till data/
+	ledger, _ := usage.NewLedger(usage.Config{})
+	usage.SetDefault(ledger)
+
+	code := m.Run()
+	pbStub.server.Close()
+	os.Exit(code)
+}
+
+// useScenario återställer den falska leverantören och väljer scenario
+func useScenario(t *testing.T, scenario llm.Scenario) {
+	t.Helper()
+	fakeAI.Reset()
+	if err := fakeAI.SetScenario(scenario); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// postJSON anropar handler med body som JSON och returnerar svaret
+func postJSON(t *testing.T, handler gin.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload []byte
+	switch b := body.(type) {
+	case string:
+		payload = []byte(b)
+	default:
+		var err error
+		if payload, err = json.Marshal(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.POST("/", handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decodeBody avkodar ett JSON-svar
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("svaret är inte JSON: %v\n%s", err, w.Body.String())
+	}
+	return body
+}
+
+// promptText sätter ihop alla meddelanden i ett AI-anrop
+func promptText(req llm.Request) string {
+	var parts []string
+	for _, m := range req.Messages {
+		parts = append(parts, m.Content)
+	}
+	return strings.Join(parts, "\n")
+}
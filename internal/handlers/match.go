@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/matching"
+	"awesomeProject/internal/platsbanken"
+	"awesomeProject/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMatchJobs begränsar hur många annonser som kan matchas i ett anrop
+const maxMatchJobs = 50
+
+type MatchRequest struct {
+	CV       data.CVData `json:"cv"`
+	JobIDs   []string    `json:"jobIds"`
+	AIRerank bool        `json:"aiRerank"`
+}
+
+// MatchJobs jämför ett CV med en lista annonser och returnerar en poäng
+// (0–100) med matchade och saknade kompetenser för varje annons
+func MatchJobs(c *gin.Context) {
+	var request MatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt förfrågningsformat"})
+		return
+	}
+
+	jobIDs := uniqueNonEmpty(request.JobIDs)
+	if len(jobIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "jobIds krävs"})
+		return
+	}
+	if len(jobIDs) > maxMatchJobs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("För många jobIds, max är %d", maxMatchJobs)})
+		return
+	}
+	for _, id := range jobIDs {
+		if !validJobID(id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Ogiltigt jobId '%s', annons-ID:n består bara av siffror", id)})
+			return
+		}
+	}
+
+	candidate := matching.NewCandidate(&request.CV)
+	if candidate.Empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CV:t saknar färdigheter och erfarenheter att matcha mot"})
+		return
+	}
+
+	client := newPlatsbankenClient()
+	var jobs []*platsbanken.JobDetail
+	found := make(map[string]bool)
+	for detail := range fetchJobDetailsConcurrently(c.Request.Context(), client, jobIDs) {
+		jobs = append(jobs, detail)
+		found[detail.ID] = true
+	}
+
+	notFound := []string{}
+	for _, id := range jobIDs {
+		if !found[id] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	results := matching.Score(candidate, jobs)
+
+	response := gin.H{
+		"results":  results,
+		"notFound": notFound,
+	}
+
+	if request.AIRerank {
+		// Omrankningen räknas mot AI-kvoten, men den deterministiska
+		// matchningen ska fungera även när kvoten är slut
+		userID, clientIP := aiUserID(c), c.ClientIP()
+		release, err := usage.Default().Reserve(userID, clientIP)
+		if err != nil {
+			response["aiError"] = err.Error()
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		defer release()
+
+		ctx := usage.WithClient(usage.WithUser(c.Request.Context(), userID), clientIP)
+		reranked, err := matching.Rerank(ctx, llm.Default(), &request.CV, jobs, results)
+		if err != nil {
+			// Den deterministiska rankningen räcker om AI:n inte svarar
+			log.Printf("⚠️ AI-omrankning misslyckades: %v", err)
+			response["aiError"] = err.Error()
+		} else {
+			response["results"] = reranked
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// validJobID anger om id ser ut som ett annons-ID från Platsbanken. ID:t
+// blir en del av adressen till jobbdetaljerna och får inte kunna ändra den.
+func validJobID(id string) bool {
+	if len(id) > 20 {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return id != ""
+}
+
+// uniqueNonEmpty tar bort tomma och dubblerade värden men behåller ordningen
+func uniqueNonEmpty(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
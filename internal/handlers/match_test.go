@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"awesomeProject/internal/data"
+)
+
+var matchCV = data.CVData{
+	PersonligInfo: data.PersonligInfo{Namn: "Anna Andersson", Titel: "Backendutvecklare"},
+	Fardigheter:   []string{"Go"},
+}
+
+func TestMatchJobs(t *testing.T) {
+	w := postJSON(t, MatchJobs, map[string]interface{}{"cv": matchCV, "jobIds": []string{"1001", "9999"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if results, _ := body["results"].([]interface{}); len(results) != 1 {
+		t.Errorf("results = %v, vill ha 1001", body["results"])
+	}
+	if notFound, _ := body["notFound"].([]interface{}); len(notFound) != 1 || notFound[0] != "9999" {
+		t.Errorf("notFound = %v", body["notFound"])
+	}
+}
+
+// jobIds blir en del av adressen till Platsbanken och får bara vara siffror
+func TestMatchJobsInvalidJobID(t *testing.T) {
+	for _, id := range []string{"../search", "1001?x=1", "1001/../1002", "12a", "123456789012345678901"} {
+		w := postJSON(t, MatchJobs, map[string]interface{}{"cv": matchCV, "jobIds": []string{"1001", id}})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, vill ha 400", id, w.Code)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"awesomeProject/internal/mailer"
+	"awesomeProject/internal/platsbanken"
+	"awesomeProject/internal/savedsearch"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSavedSearchInterval = 60 // minuter
+	minSavedSearchInterval     = 15 // minuter, för att inte belasta Platsbanken
+)
+
+var (
+	savedSearchOnce  sync.Once
+	savedSearchStore *savedsearch.Store
+)
+
+// savedSearches returnerar den delade lagringen för sparade sökningar
+func savedSearches() *savedsearch.Store {
+	savedSearchOnce.Do(func() {
+		path := os.Getenv("SAVED_SEARCHES_FILE")
+		if path == "" {
+			path = "data/saved_searches.json"
+		}
+
+		store, err := savedsearch.NewStore(path)
+		if err != nil {
+			log.Printf("⚠️ Kunde inte läsa sparade sökningar, använder endast minnet: %v", err)
+			store, _ = savedsearch.NewStore("")
+		}
+		savedSearchStore = store
+	})
+	return savedSearchStore
+}
+
+// StartSavedSearchScheduler startar bakgrundsbevakningen av sparade sökningar
+func StartSavedSearchScheduler(ctx context.Context) {
+	tick := time.Minute
+	if val := os.Getenv("SAVED_SEARCH_TICK"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			tick = d
+		}
+	}
+
+	store := savedSearches()
+	scheduler := savedsearch.NewScheduler(store, runSavedSearch, savedsearch.NewNotifier(store), tick)
+
+	if m := mailer.New(mailer.ConfigFromEnv()); m != nil {
+		baseURL := os.Getenv("PUBLIC_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080"
+		}
+		templatePath := os.Getenv("ALERT_DIGEST_TEMPLATE")
+		if templatePath == "" {
+			templatePath = "internal/templates/job-alert-digest.html"
+		}
+		digester, err := savedsearch.NewDigester(store, m, templatePath, baseURL, unsubscribeSecret())
+		if err != nil {
+			log.Printf("❌ E-postsammanfattningar är avstängda: %v", err)
+		} else {
+			scheduler.WithDigester(digester)
+		}
+	} else {
+		log.Printf("📧 SMTP_HOST är inte satt, e-postsammanfattningar är avstängda")
+	}
+
+	scheduler.Start(ctx)
+}
+
+var (
+	unsubscribeSecretOnce sync.Once
+	unsubscribeSecretKey  []byte
+)
+
+// unsubscribeSecret returnerar nyckeln som signerar avregistreringslänkar.
+// Utan ALERT_UNSUBSCRIBE_SECRET slumpas en nyckel, då slutar gamla länkar
+// att fungera efter en omstart.
+func unsubscribeSecret() []byte {
+	unsubscribeSecretOnce.Do(func() {
+		if secret := os.Getenv("ALERT_UNSUBSCRIBE_SECRET"); secret != "" {
+			unsubscribeSecretKey = []byte(secret)
+			return
+		}
+		log.Printf("⚠️ ALERT_UNSUBSCRIBE_SECRET är inte satt, använder en tillfällig nyckel")
+		unsubscribeSecretKey = make([]byte, 32)
+		if _, err := rand.Read(unsubscribeSecretKey); err != nil {
+			log.Printf("⚠️ Kunde inte skapa nyckel för avregistrering: %v", err)
+		}
+	})
+	return unsubscribeSecretKey
+}
+
+// runSavedSearch kör en lagrad SearchRequest. Med since hämtas bara annonser
+// publicerade därefter, och de lokala filtren körs på annonsernas detaljer
+// på samma sätt som i SearchJobs.
+func runSavedSearch(ctx context.Context, raw json.RawMessage, since time.Time) ([]platsbanken.Ad, error) {
+	var request SearchRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil, fmt.Errorf("kunde inte läsa sparad sökning: %v", err)
+	}
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+	if !since.IsZero() {
+		// Användarens eget publishedAfter gäller om det är senare
+		if published, err := parsePublishedAfter(request.PublishedAfter); err != nil || published.Before(since) {
+			request.PublishedAfter = since.Format(time.RFC3339)
+		}
+	}
+
+	client := newPlatsbankenClient()
+	maxJobs := request.MaxJobs
+	if maxJobs == 0 {
+		maxJobs = client.Config().DefaultMaxJobs
+	}
+
+	ads, err := fetchAllJobs(ctx, client, request, maxJobs)
+	if err != nil {
+		return nil, err
+	}
+	if len(ads) >= maxJobs {
+		log.Printf("⚠️ Sparad sökning gav minst %d annonser sedan %s, resten hoppas över", maxJobs, since.Format(time.RFC3339))
+	}
+
+	pipeline := request.filterPipeline()
+	if pipeline.Empty() || len(ads) == 0 {
+		return ads, nil
+	}
+
+	var details []*platsbanken.JobDetail
+	for detail := range fetchJobDetailsConcurrently(ctx, client, adIDs(ads)) {
+		details = append(details, detail)
+	}
+	// Utan detaljer går det inte att filtrera, och annonserna får inte
+	// markeras som sedda förrän de har filtrerats
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	kept, removed := pipeline.Apply(details)
+	log.Printf("🔎 Sparad sökning: %d av %d annonser kvar efter filtrering %v", len(kept), len(ads), removed)
+
+	keep := make(map[string]bool, len(kept))
+	for _, detail := range kept {
+		keep[detail.ID] = true
+	}
+	filtered := make([]platsbanken.Ad, 0, len(kept))
+	for _, ad := range ads {
+		if keep[ad.ID] {
+			filtered = append(filtered, ad)
+		}
+	}
+	return filtered, nil
+}
+
+// currentUserID läser användarens ID från X-User-ID och svarar med 401 om det saknas
+func currentUserID(c *gin.Context) (string, bool) {
+	userID := strings.TrimSpace(c.GetHeader("X-User-ID"))
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "X-User-ID saknas"})
+		return "", false
+	}
+	return userID, true
+}
+
+type savedSearchInput struct {
+	Name            string        `json:"name"`
+	Request         SearchRequest `json:"request"`
+	WebhookURL      string        `json:"webhookUrl"`
+	IntervalMinutes int           `json:"intervalMinutes"`
+	Email           string        `json:"email"`
+	DigestFrequency string        `json:"digestFrequency"`
+}
+
+// toSavedSearch validerar indata och svarar med 400 om något är fel
+func (in savedSearchInput) toSavedSearch(c *gin.Context, userID string) (savedsearch.SavedSearch, bool) {
+	var problems []string
+
+	if strings.TrimSpace(in.Name) == "" {
+		problems = append(problems, "name krävs")
+	}
+
+	if err := in.Request.validate(); err != nil {
+		var verr *searchValidationError
+		if errors.As(err, &verr) {
+			problems = append(problems, verr.Problems...)
+		} else {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if in.WebhookURL != "" {
+		if err := savedsearch.ValidateWebhookURL(c.Request.Context(), in.WebhookURL); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if in.Email != "" {
+		if addr, err := mail.ParseAddress(in.Email); err != nil || addr.Name != "" {
+			problems = append(problems, "email är inte en giltig e-postadress")
+		}
+		if in.DigestFrequency == "" {
+			in.DigestFrequency = savedsearch.DigestDaily
+		}
+	}
+	if in.DigestFrequency != "" {
+		if !savedsearch.ValidDigestFrequency(in.DigestFrequency) {
+			problems = append(problems, "digestFrequency måste vara daily eller weekly")
+		} else if in.Email == "" {
+			problems = append(problems, "digestFrequency kräver email")
+		}
+	}
+
+	if in.IntervalMinutes == 0 {
+		in.IntervalMinutes = defaultSavedSearchInterval
+	}
+	if in.IntervalMinutes < minSavedSearchInterval {
+		problems = append(problems, "intervalMinutes måste vara minst "+strconv.Itoa(minSavedSearchInterval))
+	}
+
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ogiltig sparad sökning",
+			"details": problems,
+		})
+		return savedsearch.SavedSearch{}, false
+	}
+
+	// Sparade sökningar körs alltid från början, paginering är meningslös här.
+	// Bevakningen rankar inte heller, så CV:t behöver inte sparas.
+	in.Request.Page, in.Request.PageSize, in.Request.Cursor = 0, 0, ""
+	in.Request.CV = nil
+	request, _ := json.Marshal(in.Request)
+
+	return savedsearch.SavedSearch{
+		UserID:          userID,
+		Name:            strings.TrimSpace(in.Name),
+		Request:         request,
+		WebhookURL:      in.WebhookURL,
+		IntervalMinutes: in.IntervalMinutes,
+		Email:           in.Email,
+		DigestFrequency: in.DigestFrequency,
+	}, true
+}
+
+// CreateSavedSearch sparar en ny sökning för användaren
+func CreateSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input savedSearchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt förfrågningsformat"})
+		return
+	}
+
+	search, ok := input.toSavedSearch(c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, savedSearches().Create(search))
+}
+
+// ListSavedSearches listar användarens sparade sökningar
+func ListSavedSearches(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"searches": savedSearches().List(userID)})
+}
+
+// GetSavedSearch hämtar en sparad sökning
+func GetSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	search, err := savedSearches().Get(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// UpdateSavedSearch ersätter en sparad sökning
+func UpdateSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input savedSearchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt förfrågningsformat"})
+		return
+	}
+
+	update, ok := input.toSavedSearch(c, userID)
+	if !ok {
+		return
+	}
+
+	search, err := savedSearches().Update(userID, c.Param("id"), update)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch tar bort en sparad sökning
+func DeleteSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := savedSearches().Delete(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetNotifications returnerar användarens notisflöde, nyast först.
+// Med ?unread=true visas bara olästa notiser.
+func GetNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	c.JSON(http.StatusOK, gin.H{"notifications": savedSearches().Notifications(userID, unreadOnly)})
+}
+
+// MarkNotificationRead markerar en notis som läst
+func MarkNotificationRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := savedSearches().MarkRead(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notisen hittades inte"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnsubscribeAlerts stänger av alla e-postsammanfattningar för en användare.
+// Länken kommer från mejlet, så den kräver en signerad token i stället för X-User-ID.
+// POST stöds för mejlklienter som använder List-Unsubscribe-Post.
+func UnsubscribeAlerts(c *gin.Context) {
+	userID := c.Query("user")
+	token := c.Query("token")
+	if userID == "" || !savedsearch.ValidUnsubscribeToken(unsubscribeSecret(), userID, token) {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8",
+			[]byte("<p>Länken är ogiltig eller har gått ut.</p>"))
+		return
+	}
+
+	count := savedSearches().Unsubscribe(userID)
+	log.Printf("📧 Användare %s avregistrerade sig från %d e-postbevakningar", userID, count)
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8",
+		[]byte("<p>Du får inte längre några mejl om nya jobb. Dina sparade sökningar finns kvar.</p>"))
+}
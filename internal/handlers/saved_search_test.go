@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunSavedSearch(t *testing.T) {
+	since := time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		request      SearchRequest
+		since        time.Time
+		wantIDs      []string
+		wantFromDate string
+	}{
+		{"baslinje", SearchRequest{SearchTerm: "utvecklare"}, time.Time{}, []string{"1001", "1002"}, ""},
+		{"sedan förra körningen", SearchRequest{SearchTerm: "utvecklare"}, since, []string{"1001", "1002"}, "2024-05-10T08:00:00Z"},
+		{"senare publishedAfter gäller", SearchRequest{SearchTerm: "utvecklare", PublishedAfter: "2024-06-01"}, since, []string{"1001", "1002"}, "2024-06-01T00:00:00Z"},
+		{"lokala filter", SearchRequest{SearchTerm: "utvecklare", ExcludeEmployers: []string{"storföretaget"}}, since, []string{"1001"}, "2024-05-10T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, _ := json.Marshal(tt.request)
+			ads, err := runSavedSearch(context.Background(), raw, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, ad := range ads {
+				ids = append(ids, ad.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("annonser = %v, vill ha %v", ids, tt.wantIDs)
+			}
+
+			search, ok := pbStub.lastSearch()
+			if !ok {
+				t.Fatal("ingen sökning gjordes")
+			}
+			fromDate := ""
+			if search.FromDate != nil {
+				fromDate = *search.FromDate
+			}
+			if fromDate != tt.wantFromDate {
+				t.Errorf("fromDate = %q, vill ha %q", fromDate, tt.wantFromDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/jobfilter"
+	"awesomeProject/internal/matching"
+	"awesomeProject/internal/platsbanken"
+)
+
+// conceptIDPattern matchar JobTechs koncept-ID:n, t.ex. "947z_JGS_Uk2"
+var conceptIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{4}_[A-Za-z0-9]{3}_[A-Za-z0-9]{3}$`)
+
+// workExtentAliases översätter läsbara namn till koncept-ID:n för arbetstid
+var workExtentAliases = map[string]string{
+	"heltid":                       platsbanken.WorkExtentFullTime,
+	"deltid":                       platsbanken.WorkExtentPartTime,
+	platsbanken.WorkExtentFullTime: platsbanken.WorkExtentFullTime,
+	platsbanken.WorkExtentPartTime: platsbanken.WorkExtentPartTime,
+}
+
+// searchValidationError listar alla ogiltiga värden i en sökförfrågan
+type searchValidationError struct {
+	Problems []string
+}
+
+func (e *searchValidationError) Error() string {
+	return "ogiltiga filter: " + strings.Join(e.Problems, "; ")
+}
+
+// hasFilters anger om förfrågan innehåller något strukturerat filter
+func (r SearchRequest) hasFilters() bool {
+	return r.Municipality != "" ||
+		len(r.OccupationFields) > 0 ||
+		len(r.OccupationGroups) > 0 ||
+		len(r.EmploymentTypes) > 0 ||
+		r.WorkExtent != "" ||
+		r.Remote != nil ||
+		r.DrivingLicenseRequired != nil ||
+		r.PublishedAfter != "" ||
+		len(r.Municipalities) > 0 ||
+		len(r.Regions) > 0
+}
+
+// validate kontrollerar alla strukturerade filter och returnerar ett
+// *searchValidationError som listar samtliga okända värden
+func (r SearchRequest) validate() error {
+	var problems []string
+
+	if strings.TrimSpace(r.SearchTerm) == "" && !r.hasFilters() {
+		problems = append(problems, "sökterm eller minst ett filter krävs")
+	}
+
+	// Yrkes- och anställningstaxonomin finns inte lokalt, så här kontrolleras
+	// bara formatet. Ett okänt ID med rätt format ger en tom träfflista.
+	checkConceptIDs := func(field string, values []string) {
+		for _, v := range values {
+			if !conceptIDPattern.MatchString(v) {
+				problems = append(problems, fmt.Sprintf("%s: ogiltigt format på koncept-ID '%s'", field, v))
+			}
+		}
+	}
+	checkConceptIDs("occupationFields", r.OccupationFields)
+	checkConceptIDs("occupationGroups", r.OccupationGroups)
+	checkConceptIDs("employmentTypes", r.EmploymentTypes)
+
+	if r.WorkExtent != "" {
+		if _, ok := resolveWorkExtent(r.WorkExtent); !ok {
+			problems = append(problems, fmt.Sprintf("workExtent: okänt värde '%s' (använd heltid, deltid eller ett koncept-ID)", r.WorkExtent))
+		}
+	}
+
+	if r.PublishedAfter != "" {
+		if _, err := parsePublishedAfter(r.PublishedAfter); err != nil {
+			problems = append(problems, fmt.Sprintf("publishedAfter: ogiltigt datum '%s' (använd ÅÅÅÅ-MM-DD)", r.PublishedAfter))
+		}
+	}
+
+	if r.Municipality != "" && data.GetMunicipalityID(r.Municipality) == "" {
+		problems = append(problems, fmt.Sprintf("municipality: okänd kommun eller okänt län '%s'", r.Municipality))
+	}
+	for _, name := range r.Municipalities {
+		if _, ok := lookupLocation(name, false); !ok {
+			problems = append(problems, fmt.Sprintf("municipalities: okänd kommun '%s'", name))
+		}
+	}
+	for _, name := range r.Regions {
+		if _, ok := lookupLocation(name, true); !ok {
+			problems = append(problems, fmt.Sprintf("regions: okänt län '%s'", name))
+		}
+	}
+
+	for _, salaryType := range r.SalaryTypes {
+		if !jobfilter.ValidSalaryType(salaryType) {
+			problems = append(problems, fmt.Sprintf("salaryTypes: okänd lönetyp '%s' (använd fast, rorlig eller fastochrorlig)", salaryType))
+		}
+	}
+
+	for _, lang := range r.Languages {
+		if !isSupportedLanguage(lang) {
+			problems = append(problems, fmt.Sprintf("languages: okänt språk '%s' (använd %s)", lang, strings.Join(jobfilter.SupportedLanguages, " eller ")))
+		}
+	}
+
+	for _, term := range r.Exclude {
+		if strings.TrimSpace(term) == "" {
+			problems = append(problems, "exclude: tomma termer är inte tillåtna")
+			break
+		}
+	}
+
+	if r.CV != nil && matching.NewCandidate(r.CV).Empty() {
+		problems = append(problems, "cv: saknar färdigheter och erfarenheter att ranka efter")
+	}
+
+	if len(problems) > 0 {
+		return &searchValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func isSupportedLanguage(lang string) bool {
+	for _, supported := range jobfilter.SupportedLanguages {
+		if strings.EqualFold(strings.TrimSpace(lang), supported) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPipeline bygger de lokala filter som körs på hämtade jobbdetaljer.
+// Utgångna annonser tas bara bort med excludeExpired, så att befintliga
+// anrop får samma resultat som innan filtren fanns.
+func (r SearchRequest) filterPipeline() *jobfilter.Pipeline {
+	var deadline jobfilter.Filter
+	if r.ExcludeExpired {
+		deadline = jobfilter.DeadlineNotPassed(time.Now())
+	}
+
+	return jobfilter.New(
+		jobfilter.Experience(r.RequiresExperience),
+		jobfilter.EmployerBlacklist(r.ExcludeEmployers),
+		jobfilter.SalaryType(r.SalaryTypes),
+		deadline,
+		jobfilter.Language(r.Languages),
+	)
+}
+
+// lookupLocation slår upp ett kommun- eller länsnamn (eller ett koncept-ID)
+// och kontrollerar att det är av rätt sort
+func lookupLocation(value string, region bool) (string, bool) {
+	name := value
+	id := ""
+	if conceptIDPattern.MatchString(value) {
+		name = data.GetMunicipalityName(value)
+		if name == "" {
+			return "", false
+		}
+		id = value
+	} else {
+		// Kräv exakt träff så att felstavningar inte tyst blir en annan ort
+		for candidate, candidateID := range data.MunicipalityMap {
+			if strings.EqualFold(candidate, value) {
+				name, id = candidate, candidateID
+				break
+			}
+		}
+		if id == "" {
+			return "", false
+		}
+	}
+
+	if strings.Contains(name, "län") != region {
+		return "", false
+	}
+	return id, true
+}
+
+// resolveWorkExtent accepterar både namn (heltid/deltid) och koncept-ID
+func resolveWorkExtent(value string) (string, bool) {
+	if id, ok := workExtentAliases[value]; ok {
+		return id, true
+	}
+	id, ok := workExtentAliases[strings.ToLower(value)]
+	return id, ok
+}
+
+func parsePublishedAfter(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// toPlatsbanken översätter en validerad förfrågan till Platsbankens format
+func (r SearchRequest) toPlatsbanken() platsbanken.SearchRequest {
+	var filters []platsbanken.Filter
+
+	// Platsbankens fritext utesluter ord som föregås av minustecken. Minuset
+	// gäller bara ett ord, så en term med flera ord delas upp.
+	words := strings.Fields(r.SearchTerm)
+	for _, term := range r.Exclude {
+		for _, word := range strings.Fields(term) {
+			if word = strings.TrimLeft(word, "-"); word != "" {
+				words = append(words, "-"+word)
+			}
+		}
+	}
+	if freetext := strings.Join(words, " "); freetext != "" {
+		filters = append(filters, platsbanken.Freetext(freetext))
+	}
+
+	if filter := locationFilter(r.Municipality); filter != nil {
+		filters = append(filters, *filter)
+	}
+	for _, name := range r.Municipalities {
+		if id, ok := lookupLocation(name, false); ok {
+			filters = append(filters, platsbanken.Municipality(id))
+		}
+	}
+	for _, name := range r.Regions {
+		if id, ok := lookupLocation(name, true); ok {
+			filters = append(filters, platsbanken.Region(id))
+		}
+	}
+
+	for _, id := range r.OccupationFields {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterOccupationField, Value: id})
+	}
+	for _, id := range r.OccupationGroups {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterOccupationGroup, Value: id})
+	}
+	for _, id := range r.EmploymentTypes {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterEmploymentType, Value: id})
+	}
+
+	if id, ok := resolveWorkExtent(r.WorkExtent); ok {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterWorkExtent, Value: id})
+	}
+	if r.Remote != nil {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterRemote, Value: strconv.FormatBool(*r.Remote)})
+	}
+	if r.DrivingLicenseRequired != nil {
+		filters = append(filters, platsbanken.Filter{Type: platsbanken.FilterDrivingLicenseRequired, Value: strconv.FormatBool(*r.DrivingLicenseRequired)})
+	}
+
+	searchReq := platsbanken.SearchRequest{Filters: filters}
+	if r.PublishedAfter != "" {
+		if t, err := parsePublishedAfter(r.PublishedAfter); err == nil {
+			fromDate := t.Format(time.RFC3339)
+			searchReq.FromDate = &fromDate
+		}
+	}
+
+	return searchReq
+}
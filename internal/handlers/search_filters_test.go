@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+// Utgångna annonser tas bara bort när klienten ber om det, så att
+// befintliga anrop till /api/search får samma resultat som tidigare
+func TestFilterPipelineDeadline(t *testing.T) {
+	details := []*platsbanken.JobDetail{
+		{ID: "1", LastApplicationDate: "2000-01-01"},
+		{ID: "2", LastApplicationDate: "2099-12-31"},
+	}
+
+	tests := []struct {
+		name     string
+		request  SearchRequest
+		wantKept int
+	}{
+		{"standard", SearchRequest{}, 2},
+		{"excludeExpired", SearchRequest{ExcludeExpired: true}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, stats := tt.request.filterPipeline().Apply(details)
+			if len(kept) != tt.wantKept {
+				t.Errorf("behölls %d jobb, vill ha %d", len(kept), tt.wantKept)
+			}
+			if removed, active := stats["deadlinePassed"]; active != tt.request.ExcludeExpired || removed != 2-tt.wantKept {
+				t.Errorf("statistik = %v", stats)
+			}
+		})
+	}
+}
+
+func TestToPlatsbankenExclude(t *testing.T) {
+	tests := []struct {
+		name       string
+		searchTerm string
+		exclude    []string
+		want       string
+	}{
+		{"ett ord", "utvecklare", []string{"senior"}, "utvecklare -senior"},
+		{"flera ord", "utvecklare", []string{"tech lead", "konsult"}, "utvecklare -tech -lead -konsult"},
+		{"extra blanksteg och minus", " utvecklare ", []string{"  -senior   java "}, "utvecklare -senior -java"},
+		{"utan sökterm", "", []string{"säljare"}, "-säljare"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := SearchRequest{SearchTerm: tt.searchTerm, Exclude: tt.exclude}.toPlatsbanken()
+			var freetext []string
+			for _, f := range req.Filters {
+				if f.Type == platsbanken.FilterFreetext {
+					freetext = append(freetext, f.Value)
+				}
+			}
+			if len(freetext) != 1 || freetext[0] != tt.want {
+				t.Errorf("fritext = %q, vill ha %q", freetext, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request SearchRequest
+		want    string // början på det enda förväntade problemet, "" om giltig
+	}{
+		{"kommun", SearchRequest{Municipality: "Göteborg"}, ""},
+		{"län", SearchRequest{Municipality: "Skåne län"}, ""},
+		{"okänd kommun", SearchRequest{SearchTerm: "lärare", Municipality: "Atlantis"}, "municipality: okänd kommun"},
+		{"koncept-ID", SearchRequest{OccupationFields: []string{"apaJ_2ja_LuF"}}, ""},
+		{"fel format", SearchRequest{OccupationFields: []string{"data/it"}}, "occupationFields: ogiltigt format"},
+		{"okänd kommun i listan", SearchRequest{Municipalities: []string{"Atlantis"}}, "municipalities: okänd kommun"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validate = %v", err)
+				}
+				return
+			}
+			var verr *searchValidationError
+			if !errors.As(err, &verr) || len(verr.Problems) != 1 || !strings.HasPrefix(verr.Problems[0], tt.want) {
+				t.Errorf("validate = %v, vill ha %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"awesomeProject/internal/platsbanken"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 25
+
+// pageCursor är innehållet i den opaka cursor som skickas till klienten
+type pageCursor struct {
+	StartIndex int    `json:"s"`
+	PageSize   int    `json:"n"`
+	Query      string `json:"q"` // Fingeravtryck av sökningen så att en cursor inte kan återanvändas för en annan sökning
+}
+
+var errInvalidCursor = errors.New("ogiltig cursor")
+
+// paginated anger om klienten har bett om en specifik sida
+func (r SearchRequest) paginated() bool {
+	return r.Cursor != "" || r.Page > 0 || r.PageSize > 0
+}
+
+// queryFingerprint identifierar en sökning oberoende av vilken sida som hämtas
+func (r SearchRequest) queryFingerprint() string {
+	r.Page, r.PageSize, r.Cursor, r.MaxJobs = 0, 0, "", 0
+	b, _ := json.Marshal(r)
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+func encodeCursor(cursor pageCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return cursor, errInvalidCursor
+	}
+	if cursor.StartIndex < 0 || cursor.PageSize <= 0 {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// resolvePage räknar ut startIndex och sidstorlek från cursor eller page/pageSize
+func (r SearchRequest) resolvePage(maxPageSize int) (startIndex, pageSize int, err error) {
+	if r.Cursor != "" {
+		cursor, err := decodeCursor(r.Cursor)
+		if err != nil {
+			return 0, 0, err
+		}
+		if cursor.Query != r.queryFingerprint() {
+			return 0, 0, errInvalidCursor
+		}
+		return cursor.StartIndex, min(cursor.PageSize, maxPageSize), nil
+	}
+
+	pageSize = r.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	page := r.Page
+	if page <= 0 {
+		page = 1
+	}
+	return (page - 1) * pageSize, pageSize, nil
+}
+
+// fetchJobsPage hämtar en enda sida med annonser från Platsbanken
+func fetchJobsPage(ctx context.Context, client *platsbanken.Client, request SearchRequest, startIndex, pageSize int) (*platsbanken.SearchResponse, error) {
+	searchReq := request.toPlatsbanken()
+	searchReq.StartIndex = startIndex
+	searchReq.MaxRecords = pageSize
+	return client.Search(ctx, searchReq)
+}
+
+// searchJobsPage svarar med en sida av sökresultatet. Detaljer hämtas bara
+// för annonserna på den begärda sidan.
+func searchJobsPage(c *gin.Context, client *platsbanken.Client, request SearchRequest) {
+	startIndex, pageSize, err := request.resolvePage(client.Config().MaxRecords)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltig cursor"})
+		return
+	}
+
+	log.Printf("Söker efter jobb med term: '%s' i kommun: '%s' (startIndex %d, sidstorlek %d)",
+		request.SearchTerm, request.Municipality, startIndex, pageSize)
+
+	result, err := fetchJobsPage(c.Request.Context(), client, request, startIndex, pageSize)
+	if err != nil {
+		log.Printf("Fel vid jobbsökning: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Behåll Platsbankens ordning även om detaljerna blir klara i annan ordning
+	detailsByID := make(map[string]*platsbanken.JobDetail, len(result.Ads))
+	for detail := range fetchJobDetailsConcurrently(c.Request.Context(), client, adIDs(result.Ads)) {
+		detailsByID[detail.ID] = detail
+	}
+
+	jobDetails := make([]*platsbanken.JobDetail, 0, len(result.Ads))
+	for _, ad := range result.Ads {
+		if detail, ok := detailsByID[ad.ID]; ok {
+			jobDetails = append(jobDetails, detail)
+		}
+	}
+
+	// Lokala filter kan göra att en sida blir kortare än pageSize, men
+	// cursorn pekar alltid på nästa annons i Platsbankens resultat
+	jobDetails, removed := request.filterPipeline().Apply(jobDetails)
+
+	// total är antalet tjänster (positions) som klienten visar, men vi
+	// bläddrar bland annonser och en annons kan ha flera tjänster. Sista
+	// sidan avgörs därför av numberOfAds.
+	total := result.Positions
+	if total == 0 {
+		total = result.NumberOfAds
+	}
+	nextIndex := startIndex + len(result.Ads)
+
+	var nextCursor interface{}
+	if len(result.Ads) > 0 && (nextIndex < result.NumberOfAds || (result.NumberOfAds == 0 && len(result.Ads) == pageSize)) {
+		nextCursor = encodeCursor(pageCursor{
+			StartIndex: nextIndex,
+			PageSize:   pageSize,
+			Query:      request.queryFingerprint(),
+		})
+	}
+
+	// Med CV rankas bara annonserna på den här sidan, ordningen mellan
+	// sidorna är fortfarande Platsbankens
+	var jobsResponse interface{} = jobDetails
+	if request.CV != nil {
+		jobsResponse = rankByCV(request, jobDetails)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":        jobsResponse,
+		"total":       total,
+		"numberOfAds": result.NumberOfAds,
+		"pageSize":    pageSize,
+		"page":        startIndex/pageSize + 1,
+		"nextCursor":  nextCursor,
+		"debug": gin.H{
+			"startIndex":      startIndex,
+			"jobsOnPage":      len(result.Ads),
+			"removedByFilter": removed,
+			"searchQuery":     request.SearchTerm,
+			"municipality":    request.Municipality,
+			"rankedByCV":      request.CV != nil,
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSearchJobsPage(t *testing.T) {
+	w := postJSON(t, SearchJobs, map[string]interface{}{"search_term": "utvecklare", "pageSize": 1})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+
+	// total är antalet tjänster, numberOfAds styr när sidorna tar slut
+	if body["total"] != float64(3) || body["numberOfAds"] != float64(2) {
+		t.Errorf("total = %v, numberOfAds = %v", body["total"], body["numberOfAds"])
+	}
+	jobs := body["jobs"].([]interface{})
+	if len(jobs) != 1 || jobs[0].(map[string]interface{})["id"] != "1001" {
+		t.Fatalf("första sidan = %v", jobs)
+	}
+	cursor, ok := body["nextCursor"].(string)
+	if !ok {
+		t.Fatalf("nextCursor saknas: %v", body)
+	}
+
+	w = postJSON(t, SearchJobs, map[string]interface{}{"search_term": "utvecklare", "cursor": cursor})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	body = decodeBody(t, w)
+	jobs = body["jobs"].([]interface{})
+	if len(jobs) != 1 || jobs[0].(map[string]interface{})["id"] != "1002" || body["page"] != float64(2) {
+		t.Errorf("andra sidan = %v", body)
+	}
+	if body["nextCursor"] != nil {
+		t.Errorf("nextCursor = %v på sista sidan", body["nextCursor"])
+	}
+}
+
+// En cursor hör till en viss sökning och kan inte användas för en annan
+func TestSearchJobsCursorOtherQuery(t *testing.T) {
+	w := postJSON(t, SearchJobs, map[string]interface{}{"search_term": "utvecklare", "pageSize": 1})
+	cursor, _ := decodeBody(t, w)["nextCursor"].(string)
+	if cursor == "" {
+		t.Fatal("nextCursor saknas")
+	}
+
+	tests := []struct {
+		name    string
+		request map[string]interface{}
+	}{
+		{"annan sökterm", map[string]interface{}{"search_term": "lärare", "cursor": cursor}},
+		{"annat filter", map[string]interface{}{"search_term": "utvecklare", "excludeExpired": true, "cursor": cursor}},
+		{"trasig cursor", map[string]interface{}{"search_term": "utvecklare", "cursor": "inte-en-cursor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postJSON(t, SearchJobs, tt.request)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, vill ha 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+
+	"awesomeProject/internal/matching"
+	"awesomeProject/internal/platsbanken"
+)
+
+// rankByCV sorterar jobben efter hur väl de matchar CV:t i förfrågan. Varje
+// jobb skickas tillbaka oförändrat men med fälten score, explanation,
+// matchedSkills, missingSkills och scoreComponents tillagda.
+func rankByCV(request SearchRequest, jobs []*platsbanken.JobDetail) []map[string]interface{} {
+	candidate := matching.NewCandidate(request.CV)
+	candidate.PreferLocation(request.Municipality)
+
+	jobsByID := make(map[string]*platsbanken.JobDetail, len(jobs))
+	for _, job := range jobs {
+		jobsByID[job.ID] = job
+	}
+
+	ranked := make([]map[string]interface{}, 0, len(jobs))
+	for _, result := range matching.Score(candidate, jobs) {
+		job, ok := jobsByID[result.JobID]
+		if !ok {
+			continue
+		}
+
+		fields, err := jobFields(job)
+		if err != nil {
+			log.Printf("⚠️ Kunde inte lägga till matchning för jobb %s: %v", job.ID, err)
+			continue
+		}
+		fields["score"] = result.Score
+		fields["explanation"] = result.Explanation
+		fields["matchedSkills"] = result.MatchedSkills
+		fields["missingSkills"] = result.MissingSkills
+		fields["scoreComponents"] = result.Components
+		ranked = append(ranked, fields)
+	}
+
+	return ranked
+}
+
+// jobFields gör om jobbdetaljerna till en map så att fler fält kan läggas till
+func jobFields(job *platsbanken.JobDetail) (map[string]interface{}, error) {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchJobsStream fungerar som SearchJobs men skickar varje jobb som ett
+// Server-Sent Event så fort dess detaljer har hämtats. Flödet består av:
+//
+//	event: start     {"total": <antal annonser>}
+//	event: job       <jobbdetaljer> för varje jobb som passerar de lokala filtren
+//	event: progress  {"completed": n, "failed": n, "total": n}
+//	event: summary   samma debug-information som SearchJobs returnerar
+//	event: error     {"error": "..."} om sökningen misslyckas
+//
+// Förfrågan kan skickas som JSON (POST) eller som query-parametrar (GET),
+// så att webbläsarens EventSource kan användas direkt.
+func SearchJobsStream(c *gin.Context) {
+	client := newPlatsbankenClient()
+	started := time.Now()
+
+	var request SearchRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ogiltigt förfrågningsformat"})
+		return
+	}
+
+	if !validateSearchRequest(c, request) {
+		return
+	}
+
+	if request.MaxJobs == 0 {
+		request.MaxJobs = client.Config().DefaultMaxJobs
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stäng av buffring i nginx
+
+	ctx := c.Request.Context()
+
+	log.Printf("📡 Streamar sökning efter '%s' i kommun: '%s'", request.SearchTerm, request.Municipality)
+
+	jobs, err := fetchAllJobs(ctx, client, request, request.MaxJobs)
+	if err != nil {
+		log.Printf("Fel vid jobbsökning: %v", err)
+		sendEvent(c, "error", gin.H{"error": err.Error()})
+		return
+	}
+
+	total := len(jobs)
+	sendEvent(c, "start", gin.H{"total": total})
+
+	pipeline := request.filterPipeline()
+	removed := pipeline.NewStats()
+	completed := 0
+	kept := 0
+	details := fetchJobDetailsConcurrently(ctx, client, adIDs(jobs))
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("📡 Klienten kopplade ner efter %d av %d jobb", completed, total)
+			return
+		case detail, ok := <-details:
+			if !ok {
+				sendEvent(c, "progress", gin.H{
+					"completed": completed,
+					"failed":    total - completed,
+					"total":     total,
+				})
+				sendEvent(c, "summary", gin.H{
+					"totalJobsBeforeFilter": total,
+					"totalJobsAfterFilter":  kept,
+					"removedByFilter":       removed,
+					"searchQuery":           request.SearchTerm,
+					"municipality":          request.Municipality,
+					"durationMs":            time.Since(started).Milliseconds(),
+				})
+				return
+			}
+
+			completed++
+			if ok, removedBy := pipeline.Keep(detail); ok {
+				kept++
+				sendEvent(c, "job", detail)
+			} else {
+				removed[removedBy]++
+			}
+			sendEvent(c, "progress", gin.H{
+				"completed": completed,
+				"total":     total,
+			})
+		}
+	}
+}
+
+// sendEvent skriver ett SSE-event och flushar direkt så att klienten får det
+func sendEvent(c *gin.Context, event string, payload interface{}) {
+	c.SSEvent(event, payload)
+	c.Writer.Flush()
+}
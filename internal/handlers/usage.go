@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// AIQuota bokför AI-anropen på användaren i X-User-ID, eller på klientens
+// IP-adress om den saknas, och svarar 429 när en dagskvot är förbrukad.
+// X-User-ID sätts av klienten själv, så kvoten per IP-adress gäller alltid
+// också. Förfrågan räknas mot kvoten redan innan den har bokförts, så att
+// parallella förfrågningar inte kan gå förbi den tillsammans.
+func AIQuota() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, clientIP := aiUserID(c), c.ClientIP()
+		ctx := usage.WithClient(usage.WithUser(c.Request.Context(), userID), clientIP)
+		c.Request = c.Request.WithContext(ctx)
+
+		release, err := usage.Default().Reserve(userID, clientIP)
+		var quotaErr *usage.QuotaError
+		if errors.As(err, &quotaErr) {
+			retryAfter := int(time.Until(quotaErr.ResetAt).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Kvoten för AI-anrop är förbrukad: " + quotaErr.Error(),
+				"quota": quotaErr,
+			})
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
+
+// aiUserID är användaren som AI-anropen bokförs på
+func aiUserID(c *gin.Context) string {
+	if userID := strings.TrimSpace(c.GetHeader("X-User-ID")); userID != "" {
+		return userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// GetUsageReport hanterar GET /api/admin/usage. Perioden anges med from och
+// to (YYYY-MM-DD, båda inklusive) och standard är de senaste sju dagarna.
+// Med user visas bara den användarens anrop.
+func GetUsageReport(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	today := time.Now().In(time.Local)
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	from, to := today.AddDate(0, 0, -6), today
+
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if val := c.Query(param); val != "" {
+			date, err := time.ParseInLocation("2006-01-02", val, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " ska vara ett datum på formatet YYYY-MM-DD"})
+				return
+			}
+			*target = date
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to får inte vara före from"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage.Default().Report(from, to.AddDate(0, 0, 1), c.Query("user")))
+}
+
+// requireAdmin kontrollerar att förfrågan har Authorization: Bearer <ADMIN_TOKEN>.
+// Utan ADMIN_TOKEN är admin-endpoints avstängda.
+func requireAdmin(c *gin.Context) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin-API:t är avstängt, ADMIN_TOKEN är inte satt"})
+		return false
+	}
+
+	given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Ogiltig eller saknad admin-token"})
+		return false
+	}
+	return true
+}
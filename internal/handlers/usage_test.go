@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// useLedger sätter en tom liggare med quota under testet
+func useLedger(t *testing.T, quota usage.QuotaConfig) *usage.Ledger {
+	t.Helper()
+	ledger, err := usage.NewLedger(usage.Config{Quota: quota})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := usage.Default()
+	usage.SetDefault(ledger)
+	t.Cleanup(func() { usage.SetDefault(previous) })
+	return ledger
+}
+
+func TestAIQuota(t *testing.T) {
+	useScenario(t, llm.ScenarioOK)
+	ledger := useLedger(t, usage.QuotaConfig{UserDailyRequests: 1, IPDailyRequests: 1})
+
+	router := gin.New()
+	router.POST("/", AIQuota(), GenerateCV)
+
+	payload, _ := json.Marshal(cvRequest)
+	post := func(userID, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("anna", "192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("första anropet gav %d: %s", w.Code, w.Body.String())
+	}
+	report := ledger.Report(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "anna")
+	if report.Totals.Requests != 1 || report.Totals.TotalTokens == 0 {
+		t.Fatalf("anropet bokfördes inte: %+v", report.Totals)
+	}
+
+	w := post("anna", "192.0.2.2")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("andra anropet gav %d, vill ha 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After saknas")
+	}
+	if body := decodeBody(t, w); body["quota"] == nil {
+		t.Errorf("svaret saknar kvoten: %v", body)
+	}
+	if len(fakeAI.Requests()) != 1 {
+		t.Errorf("leverantören anropades %d gånger, vill ha 1", len(fakeAI.Requests()))
+	}
+
+	if w := post("bertil", "192.0.2.3"); w.Code != http.StatusOK {
+		t.Errorf("en annan användare gav %d", w.Code)
+	}
+
+	// Ett nytt X-User-ID från samma IP-adress ger ingen ny kvot
+	w = post("cecilia", "192.0.2.3")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("nytt X-User-ID från samma IP gav %d, vill ha 429", w.Code)
+	}
+	if quota, _ := decodeBody(t, w)["quota"].(map[string]interface{}); quota["scope"] != "ip" {
+		t.Errorf("kvot = %v, vill ha ip", quota)
+	}
+}
+
+func TestGetUsageReport(t *testing.T) {
+	ledger := useLedger(t, usage.QuotaConfig{})
+	ledger.Record(usage.Record{UserID: "anna", Feature: "cv", Provider: "fake", PromptTokens: 10, CompletionTokens: 5})
+
+	get := func(token, query string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/", GetUsageReport)
+		req := httptest.NewRequest(http.MethodGet, "/"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Setenv("ADMIN_TOKEN", "")
+	if w := get("hemligt", ""); w.Code != http.StatusForbidden {
+		t.Errorf("utan ADMIN_TOKEN gav %d, vill ha 403", w.Code)
+	}
+
+	t.Setenv("ADMIN_TOKEN", "hemligt")
+	if w := get("fel", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("fel token gav %d, vill ha 401", w.Code)
+	}
+	if w := get("hemligt", "?from=igår"); w.Code != http.StatusBadRequest {
+		t.Errorf("ogiltigt datum gav %d, vill ha 400", w.Code)
+	}
+
+	w := get("hemligt", "?user=anna")
+	if w.Code != http.StatusOK {
+		t.Fatalf("rapporten gav %d: %s", w.Code, w.Body.String())
+	}
+	totals, _ := decodeBody(t, w)["totals"].(map[string]interface{})
+	if totals["requests"] != float64(1) || totals["totalTokens"] != float64(15) {
+		t.Errorf("totals = %v", totals)
+	}
+}
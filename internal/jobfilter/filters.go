@@ -0,0 +1,225 @@
+package jobfilter
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+// funcFilter gör om en namngiven funktion till ett Filter
+type funcFilter struct {
+	name string
+	keep func(*platsbanken.JobDetail) bool
+}
+
+func (f funcFilter) Name() string                            { return f.name }
+func (f funcFilter) Keep(detail *platsbanken.JobDetail) bool { return f.keep(detail) }
+
+// Experience tar bort jobb som kräver erfarenhet när requiresExperience är
+// false. För true görs ingen filtrering eftersom jobb utan erfarenhetskrav
+// passar även den som har erfarenhet.
+func Experience(requiresExperience *bool) Filter {
+	if requiresExperience == nil || *requiresExperience {
+		return nil
+	}
+	return funcFilter{
+		name: "experience",
+		keep: func(detail *platsbanken.JobDetail) bool {
+			return !detail.RequiresExperience
+		},
+	}
+}
+
+// EmployerBlacklist tar bort jobb där arbetsgivarens namn innehåller något av namnen
+func EmployerBlacklist(employers []string) Filter {
+	var blocked []string
+	for _, e := range employers {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			blocked = append(blocked, e)
+		}
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	return funcFilter{
+		name: "employerBlacklist",
+		keep: func(detail *platsbanken.JobDetail) bool {
+			company := strings.ToLower(detail.Company.Name)
+			for _, b := range blocked {
+				if strings.Contains(company, b) {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
+
+// salaryConcept är en lönetyp i JobTechs taxonomi
+type salaryConcept struct {
+	id    string
+	label string
+}
+
+// salaryTypeConcepts är de tre lönetyper Platsbanken använder. Varje annons
+// har exakt en, så kategorierna överlappar inte.
+var salaryTypeConcepts = map[string]salaryConcept{
+	"fast":          {"oG8G_9cW_nRf", "Fast månads- vecko- eller timlön"},
+	"rorlig":        {"vVtj_qm6_GQu", "Rörlig ackords- eller provisionslön"},
+	"fastochrorlig": {"asrX_9Df_ukn", "Fast och rörlig lön"},
+}
+
+// salaryTypeAliases är äldre namn som motsvarar en av lönetyperna
+var salaryTypeAliases = map[string]string{
+	"manadslon": "fast",
+	"timlon":    "fast",
+	"provision": "rorlig",
+	"ackord":    "rorlig",
+}
+
+// ValidSalaryType anger om en lönetyp är känd
+func ValidSalaryType(salaryType string) bool {
+	_, ok := lookupSalaryType(salaryType)
+	return ok
+}
+
+func lookupSalaryType(s string) (salaryConcept, bool) {
+	key := strings.NewReplacer("ö", "o", "å", "a", "ä", "a", " ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+	if alias, ok := salaryTypeAliases[key]; ok {
+		key = alias
+	}
+	concept, ok := salaryTypeConcepts[key]
+	return concept, ok
+}
+
+// SalaryType behåller bara jobb vars lönetyp är någon av de valda. Jämförelsen
+// görs på koncept-ID:t, eller på hela etiketten om annonsen saknar ID. Jobb
+// som saknar lönetyp behålls.
+func SalaryType(salaryTypes []string) Filter {
+	ids := make(map[string]bool)
+	labels := make(map[string]bool)
+	for _, t := range salaryTypes {
+		if concept, ok := lookupSalaryType(t); ok {
+			ids[concept.id] = true
+			labels[strings.ToLower(concept.label)] = true
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return funcFilter{
+		name: "salaryType",
+		keep: func(detail *platsbanken.JobDetail) bool {
+			if detail.SalaryTypeID != "" {
+				return ids[detail.SalaryTypeID]
+			}
+			if detail.SalaryType == "" {
+				return true
+			}
+			return labels[strings.ToLower(strings.TrimSpace(detail.SalaryType))]
+		},
+	}
+}
+
+// DeadlineNotPassed tar bort jobb vars sista ansökningsdag har passerat.
+// Jobb utan (tolkningsbart) datum behålls.
+func DeadlineNotPassed(now time.Time) Filter {
+	return funcFilter{
+		name: "deadlinePassed",
+		keep: func(detail *platsbanken.JobDetail) bool {
+			deadline, ok := parseDeadline(detail.LastApplicationDate)
+			return !ok || !deadline.Before(now)
+		},
+	}
+}
+
+func parseDeadline(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local); err == nil {
+		return t, true
+	}
+	// Bara ett datum betyder att hela dagen gäller
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.Add(24*time.Hour - time.Nanosecond), true
+	}
+	return time.Time{}, false
+}
+
+// Language behåller jobb vars annonstext är skriven på något av språken
+// ("sv" eller "en"). Om språket inte går att avgöra behålls jobbet.
+func Language(languages []string) Filter {
+	allowed := map[string]bool{}
+	for _, l := range languages {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			allowed[l] = true
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	return funcFilter{
+		name: "language",
+		keep: func(detail *platsbanken.JobDetail) bool {
+			lang := DetectLanguage(detail.Title + " " + detail.Description)
+			return lang == "" || allowed[lang]
+		},
+	}
+}
+
+// SupportedLanguages är de språk DetectLanguage kan känna igen
+var SupportedLanguages = []string{"sv", "en"}
+
+var (
+	htmlTagPattern = regexp.MustCompile("<[^>]*>")
+	wordPattern    = regexp.MustCompile(`[\p{L}]+`)
+)
+
+var stopWords = map[string]map[string]bool{
+	"sv": toSet("och", "att", "det", "som", "en", "är", "av", "för", "med", "till", "på", "har", "du", "vi", "inte", "om", "eller", "hos", "ett", "vår", "dig", "kommer", "ska", "arbete", "erfarenhet"),
+	"en": toSet("and", "the", "to", "of", "you", "we", "in", "for", "with", "our", "is", "are", "be", "will", "as", "on", "your", "have", "experience", "work", "team", "an", "or"),
+}
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// DetectLanguage gör en enkel bedömning av om en text är svensk eller
+// engelsk genom att räkna vanliga småord. Returnerar "" om det är oklart.
+func DetectLanguage(text string) string {
+	text = strings.ToLower(htmlTagPattern.ReplaceAllString(text, " "))
+
+	scores := map[string]int{}
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		for lang, words := range stopWords {
+			if words[word] {
+				scores[lang]++
+			}
+		}
+	}
+
+	sv, en := scores["sv"], scores["en"]
+	switch {
+	case sv+en < 3:
+		return ""
+	case sv > en*2:
+		return "sv"
+	case en > sv*2:
+		return "en"
+	default:
+		return ""
+	}
+}
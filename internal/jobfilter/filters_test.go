@@ -0,0 +1,165 @@
+package jobfilter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+// keeps kör filtret på varje jobb och returnerar vilka som behölls
+func keeps(f Filter, details []*platsbanken.JobDetail) []bool {
+	result := make([]bool, len(details))
+	for i, d := range details {
+		result[i] = f == nil || f.Keep(d)
+	}
+	return result
+}
+
+func TestExperience(t *testing.T) {
+	yes, no := true, false
+	details := []*platsbanken.JobDetail{{RequiresExperience: true}, {RequiresExperience: false}}
+
+	tests := []struct {
+		name  string
+		value *bool
+		want  []bool
+	}{
+		{"inget val", nil, []bool{true, true}},
+		{"kräver erfarenhet", &yes, []bool{true, true}},
+		{"utan erfarenhet", &no, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		if got := keeps(Experience(tt.value), details); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, vill ha %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEmployerBlacklist(t *testing.T) {
+	details := []*platsbanken.JobDetail{
+		{Company: platsbanken.Company{Name: "Bemanningsbolaget Sverige AB"}},
+		{Company: platsbanken.Company{Name: "Volvo Cars"}},
+		{Company: platsbanken.Company{Name: ""}},
+	}
+
+	tests := []struct {
+		name      string
+		employers []string
+		want      []bool
+	}{
+		{"delnamn och versaler", []string{"BEMANNING"}, []bool{false, true, true}},
+		{"flera", []string{"volvo", " bemanning "}, []bool{false, false, true}},
+		{"tomma namn ignoreras", []string{"", "  "}, []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		if got := keeps(EmployerBlacklist(tt.employers), details); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, vill ha %v", tt.name, got, tt.want)
+		}
+	}
+	if EmployerBlacklist([]string{" "}) != nil {
+		t.Error("en lista utan namn ska inte ge något filter")
+	}
+}
+
+func TestSalaryType(t *testing.T) {
+	// Etiketterna och koncept-ID:na som Platsbanken använder
+	details := []*platsbanken.JobDetail{
+		{SalaryType: "Fast månads- vecko- eller timlön"},
+		{SalaryType: "Rörlig ackords- eller provisionslön"},
+		{SalaryType: "Fast och rörlig lön"},
+		{SalaryType: "Fast månads- vecko- eller timlön", SalaryTypeID: "oG8G_9cW_nRf"},
+		{SalaryType: "Fast och rörlig lön", SalaryTypeID: "asrX_9Df_ukn"},
+		{SalaryType: "Lön enligt överenskommelse, timme för timme"},
+		{SalaryType: ""},
+	}
+
+	tests := []struct {
+		name  string
+		types []string
+		want  []bool
+	}{
+		{"fast", []string{"fast"}, []bool{true, false, false, true, false, false, true}},
+		{"rörlig med å/ä/ö", []string{"Rörlig"}, []bool{false, true, false, false, false, false, true}},
+		{"fast och rörlig", []string{"fast och rörlig"}, []bool{false, false, true, false, true, false, true}},
+		{"äldre namn", []string{"timlon", "provision"}, []bool{true, true, false, true, false, false, true}},
+		{"okänd typ", []string{"bonus"}, []bool{true, true, true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		if got := keeps(SalaryType(tt.types), details); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, vill ha %v", tt.name, got, tt.want)
+		}
+	}
+
+	for value, want := range map[string]bool{"fast": true, "Månadslön": true, "fast_och_rorlig": true, "bonus": false} {
+		if ValidSalaryType(value) != want {
+			t.Errorf("ValidSalaryType(%q) = %v", value, !want)
+		}
+	}
+}
+
+func TestDeadlineNotPassed(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		deadline string
+		want     bool
+	}{
+		{"2024-05-11T23:59:59", true},
+		{"2024-05-09T23:59:59", false},
+		{"2024-05-10T11:00:00Z", false},
+		{"2024-05-10T23:00:00+02:00", true},
+		{"2024-05-10", true}, // hela dagen gäller
+		{"2024-05-09", false},
+		{"", true},
+		{"snarast", true},
+	}
+
+	filter := DeadlineNotPassed(now)
+	for _, tt := range tests {
+		if got := filter.Keep(&platsbanken.JobDetail{LastApplicationDate: tt.deadline}); got != tt.want {
+			t.Errorf("sista ansökningsdag %q: behålls = %v, vill ha %v", tt.deadline, got, tt.want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"svenska", "Vi söker en utvecklare som har erfarenhet av Go och vill arbeta hos oss", "sv"},
+		{"engelska", "We are looking for a developer with experience in Go to join our team", "en"},
+		{"html", "<p>We are</p><ul><li>looking for you</li><li>to join our team</li></ul>", "en"},
+		{"för kort", "Backendutvecklare Go", ""},
+		{"blandat", "Vi söker en developer to join our team och arbeta med oss för you", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("%s: DetectLanguage = %q, vill ha %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	details := []*platsbanken.JobDetail{
+		{Title: "Utvecklare", Description: "Vi söker en utvecklare som har erfarenhet av Go"},
+		{Title: "Developer", Description: "We are looking for a developer with experience in Go"},
+		{Title: "Go"},
+	}
+
+	tests := []struct {
+		languages []string
+		want      []bool
+	}{
+		{[]string{"sv"}, []bool{true, false, true}},
+		{[]string{" EN "}, []bool{false, true, true}},
+		{[]string{"sv", "en"}, []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		if got := keeps(Language(tt.languages), details); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: %v, vill ha %v", tt.languages, got, tt.want)
+		}
+	}
+}
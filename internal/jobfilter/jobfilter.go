@@ -0,0 +1,75 @@
+// Package jobfilter filtrerar jobbdetaljer efter att de hämtats från
+// Platsbanken, för villkor som inte går att uttrycka som sökfilter.
+package jobfilter
+
+import (
+	"awesomeProject/internal/platsbanken"
+)
+
+// Filter avgör om en annons ska behållas
+type Filter interface {
+	// Name används som nyckel i statistiken över bortfiltrerade jobb
+	Name() string
+	Keep(detail *platsbanken.JobDetail) bool
+}
+
+// Stats anger hur många jobb varje filter tog bort
+type Stats map[string]int
+
+// Pipeline kör en serie filter i ordning. Ett jobb räknas bara på det
+// första filter som tar bort det.
+type Pipeline struct {
+	filters []Filter
+}
+
+// New skapar en pipeline. Nil-filter hoppas över så att anroparen kan
+// skicka med villkorliga filter direkt.
+func New(filters ...Filter) *Pipeline {
+	p := &Pipeline{}
+	for _, f := range filters {
+		if f != nil {
+			p.filters = append(p.filters, f)
+		}
+	}
+	return p
+}
+
+// Keep returnerar om jobbet ska behållas och i så fall inte, vilket filter som tog bort det
+func (p *Pipeline) Keep(detail *platsbanken.JobDetail) (bool, string) {
+	for _, f := range p.filters {
+		if !f.Keep(detail) {
+			return false, f.Name()
+		}
+	}
+	return true, ""
+}
+
+// Empty anger om pipelinen saknar filter, så att anroparen kan låta bli att
+// hämta jobbdetaljer i onödan
+func (p *Pipeline) Empty() bool {
+	return len(p.filters) == 0
+}
+
+// NewStats skapar statistik med noll för varje filter i pipelinen, så att
+// debug-blocket alltid visar vilka filter som var aktiva
+func (p *Pipeline) NewStats() Stats {
+	stats := Stats{}
+	for _, f := range p.filters {
+		stats[f.Name()] = 0
+	}
+	return stats
+}
+
+// Apply filtrerar en lista med jobb och returnerar de som behölls
+func (p *Pipeline) Apply(details []*platsbanken.JobDetail) ([]*platsbanken.JobDetail, Stats) {
+	stats := p.NewStats()
+	kept := make([]*platsbanken.JobDetail, 0, len(details))
+	for _, detail := range details {
+		if ok, removedBy := p.Keep(detail); ok {
+			kept = append(kept, detail)
+		} else {
+			stats[removedBy]++
+		}
+	}
+	return kept, stats
+}
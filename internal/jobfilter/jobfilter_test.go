@@ -0,0 +1,38 @@
+package jobfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+func TestPipelineApply(t *testing.T) {
+	no := false
+	details := []*platsbanken.JobDetail{
+		{ID: "1", Company: platsbanken.Company{Name: "Bemanning AB"}, RequiresExperience: true},
+		{ID: "2", Company: platsbanken.Company{Name: "Bemanning AB"}},
+		{ID: "3", Company: platsbanken.Company{Name: "Volvo"}, RequiresExperience: true},
+		{ID: "4", Company: platsbanken.Company{Name: "Volvo"}},
+	}
+
+	// Nil-filter hoppas över, och ett jobb räknas bara på det första filtret
+	pipeline := New(Experience(&no), nil, EmployerBlacklist([]string{"bemanning"}), SalaryType(nil))
+	kept, stats := pipeline.Apply(details)
+
+	if len(kept) != 1 || kept[0].ID != "4" {
+		t.Errorf("behölls = %+v", kept)
+	}
+	want := Stats{"experience": 2, "employerBlacklist": 1}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("statistik = %v, vill ha %v", stats, want)
+	}
+}
+
+func TestPipelineEmpty(t *testing.T) {
+	details := []*platsbanken.JobDetail{{ID: "1"}, {ID: "2"}}
+	kept, stats := New().Apply(details)
+	if len(kept) != 2 || len(stats) != 0 {
+		t.Errorf("behölls = %d, statistik = %v", len(kept), stats)
+	}
+}
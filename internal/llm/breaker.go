@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState är tillståndet för en kretsbrytare
+type BreakerState int
+
+const (
+	// BreakerClosed släpper igenom alla anrop
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen släpper igenom ett provanrop i taget efter vilotiden
+	BreakerHalfOpen
+	// BreakerOpen hoppar över leverantören tills vilotiden har gått
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker är en kretsbrytare för en leverantör. Efter Threshold fel i rad
+// öppnas den och leverantören hoppas över i Cooldown. Därefter släpps ett
+// provanrop igenom; lyckas det stängs brytaren, annars öppnas den igen.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	// now kan bytas ut för att styra tiden
+	now func() time.Time
+}
+
+// NewBreaker skapar en stängd kretsbrytare
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow avgör om ett anrop får göras just nu. Returnerar true för ett
+// provanrop i halvöppet läge; resultatet ska då rapporteras med Success
+// eller Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success stänger brytaren och nollställer felräknaren
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure räknar ett fel och öppnar brytaren när gränsen nås. Ett
+// misslyckat provanrop öppnar den direkt igen.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// Release avslutar ett anrop utan att räkna det som lyckat eller misslyckat,
+// t.ex. när anroparen själv avbröt
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State returnerar brytarens aktuella tillstånd
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
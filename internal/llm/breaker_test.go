@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	type step struct {
+		name      string
+		advance   time.Duration
+		action    string // "allow", "success", "failure" eller "release"
+		wantAllow bool
+		wantState BreakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"öppnas efter tröskeln", []step{
+			{"första felet", 0, "failure", false, BreakerClosed},
+			{"släpper igenom", 0, "allow", true, BreakerClosed},
+			{"andra felet", 0, "failure", false, BreakerOpen},
+			{"hoppar över", 0, "allow", false, BreakerOpen},
+			{"under vilotiden", 9 * time.Second, "allow", false, BreakerOpen},
+		}},
+		{"lyckat anrop nollställer felen", []step{
+			{"fel", 0, "failure", false, BreakerClosed},
+			{"lyckat", 0, "success", false, BreakerClosed},
+			{"fel igen", 0, "failure", false, BreakerClosed},
+			{"släpper igenom", 0, "allow", true, BreakerClosed},
+		}},
+		{"lyckat provanrop stänger", []step{
+			{"fel", 0, "failure", false, BreakerClosed},
+			{"fel", 0, "failure", false, BreakerOpen},
+			{"efter vilotiden", 10 * time.Second, "allow", true, BreakerHalfOpen},
+			{"ett provanrop i taget", 0, "allow", false, BreakerHalfOpen},
+			{"provet lyckas", 0, "success", false, BreakerClosed},
+			{"släpper igenom", 0, "allow", true, BreakerClosed},
+		}},
+		{"misslyckat provanrop öppnar igen", []step{
+			{"fel", 0, "failure", false, BreakerClosed},
+			{"fel", 0, "failure", false, BreakerOpen},
+			{"efter vilotiden", 10 * time.Second, "allow", true, BreakerHalfOpen},
+			{"provet misslyckas", 0, "failure", false, BreakerOpen},
+			{"ny vilotid", 9 * time.Second, "allow", false, BreakerOpen},
+			{"efter nya vilotiden", time.Second, "allow", true, BreakerHalfOpen},
+		}},
+		{"avbrutet provanrop", []step{
+			{"fel", 0, "failure", false, BreakerClosed},
+			{"fel", 0, "failure", false, BreakerOpen},
+			{"efter vilotiden", 10 * time.Second, "allow", true, BreakerHalfOpen},
+			{"anroparen avbryter", 0, "release", false, BreakerHalfOpen},
+			{"nytt provanrop", 0, "allow", true, BreakerHalfOpen},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			b := NewBreaker(2, 10*time.Second)
+			b.now = func() time.Time { return now }
+
+			for _, s := range tt.steps {
+				now = now.Add(s.advance)
+				switch s.action {
+				case "allow":
+					if got := b.Allow(); got != s.wantAllow {
+						t.Fatalf("%s: Allow = %v, vill ha %v", s.name, got, s.wantAllow)
+					}
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure()
+				case "release":
+					b.Release()
+				}
+				if got := b.State(); got != s.wantState {
+					t.Fatalf("%s: State = %s, vill ha %s", s.name, got, s.wantState)
+				}
+			}
+		})
+	}
+}
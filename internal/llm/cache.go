@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"awesomeProject/internal/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "llm_cache_requests_total",
+	Help: "Uppslag i AI-svarscachen per funktion, med resultat hit, miss, expired eller bypass",
+}, []string{"feature", "result"})
+
+func init() {
+	prometheus.MustRegister(cacheRequests)
+}
+
+// ModelNamer implementeras av leverantörer som kan säga vilken modell de
+// använder. Modellen ingår i cachenyckeln så att ett modellbyte inte ger
+// gamla svar.
+type ModelNamer interface {
+	Model() string
+}
+
+// CacheConfig styr cachen för AI-svar
+type CacheConfig struct {
+	Backend    string // "memory", "disk" eller "none"
+	TTL        time.Duration
+	MaxEntries int
+	Dir        string
+}
+
+// CacheConfigFromEnv läser AI_CACHE, AI_CACHE_TTL, AI_CACHE_MAX_ENTRIES och AI_CACHE_DIR
+func CacheConfigFromEnv() CacheConfig {
+	cfg := CacheConfig{
+		Backend:    strings.ToLower(os.Getenv("AI_CACHE")),
+		TTL:        24 * time.Hour,
+		MaxEntries: 1000,
+		Dir:        os.Getenv("AI_CACHE_DIR"),
+	}
+
+	if cfg.Backend == "" {
+		cfg.Backend = "memory"
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "data/cache/ai"
+	}
+	if val := os.Getenv("AI_CACHE_TTL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.TTL = d
+		}
+	}
+	if val := os.Getenv("AI_CACHE_MAX_ENTRIES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.MaxEntries = n
+		}
+	}
+
+	return cfg
+}
+
+// ResponseCache sparar validerade AI-svar så att samma fråga inte kostar
+// ett nytt anrop. Bara anrop med Request.CacheKey cachas.
+type ResponseCache struct {
+	store cache.Store
+	ttl   time.Duration
+}
+
+// NewResponseCache skapar en cache ovanpå store
+func NewResponseCache(store cache.Store, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{store: store, ttl: ttl}
+}
+
+// NewResponseCacheFromConfig skapar den backend som konfigurationen anger.
+// Returnerar nil om cachen är avstängd.
+func NewResponseCacheFromConfig(cfg CacheConfig) (*ResponseCache, error) {
+	switch cfg.Backend {
+	case "none", "off":
+		log.Printf("🗄️ AI-cache avstängd")
+		return nil, nil
+	case "disk":
+		log.Printf("🗄️ AI-cache på disk: %s (max %d, TTL %s)", cfg.Dir, cfg.MaxEntries, cfg.TTL)
+		store, err := cache.NewDiskStore(cfg.Dir, cfg.MaxEntries)
+		if err != nil {
+			return nil, err
+		}
+		return NewResponseCache(store, cfg.TTL), nil
+	default:
+		log.Printf("🗄️ AI-cache i minnet (max %d, TTL %s)", cfg.MaxEntries, cfg.TTL)
+		return NewResponseCache(cache.NewLRU(cfg.MaxEntries), cfg.TTL), nil
+	}
+}
+
+// cacheRouter implementeras av modeller som består av flera leverantörer,
+// som Chain. Svaret ska cachas under den leverantör som faktiskt svarade.
+type cacheRouter interface {
+	// cacheProvider returnerar leverantören som heter served, eller den
+	// som står på tur om served är tom. nil om ingen passar.
+	cacheProvider(served string) LLM
+}
+
+// cacheProvider väljer vilken leverantör cachenyckeln ska gälla
+func cacheProvider(model LLM, served string) LLM {
+	if r, ok := model.(cacheRouter); ok {
+		return r.cacheProvider(served)
+	}
+	return model
+}
+
+// cacheKey bygger nyckeln av leverantör, modell, promptversion, funktion och
+// anroparens normaliserade indata
+func cacheKey(model LLM, req Request) string {
+	modelName := ""
+	if m, ok := model.(ModelNamer); ok {
+		modelName = m.Model()
+	}
+	return strings.Join([]string{model.Name(), modelName, req.PromptVersion, req.Feature, req.CacheKey}, "|")
+}
+
+func (c *ResponseCache) get(ctx context.Context, model LLM, req Request) (*Response, bool) {
+	if c == nil || req.CacheKey == "" {
+		return nil, false
+	}
+	feature := req.Feature
+	if feature == "" {
+		feature = "unknown"
+	}
+	if CacheModeFrom(ctx) != CacheDefault {
+		cacheRequests.WithLabelValues(feature, "bypass").Inc()
+		return nil, false
+	}
+
+	provider := cacheProvider(model, "")
+	if provider == nil {
+		cacheRequests.WithLabelValues(feature, "miss").Inc()
+		return nil, false
+	}
+	key := cacheKey(provider, req)
+	entry, ok := c.store.Get(key)
+	if !ok {
+		cacheRequests.WithLabelValues(feature, "miss").Inc()
+		return nil, false
+	}
+	if !entry.Fresh(c.ttl) {
+		c.store.Delete(key)
+		cacheRequests.WithLabelValues(feature, "expired").Inc()
+		return nil, false
+	}
+
+	var resp Response
+	if err := json.Unmarshal(entry.Value, &resp); err != nil {
+		c.store.Delete(key)
+		cacheRequests.WithLabelValues(feature, "miss").Inc()
+		return nil, false
+	}
+	cacheRequests.WithLabelValues(feature, "hit").Inc()
+	resp.Cached = true
+	return &resp, true
+}
+
+func (c *ResponseCache) set(ctx context.Context, model LLM, req Request, resp *Response) {
+	if c == nil || req.CacheKey == "" || CacheModeFrom(ctx) == CacheOff {
+		return
+	}
+	provider := cacheProvider(model, resp.Provider)
+	if provider == nil {
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("⚠️ Kunde inte serialisera AI-svar för cachen: %v", err)
+		return
+	}
+	c.store.Set(cacheKey(provider, req), cache.Entry{Value: b, StoredAt: time.Now()})
+}
+
+// CacheMode styr hur ett anrop använder cachen
+type CacheMode int
+
+const (
+	// CacheDefault läser från och skriver till cachen
+	CacheDefault CacheMode = iota
+	// CacheRefresh hoppar över cachat svar men sparar det nya
+	CacheRefresh
+	// CacheOff varken läser eller skriver
+	CacheOff
+)
+
+type cacheModeKey struct{}
+
+// WithCacheMode returnerar en kontext där AI-anropen använder cachen enligt mode
+func WithCacheMode(ctx context.Context, mode CacheMode) context.Context {
+	return context.WithValue(ctx, cacheModeKey{}, mode)
+}
+
+// CacheModeFrom läser cacheläget från kontexten
+func CacheModeFrom(ctx context.Context) CacheMode {
+	if mode, ok := ctx.Value(cacheModeKey{}).(CacheMode); ok {
+		return mode
+	}
+	return CacheDefault
+}
+
+var (
+	cacheOnce    sync.Once
+	defaultCache *ResponseCache
+)
+
+// DefaultCache är cachen som GenerateJSON använder, konfigurerad från miljön.
+// Är nil om cachen är avstängd.
+func DefaultCache() *ResponseCache {
+	cacheOnce.Do(func() {
+		cache, err := NewResponseCacheFromConfig(CacheConfigFromEnv())
+		if err != nil {
+			log.Printf("⚠️ Kunde inte skapa AI-cache, fortsätter utan: %v", err)
+			return
+		}
+		defaultCache = cache
+	})
+	return defaultCache
+}
+
+// SetDefaultCache ersätter standardcachen, t.ex. i tester. nil stänger av den.
+func SetDefaultCache(cache *ResponseCache) {
+	cacheOnce.Do(func() {})
+	defaultCache = cache
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/cache"
+)
+
+// useCache sätter en tom minnescache som standardcache under testet
+func useCache(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	SetDefaultCache(NewResponseCache(cache.NewLRU(10), ttl))
+	t.Cleanup(func() { SetDefaultCache(nil) })
+}
+
+func TestGenerateJSONCache(t *testing.T) {
+	useCache(t, time.Hour)
+	fake := NewFake()
+	fake.SetResponse("test",
+		`inte JSON`,
+		`{"job": "utvecklare", "requiresExperience": null, "tags": []}`,
+		`{"job": "ny analys", "requiresExperience": null, "tags": []}`,
+	)
+	req := Request{Messages: []Message{User("analysera")}, Feature: "test", PromptVersion: "test@v1/sv", CacheKey: "utvecklare"}
+
+	generate := func(ctx context.Context, req Request) (*Response, testAnalysis) {
+		t.Helper()
+		var result testAnalysis
+		resp, err := GenerateJSON(ctx, fake, req, &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, result
+	}
+
+	// Det ogiltiga första svaret ska inte sparas, bara det validerade
+	if resp, result := generate(context.Background(), req); resp.Cached || result.Job != "utvecklare" {
+		t.Fatalf("första anropet = %+v (cachat %v)", result, resp.Cached)
+	}
+	if resp, result := generate(context.Background(), req); !resp.Cached || result.Job != "utvecklare" {
+		t.Errorf("andra anropet = %+v (cachat %v), vill ha cachat svar", result, resp.Cached)
+	}
+	if n := len(fake.Requests()); n != 2 {
+		t.Errorf("antal anrop = %d, vill ha 2", n)
+	}
+
+	// Annan promptversion eller indata ger ett nytt anrop
+	other := req
+	other.PromptVersion = "test@v2/sv"
+	if resp, _ := generate(context.Background(), other); resp.Cached {
+		t.Error("ny promptversion ska inte ge cachat svar")
+	}
+
+	// CacheOff läser inte och sparar inte, CacheRefresh sparar det nya svaret
+	if resp, _ := generate(WithCacheMode(context.Background(), CacheOff), req); resp.Cached {
+		t.Error("CacheOff ska inte läsa från cachen")
+	}
+	if resp, _ := generate(WithCacheMode(context.Background(), CacheRefresh), req); resp.Cached {
+		t.Error("CacheRefresh ska inte läsa från cachen")
+	}
+	if _, result := generate(context.Background(), req); result.Job != "ny analys" {
+		t.Errorf("efter refresh = %+v, vill ha det nya svaret", result)
+	}
+}
+
+func TestGenerateJSONCacheExpires(t *testing.T) {
+	useCache(t, time.Millisecond)
+	fake := NewFake()
+	fake.SetResponse("test", `{"job": "utvecklare", "requiresExperience": null, "tags": []}`)
+	req := Request{Feature: "test", CacheKey: "utvecklare"}
+
+	var result testAnalysis
+	for i := 0; i < 2; i++ {
+		if _, err := GenerateJSON(context.Background(), fake, req, &result); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := len(fake.Requests()); n != 2 {
+		t.Errorf("antal anrop = %d, vill ha 2 när svaret gått ut", n)
+	}
+}
+
+// Efter ett byte till reservleverantören ska dess svar cachas under dess
+// eget namn och inte under primärens
+func TestGenerateJSONCacheFailover(t *testing.T) {
+	useCache(t, time.Hour)
+	useMemoryLedger(t)
+	primary := newNamedFake(t, "primär", ScenarioError)
+	secondary := newNamedFake(t, "reserv", ScenarioOK)
+	for _, f := range []namedFake{primary, secondary} {
+		f.SetResponse("test", `{"job": "`+f.name+`", "requiresExperience": null, "tags": []}`)
+	}
+	chain := NewChain(ChainConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute, Timeout: time.Second}, primary, secondary)
+	now := time.Now()
+	chain.links[0].breaker.now = func() time.Time { return now }
+	req := Request{Feature: "test", CacheKey: "utvecklare"}
+
+	generate := func() (*Response, testAnalysis) {
+		t.Helper()
+		var result testAnalysis
+		resp, err := GenerateJSON(context.Background(), chain, req, &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, result
+	}
+
+	if _, result := generate(); result.Job != "reserv" {
+		t.Fatalf("första anropet = %+v", result)
+	}
+	if _, ok := DefaultCache().store.Get(cacheKey(secondary, req)); !ok {
+		t.Error("reservens svar cachades inte under reservens nyckel")
+	}
+	if _, ok := DefaultCache().store.Get(cacheKey(primary, req)); ok {
+		t.Error("reservens svar cachades under primärens nyckel")
+	}
+
+	// Medan primärens brytare är öppen svarar reserven, från cachen
+	primary.SetScenario(ScenarioOK)
+	if resp, result := generate(); !resp.Cached || result.Job != "reserv" {
+		t.Errorf("med öppen brytare = %+v (cachat %v)", result, resp.Cached)
+	}
+
+	// När primären får provas igen används dess egen nyckel
+	now = now.Add(time.Minute)
+	if resp, result := generate(); resp.Cached || result.Job != "primär" {
+		t.Errorf("efter vilotiden = %+v (cachat %v)", result, resp.Cached)
+	}
+	if resp, result := generate(); !resp.Cached || result.Job != "primär" {
+		t.Errorf("andra anropet till primären = %+v (cachat %v)", result, resp.Cached)
+	}
+}
@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/usage"
+)
+
+// ChainConfig styr failover-kedjan
+type ChainConfig struct {
+	// Providers är leverantörerna i den ordning de ska provas
+	Providers []string
+
+	// BreakerThreshold är antalet fel i rad som öppnar en leverantörs brytare
+	BreakerThreshold int
+	// BreakerCooldown är hur länge en öppen brytare hoppar över leverantören
+	BreakerCooldown time.Duration
+
+	// Timeout gäller varje enskilt anrop mot en leverantör
+	Timeout time.Duration
+}
+
+// ChainConfigFromEnv läser kedjan från AI_PROVIDER_CHAIN, t.ex.
+// "huggingface,gemini,openai". Utan den används bara AI_PROVIDER.
+func ChainConfigFromEnv() ChainConfig {
+	cfg := ChainConfig{
+		BreakerThreshold: 3,
+		BreakerCooldown:  30 * time.Second,
+		Timeout:          60 * time.Second,
+	}
+
+	for _, name := range strings.Split(os.Getenv("AI_PROVIDER_CHAIN"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Providers = append(cfg.Providers, name)
+		}
+	}
+	if len(cfg.Providers) == 0 {
+		name := os.Getenv("AI_PROVIDER")
+		if name == "" {
+			name = DefaultProvider
+		}
+		cfg.Providers = []string{name}
+	}
+
+	if val := os.Getenv("AI_BREAKER_THRESHOLD"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.BreakerThreshold = n
+		}
+	}
+	if val := os.Getenv("AI_BREAKER_COOLDOWN"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.BreakerCooldown = d
+		}
+	}
+	if val := os.Getenv("AI_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.Timeout = d
+		}
+	}
+
+	return cfg
+}
+
+// ErrNoProvider returneras när alla leverantörer i kedjan har öppen brytare
+var ErrNoProvider = errors.New("ingen AI-leverantör är tillgänglig just nu")
+
+// link är en leverantör i kedjan med sin egen brytare
+type link struct {
+	provider LLM
+	breaker  *Breaker
+}
+
+// Chain provar leverantörerna i tur och ordning tills en svarar. Varje
+// leverantör har en egen kretsbrytare så att en leverantör som ligger nere
+// inte gör varje anrop långsamt.
+type Chain struct {
+	links   []link
+	timeout time.Duration
+}
+
+// NewChain skapar en kedja av färdiga leverantörer
+func NewChain(cfg ChainConfig, providers ...LLM) *Chain {
+	chain := &Chain{timeout: cfg.Timeout}
+	for _, provider := range providers {
+		chain.links = append(chain.links, link{
+			provider: provider,
+			breaker:  NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		})
+		breakerState.WithLabelValues(provider.Name()).Set(float64(BreakerClosed))
+	}
+	return chain
+}
+
+// NewChainFromConfig skapar leverantörerna i cfg.Providers från registret.
+// Leverantörer som inte går att skapa loggas och hoppas över.
+func NewChainFromConfig(cfg ChainConfig) (*Chain, error) {
+	var providers []LLM
+	var errs []string
+	seen := make(map[string]bool)
+
+	for _, name := range cfg.Providers {
+		provider, err := New(name)
+		if err != nil {
+			log.Printf("⚠️ Hoppar över AI-leverantör %s: %v", name, err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		if seen[provider.Name()] {
+			continue
+		}
+		seen[provider.Name()] = true
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("ingen AI-leverantör kunde skapas: %s", strings.Join(errs, "; "))
+	}
+	return NewChain(cfg, providers...), nil
+}
+
+// Name listar leverantörerna i kedjan i ordning
+func (c *Chain) Name() string {
+	names := make([]string, len(c.links))
+	for i, l := range c.links {
+		names[i] = l.provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Model listar leverantörernas modeller i samma ordning som Name
+func (c *Chain) Model() string {
+	models := make([]string, len(c.links))
+	for i, l := range c.links {
+		if m, ok := l.provider.(ModelNamer); ok {
+			models[i] = m.Model()
+		}
+	}
+	return strings.Join(models, ",")
+}
+
+// cacheProvider gör att svar cachas per leverantör och inte under kedjans
+// sammansatta namn. Vid uppslag används den första leverantören vars brytare
+// inte är öppen, dvs. den som skulle få anropet.
+func (c *Chain) cacheProvider(served string) LLM {
+	for _, l := range c.links {
+		if served != "" && l.provider.Name() == served {
+			return l.provider
+		}
+		if served == "" && l.breaker.State() != BreakerOpen {
+			return l.provider
+		}
+	}
+	if served == "" && len(c.links) > 0 {
+		return c.links[0].provider
+	}
+	return nil
+}
+
+// Generate skickar anropet till första leverantören vars brytare släpper
+// igenom det och går vidare till nästa vid fel
+func (c *Chain) Generate(ctx context.Context, req Request) (*Response, error) {
+	return c.run(ctx, req, nil)
+}
+
+// GenerateStream fungerar som Generate men skickar texten vidare medan den
+// genereras. När en leverantör väl har skickat text byts den inte ut vid
+// fel, eftersom klienten då redan har fått en del av svaret.
+func (c *Chain) GenerateStream(ctx context.Context, req Request, onToken func(string)) (*Response, error) {
+	return c.run(ctx, req, onToken)
+}
+
+func (c *Chain) run(ctx context.Context, req Request, onToken func(string)) (*Response, error) {
+	feature := req.Feature
+	if feature == "" {
+		feature = "unknown"
+	}
+
+	var errs []string
+	for i, l := range c.links {
+		name := l.provider.Name()
+
+		if !l.breaker.Allow() {
+			providerRequests.WithLabelValues(name, feature, req.PromptVersion, "skipped").Inc()
+			errs = append(errs, fmt.Sprintf("%s: brytaren är öppen", name))
+			continue
+		}
+
+		streamed := false
+		var forward func(string)
+		if onToken != nil {
+			forward = func(token string) {
+				streamed = true
+				onToken(token)
+			}
+		}
+
+		resp, err := c.call(ctx, l.provider, req, forward)
+		if err == nil {
+			l.breaker.Success()
+			breakerState.WithLabelValues(name).Set(float64(l.breaker.State()))
+			providerRequests.WithLabelValues(name, feature, req.PromptVersion, "success").Inc()
+			if i > 0 {
+				log.Printf("🔁 %s besvarades av reservleverantören %s", feature, name)
+			}
+			return resp, nil
+		}
+
+		// Om anroparen själv har gett upp är det inte leverantörens fel
+		if ctx.Err() != nil {
+			l.breaker.Release()
+			return nil, ctx.Err()
+		}
+
+		l.breaker.Failure()
+		state := l.breaker.State()
+		breakerState.WithLabelValues(name).Set(float64(state))
+		providerRequests.WithLabelValues(name, feature, req.PromptVersion, "error").Inc()
+		log.Printf("❌ AI-leverantören %s misslyckades med %s (brytare: %s): %v", name, feature, state, err)
+		if streamed {
+			return nil, err
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, ErrNoProvider
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoProvider, strings.Join(errs, "; "))
+}
+
+func (c *Chain) call(ctx context.Context, provider LLM, req Request, onToken func(string)) (*Response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var resp *Response
+	var err error
+	if onToken != nil {
+		resp, err = Stream(ctx, provider, req, onToken)
+	} else {
+		resp, err = provider.Generate(ctx, req)
+	}
+	latency := time.Since(start)
+	providerDuration.WithLabelValues(provider.Name()).Observe(latency.Seconds())
+	recordUsage(ctx, provider, req, resp, latency, err)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("tidsgränsen på %s överskreds: %v", c.timeout, err)
+		}
+		return nil, err
+	}
+	// Leverantören i kedjan, så att cachen vet vem som svarade
+	resp.Provider = provider.Name()
+	return resp, nil
+}
+
+// recordUsage bokför anropet i förbrukningsliggaren, även misslyckade anrop
+// eftersom de också räknas mot kvoterna
+func recordUsage(ctx context.Context, provider LLM, req Request, resp *Response, latency time.Duration, err error) {
+	record := usage.Record{
+		UserID:        usage.UserFrom(ctx),
+		ClientIP:      usage.ClientFrom(ctx),
+		Feature:       req.Feature,
+		Provider:      provider.Name(),
+		PromptVersion: req.PromptVersion,
+		LatencyMs:     latency.Milliseconds(),
+		Error:         err != nil,
+	}
+	if m, ok := provider.(ModelNamer); ok {
+		record.Model = m.Model()
+	}
+	if err == nil && resp != nil {
+		record.PromptTokens = resp.Usage.PromptTokens
+		record.CompletionTokens = resp.Usage.CompletionTokens
+		if resp.Model != "" {
+			record.Model = resp.Model
+		}
+	}
+	usage.Default().Record(record)
+}
@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/usage"
+)
+
+// namedFake är en falsk leverantör med eget namn, så att en kedja kan ha
+// flera. Svaret är {"svar": namn} så att testet ser vem som svarade.
+type namedFake struct {
+	*Fake
+	name string
+}
+
+func (f namedFake) Name() string { return f.name }
+
+func newNamedFake(t *testing.T, name string, scenario Scenario) namedFake {
+	t.Helper()
+	f := namedFake{NewFake(), name}
+	if err := f.SetScenario(scenario); err != nil {
+		t.Fatal(err)
+	}
+	f.SetResponse("test", answer(name))
+	return f
+}
+
+func answer(name string) string {
+	return `{"svar": "` + name + `"}`
+}
+
+// useMemoryLedger bokför kedjans anrop i minnet under testet
+func useMemoryLedger(t *testing.T) {
+	t.Helper()
+	ledger, _ := usage.NewLedger(usage.Config{})
+	previous := usage.Default()
+	usage.SetDefault(ledger)
+	t.Cleanup(func() { usage.SetDefault(previous) })
+}
+
+func TestChainFailover(t *testing.T) {
+	useMemoryLedger(t)
+	primary := newNamedFake(t, "primär", ScenarioError)
+	secondary := newNamedFake(t, "reserv", ScenarioOK)
+	chain := NewChain(ChainConfig{BreakerThreshold: 2, BreakerCooldown: time.Minute, Timeout: time.Second}, primary, secondary)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, l := range chain.links {
+		l.breaker.now = func() time.Time { return now }
+	}
+
+	steps := []struct {
+		name         string
+		advance      time.Duration
+		primaryOK    bool
+		wantProvider string
+		wantPrimary  int // totalt antal anrop till primär
+		wantState    BreakerState
+	}{
+		{"primär ligger nere", 0, false, "reserv", 1, BreakerClosed},
+		{"brytaren öppnas", 0, false, "reserv", 2, BreakerOpen},
+		{"primär hoppas över", 0, false, "reserv", 2, BreakerOpen},
+		{"provanrop misslyckas", time.Minute, false, "reserv", 3, BreakerOpen},
+		{"fortfarande öppen", 30 * time.Second, true, "reserv", 3, BreakerOpen},
+		{"provanrop lyckas", 30 * time.Second, true, "primär", 4, BreakerClosed},
+		{"tillbaka på primär", 0, true, "primär", 5, BreakerClosed},
+	}
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		scenario := ScenarioError
+		if s.primaryOK {
+			scenario = ScenarioOK
+		}
+		primary.SetScenario(scenario)
+
+		resp, err := chain.Generate(context.Background(), Request{Feature: "test"})
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if resp.Text != answer(s.wantProvider) {
+			t.Errorf("%s: svar %s, vill ha %s", s.name, resp.Text, answer(s.wantProvider))
+		}
+		if n := len(primary.Requests()); n != s.wantPrimary {
+			t.Errorf("%s: anrop till primär = %d, vill ha %d", s.name, n, s.wantPrimary)
+		}
+		if state := chain.links[0].breaker.State(); state != s.wantState {
+			t.Errorf("%s: primärens brytare = %s, vill ha %s", s.name, state, s.wantState)
+		}
+	}
+}
+
+func TestChainAllFail(t *testing.T) {
+	useMemoryLedger(t)
+	chain := NewChain(ChainConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute, Timeout: time.Second},
+		newNamedFake(t, "a", ScenarioError), newNamedFake(t, "b", ScenarioError))
+
+	_, err := chain.Generate(context.Background(), Request{Feature: "test"})
+	if !errors.Is(err, ErrNoProvider) {
+		t.Fatalf("fel = %v, vill ha ErrNoProvider", err)
+	}
+
+	// Båda brytarna är nu öppna och ingen leverantör anropas
+	_, err = chain.Generate(context.Background(), Request{Feature: "test"})
+	if !errors.Is(err, ErrNoProvider) {
+		t.Fatalf("fel = %v, vill ha ErrNoProvider", err)
+	}
+	for _, l := range chain.links {
+		if n := len(l.provider.(namedFake).Requests()); n != 1 {
+			t.Errorf("%s anropades %d gånger, vill ha 1", l.provider.Name(), n)
+		}
+	}
+}
+
+// En leverantör som inte svarar inom tidsgränsen räknas som ett fel och
+// kedjan går vidare
+func TestChainTimeout(t *testing.T) {
+	useMemoryLedger(t)
+	chain := NewChain(ChainConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute, Timeout: 20 * time.Millisecond},
+		newNamedFake(t, "långsam", ScenarioTimeout), newNamedFake(t, "snabb", ScenarioOK))
+
+	resp, err := chain.Generate(context.Background(), Request{Feature: "test"})
+	if err != nil || resp.Text != answer("snabb") {
+		t.Fatalf("Generate = %+v, %v", resp, err)
+	}
+	if state := chain.links[0].breaker.State(); state != BreakerOpen {
+		t.Errorf("den långsamma leverantörens brytare = %s, vill ha open", state)
+	}
+}
+
+// Om anroparen själv avbryter är det inte leverantörens fel
+func TestChainCanceled(t *testing.T) {
+	useMemoryLedger(t)
+	slow := newNamedFake(t, "långsam", ScenarioTimeout)
+	other := newNamedFake(t, "annan", ScenarioOK)
+	chain := NewChain(ChainConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute, Timeout: time.Second}, slow, other)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := chain.Generate(ctx, Request{Feature: "test"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("fel = %v, vill ha context.DeadlineExceeded", err)
+	}
+	if state := chain.links[0].breaker.State(); state != BreakerClosed {
+		t.Errorf("brytaren = %s, vill ha closed", state)
+	}
+	if n := len(other.Requests()); n != 0 {
+		t.Errorf("nästa leverantör anropades %d gånger efter att anroparen gett upp", n)
+	}
+}
+
+func TestChainStream(t *testing.T) {
+	useMemoryLedger(t)
+	chain := NewChain(ChainConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute, Timeout: time.Second},
+		newNamedFake(t, "nere", ScenarioError), newNamedFake(t, "uppe", ScenarioOK))
+
+	var streamed string
+	resp, err := chain.GenerateStream(context.Background(), Request{Feature: "test"}, func(token string) {
+		streamed += token
+	})
+	if err != nil || resp.Text != answer("uppe") {
+		t.Fatalf("GenerateStream = %+v, %v", resp, err)
+	}
+	if streamed != answer("uppe") {
+		t.Errorf("strömmad text = %q", streamed)
+	}
+}
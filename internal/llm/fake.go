@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+func init() {
+	Register("fake", func() (LLM, error) {
+		return NewFakeFromEnv()
+	})
+}
+
+//go:embed fixtures/*.json
+var builtinFixtures embed.FS
+
+// Scenario styr hur den falska leverantören beter sig
+type Scenario string
+
+const (
+	// ScenarioOK svarar med fixturen för anropets funktion
+	ScenarioOK Scenario = "ok"
+	// ScenarioMalformed svarar med avklippt JSON
+	ScenarioMalformed Scenario = "malformed"
+	// ScenarioTimeout svarar inte förrän anroparens context avbryts
+	ScenarioTimeout Scenario = "timeout"
+	// ScenarioError svarar med ett fel, som när leverantören ligger nere
+	ScenarioError Scenario = "error"
+)
+
+// Fake är en deterministisk leverantör för tester och demo utan nätverk.
+// Svaren läses från fixturer per funktion (Request.Feature): först
+// AI_FAKE_FIXTURES/<feature>.json, sedan de inbyggda i fixtures/.
+type Fake struct {
+	mu        sync.Mutex
+	scenario  Scenario
+	dir       string
+	responses map[string][]string
+	requests  []Request
+}
+
+// NewFake skapar en falsk leverantör med de inbyggda fixturerna
+func NewFake() *Fake {
+	return &Fake{scenario: ScenarioOK, responses: make(map[string][]string)}
+}
+
+// NewFakeFromEnv läser AI_FAKE_FIXTURES och AI_FAKE_SCENARIO
+func NewFakeFromEnv() (*Fake, error) {
+	f := NewFake()
+	f.dir = os.Getenv("AI_FAKE_FIXTURES")
+	if val := os.Getenv("AI_FAKE_SCENARIO"); val != "" {
+		if err := f.SetScenario(Scenario(strings.ToLower(val))); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+// Model är fixturkatalogen, så att cachade svar inte blandas mellan fixturer
+func (f *Fake) Model() string { return "fixtures:" + f.dir }
+
+// SetScenario byter beteende för kommande anrop
+func (f *Fake) SetScenario(s Scenario) error {
+	switch s {
+	case ScenarioOK, ScenarioMalformed, ScenarioTimeout, ScenarioError:
+	default:
+		return fmt.Errorf("okänt scenario '%s' för AI_FAKE_SCENARIO", s)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scenario = s
+	return nil
+}
+
+// SetResponse ersätter svaret för en funktion, oavsett fixturer. Med flera
+// svar används de i tur och ordning och det sista upprepas.
+func (f *Fake) SetResponse(feature string, texts ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[feature] = texts
+}
+
+// Requests returnerar alla anrop som gjorts, i ordning
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
+
+// Reset återställer scenario, svar och anropshistorik
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scenario = ScenarioOK
+	f.responses = make(map[string][]string)
+	f.requests = nil
+}
+
+func (f *Fake) Generate(ctx context.Context, req Request) (*Response, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	scenario := f.scenario
+	var text string
+	queued := f.responses[req.Feature]
+	ok := len(queued) > 0
+	if ok {
+		text = queued[0]
+		if len(queued) > 1 {
+			f.responses[req.Feature] = queued[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	switch scenario {
+	case ScenarioTimeout:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case ScenarioError:
+		return nil, fmt.Errorf("den falska leverantören är konfigurerad att misslyckas")
+	}
+
+	if !ok {
+		var err error
+		if text, err = f.fixture(req.Feature); err != nil {
+			return nil, err
+		}
+	}
+	if scenario == ScenarioMalformed {
+		text = text[:len(text)/2]
+	}
+
+	return &Response{
+		Text:     text,
+		Provider: f.Name(),
+		Model:    "fake-" + string(scenario),
+		Usage:    fakeUsage(req, text),
+	}, nil
+}
+
+// GenerateStream skickar svaret ord för ord, som en riktig modell
+func (f *Fake) GenerateStream(ctx context.Context, req Request, onToken func(string)) (*Response, error) {
+	resp, err := f.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range strings.SplitAfter(resp.Text, " ") {
+		if token == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		onToken(token)
+	}
+	return resp, nil
+}
+
+// fixture läser fixturen för en funktion
+func (f *Fake) fixture(feature string) (string, error) {
+	if feature == "" {
+		return "", fmt.Errorf("anropet saknar Feature, den falska leverantören vet inte vad den ska svara")
+	}
+	name := filepath.Base(feature) + ".json"
+
+	if f.dir != "" {
+		if b, err := os.ReadFile(filepath.Join(f.dir, name)); err == nil {
+			return string(b), nil
+		}
+	}
+	b, err := builtinFixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		return "", fmt.Errorf("ingen fixtur för '%s'", feature)
+	}
+	return string(b), nil
+}
+
+// fakeUsage räknar ord som tokens så att förbrukningen blir förutsägbar
+func fakeUsage(req Request, text string) Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += len(strings.Fields(m.Content))
+	}
+	completion := len(strings.Fields(text))
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeScenarios(t *testing.T) {
+	fixture, err := builtinFixtures.ReadFile("fixtures/match_rerank.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		scenario Scenario
+		wantText string
+		wantErr  bool
+	}{
+		{"ok", ScenarioOK, string(fixture), false},
+		{"avklippt", ScenarioMalformed, string(fixture[:len(fixture)/2]), false},
+		{"fel", ScenarioError, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake()
+			if err := f.SetScenario(tt.scenario); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := f.Generate(context.Background(), Request{Feature: "match_rerank"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate = %+v, %v", resp, err)
+			}
+			if err == nil && resp.Text != tt.wantText {
+				t.Errorf("text = %q, vill ha %q", resp.Text, tt.wantText)
+			}
+			if len(f.Requests()) != 1 {
+				t.Errorf("anropet registrerades inte")
+			}
+		})
+	}
+}
+
+// Avklippt JSON ska inte gå att tolka, annars testar scenariot ingenting
+func TestFakeMalformedIsInvalidJSON(t *testing.T) {
+	f := NewFake()
+	f.SetScenario(ScenarioMalformed)
+	f.SetResponse("test", `{"rankings": [{"id": "1", "score": 0.9, "reason": "Go"}]}`)
+
+	resp, err := f.Generate(context.Background(), Request{Feature: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if json.Unmarshal([]byte(resp.Text), &v) == nil {
+		t.Errorf("avklippt svar %q gick att tolka som JSON", resp.Text)
+	}
+}
+
+func TestFakeTimeout(t *testing.T) {
+	f := NewFake()
+	f.SetScenario(ScenarioTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := f.Generate(ctx, Request{Feature: "match_rerank"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("fel = %v, vill ha context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("svarade efter %s, före tidsgränsen", elapsed)
+	}
+}
+
+func TestFakeResponses(t *testing.T) {
+	f := NewFake()
+	f.SetResponse("test", "första", "andra")
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		resp, err := f.Generate(context.Background(), Request{Feature: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, resp.Text)
+	}
+	if strings.Join(got, ",") != "första,andra,andra" {
+		t.Errorf("svar = %v, det sista svaret ska upprepas", got)
+	}
+
+	if _, err := f.Generate(context.Background(), Request{Feature: "finns_inte"}); err == nil {
+		t.Error("ingen fixtur borde ge ett fel")
+	}
+	if _, err := f.Generate(context.Background(), Request{}); err == nil {
+		t.Error("anrop utan Feature borde ge ett fel")
+	}
+}
+
+func TestFakeFixtureDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "test.json"), []byte(`{"egen": true}`), 0o644)
+	t.Setenv("AI_FAKE_FIXTURES", dir)
+	t.Setenv("AI_FAKE_SCENARIO", "OK")
+
+	f, err := NewFakeFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := f.Generate(context.Background(), Request{Feature: "test"})
+	if err != nil || resp.Text != `{"egen": true}` {
+		t.Errorf("Generate = %+v, %v", resp, err)
+	}
+
+	t.Setenv("AI_FAKE_SCENARIO", "långsam")
+	if _, err := NewFakeFromEnv(); err == nil {
+		t.Error("okänt scenario accepterades")
+	}
+}
+
+func TestFakeStream(t *testing.T) {
+	f := NewFake()
+	f.SetResponse("test", "Hej från den falska leverantören")
+
+	var tokens []string
+	resp, err := f.GenerateStream(context.Background(), Request{Feature: "test"}, func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 5 || strings.Join(tokens, "") != resp.Text {
+		t.Errorf("tokens = %q", tokens)
+	}
+	if resp.Usage.CompletionTokens != 5 {
+		t.Errorf("CompletionTokens = %d, vill ha 5", resp.Usage.CompletionTokens)
+	}
+}
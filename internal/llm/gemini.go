@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+func init() {
+	Register("gemini", func() (LLM, error) {
+		return NewGemini(os.Getenv("GEMINI_API_KEY"), os.Getenv("GEMINI_MODEL"))
+	})
+}
+
+const defaultGeminiModel = "gemini-1.5-flash-8b"
+
+// Gemini anropar Google Gemini via generative-ai-go
+type Gemini struct {
+	apiKey string
+	model  string
+}
+
+// NewGemini skapar en Gemini-leverantör
+func NewGemini(apiKey, model string) (*Gemini, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY är inte satt")
+	}
+	if model == "" {
+		model = defaultGeminiModel
+	}
+	return &Gemini{apiKey: apiKey, model: model}, nil
+}
+
+func (g *Gemini) Name() string { return "gemini" }
+
+func (g *Gemini) Model() string { return g.model }
+
+func (g *Gemini) Generate(ctx context.Context, req Request) (*Response, error) {
+	client, session, parts, err := g.startChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	resp, err := session.SendMessage(ctx, parts...)
+	if err != nil {
+		return nil, fmt.Errorf("Gemini generering misslyckades: %v", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, fmt.Errorf("inget svar från Gemini")
+	}
+
+	return g.response(candidateText(resp), resp.UsageMetadata), nil
+}
+
+// GenerateStream skickar varje del av Gemini-svaret till onToken
+func (g *Gemini) GenerateStream(ctx context.Context, req Request, onToken func(string)) (*Response, error) {
+	client, session, parts, err := g.startChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var text strings.Builder
+	var usage *genai.UsageMetadata
+	iter := session.SendMessageStream(ctx, parts...)
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Gemini generering misslyckades: %v", err)
+		}
+		if chunk := candidateText(resp); chunk != "" {
+			text.WriteString(chunk)
+			onToken(chunk)
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("inget svar från Gemini")
+	}
+
+	return g.response(text.String(), usage), nil
+}
+
+// startChat skapar en chatt med anropets systeminstruktion och historik och
+// returnerar det sista meddelandet som ska skickas
+func (g *Gemini) startChat(ctx context.Context, req Request) (*genai.Client, *genai.ChatSession, []genai.Part, error) {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(g.apiKey))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("kunde inte skapa Gemini-klient: %v", err)
+	}
+
+	model := client.GenerativeModel(g.model)
+	if req.Temperature > 0 {
+		model.SetTemperature(float32(req.Temperature))
+	}
+	if req.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(req.MaxTokens))
+	}
+	if req.JSON {
+		model.ResponseMIMEType = "application/json"
+	}
+
+	// Gemini har systeminstruktion och chatthistorik i stället för en meddelandelista
+	var system []string
+	var history []*genai.Content
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case RoleSystem:
+			system = append(system, msg.Content)
+		case RoleAssistant:
+			history = append(history, &genai.Content{Role: "model", Parts: []genai.Part{genai.Text(msg.Content)}})
+		default:
+			history = append(history, &genai.Content{Role: "user", Parts: []genai.Part{genai.Text(msg.Content)}})
+		}
+	}
+	if len(system) > 0 {
+		model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(strings.Join(system, "\n\n"))}}
+	}
+	if len(history) == 0 {
+		client.Close()
+		return nil, nil, nil, fmt.Errorf("inga meddelanden att skicka till Gemini")
+	}
+
+	last := history[len(history)-1]
+	session := model.StartChat()
+	session.History = history[:len(history)-1]
+	return client, session, last.Parts, nil
+}
+
+func candidateText(resp *genai.GenerateContentResponse) string {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		text.WriteString(fmt.Sprintf("%v", part))
+	}
+	return text.String()
+}
+
+func (g *Gemini) response(text string, usage *genai.UsageMetadata) *Response {
+	result := &Response{
+		Text:     text,
+		Provider: g.Name(),
+		Model:    g.model,
+	}
+	if usage != nil {
+		result.Usage = Usage{
+			PromptTokens:     int(usage.PromptTokenCount),
+			CompletionTokens: int(usage.CandidatesTokenCount),
+			TotalTokens:      int(usage.TotalTokenCount),
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+func init() {
+	Register("huggingface", func() (LLM, error) {
+		return NewHuggingFace(HuggingFaceConfigFromEnv())
+	})
+}
+
+const (
+	huggingFaceBaseURL      = "https://api-inference.huggingface.co/v1"
+	defaultHuggingFaceModel = "meta-llama/Llama-3.2-3B-Instruct"
+)
+
+// HuggingFaceConfigFromEnv läser HUGGINGFACE_API_KEY och HUGGINGFACE_MODEL_ID.
+// Hugging Face Inference API talar samma chat completions-format som OpenAI.
+func HuggingFaceConfigFromEnv() OpenAIConfig {
+	cfg := OpenAIConfig{
+		Name:    "huggingface",
+		BaseURL: os.Getenv("HUGGINGFACE_BASE_URL"),
+		APIKey:  os.Getenv("HUGGINGFACE_API_KEY"),
+		Model:   os.Getenv("HUGGINGFACE_MODEL_ID"),
+		Timeout: 30 * time.Second,
+		Stream:  true,
+		// Hugging Face stöder inte response_format för alla modeller
+		JSONMode: false,
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = huggingFaceBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultHuggingFaceModel
+	}
+	return cfg
+}
+
+// NewHuggingFace skapar en Hugging Face-leverantör
+func NewHuggingFace(cfg OpenAIConfig) (*OpenAI, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("HUGGINGFACE_API_KEY är inte satt")
+	}
+	return NewOpenAI(cfg)
+}
@@ -0,0 +1,84 @@
+// Package llm är det gemensamma gränssnittet mot språkmodellerna. Alla
+// AI-funktioner (CV, personligt brev, sökanalys, matchning) går via LLM så
+// att leverantören kan väljas med AI_PROVIDER utan att handlers ändras.
+package llm
+
+import "context"
+
+// Role anger vem ett meddelande kommer från
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message är ett meddelande i en chatt
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// System skapar ett systemmeddelande
+func System(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+// User skapar ett användarmeddelande
+func User(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// Request är ett anrop mot en modell. Nollvärden för Temperature och
+// MaxTokens betyder leverantörens standardvärde.
+type Request struct {
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+
+	// JSON ber modellen svara med ett JSON-objekt, med leverantörens
+	// JSON-läge om det finns
+	JSON bool
+
+	// Schema beskriver svaret när JSON är satt. Leverantörer med stöd för
+	// strukturerade svar skickar det vidare. Sätts av GenerateJSON.
+	Schema Schema
+
+	// Feature är den funktion som gör anropet, t.ex. "cv" eller
+	// "search_analysis". Används för loggning och mätvärden.
+	Feature string
+
+	// PromptVersion är promptens etikett från prompts-registret, t.ex.
+	// "cv@v1/sv", så att mätvärden kan jämföras mellan promptversioner
+	PromptVersion string
+
+	// CacheKey är anroparens normaliserade indata, t.ex. sökfrågan. Svar på
+	// anrop med CacheKey sparas i ResponseCache; tomt betyder ingen cache.
+	CacheKey string
+}
+
+// Usage är tokenförbrukningen för ett anrop, så som leverantören rapporterar den
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// Response är modellens svar
+type Response struct {
+	Text     string
+	Provider string
+	Model    string
+	Usage    Usage
+
+	// Cached är satt när svaret kom från ResponseCache
+	Cached bool
+}
+
+// LLM är en språkmodell hos någon leverantör
+type LLM interface {
+	// Name är leverantörens namn i registret, t.ex. "gemini"
+	Name() string
+	Generate(ctx context.Context, req Request) (*Response, error)
+}
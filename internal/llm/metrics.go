@@ -0,0 +1,24 @@
+package llm
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics för failover-kedjan
+var (
+	providerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_provider_requests_total",
+		Help: "Antal AI-anrop per leverantör, funktion och promptversion, med resultat success, error eller skipped",
+	}, []string{"provider", "feature", "prompt_version", "result"})
+	providerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llm_provider_request_duration_seconds",
+		Help:    "Svarstid för AI-anrop per leverantör",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider"})
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "llm_provider_breaker_state",
+		Help: "Kretsbrytarens tillstånd per leverantör (0 = stängd, 1 = halvöppen, 2 = öppen)",
+	}, []string{"provider"})
+)
+
+func init() {
+	prometheus.MustRegister(providerRequests, providerDuration, breakerState)
+}
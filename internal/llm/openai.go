@@ -0,0 +1,370 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	Register("openai", func() (LLM, error) {
+		return NewOpenAI(OpenAIConfigFromEnv())
+	})
+	Register("local", func() (LLM, error) {
+		return NewOpenAI(LocalConfigFromEnv())
+	})
+}
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIConfig innehåller inställningarna för en OpenAI-kompatibel endpoint,
+// t.ex. OpenAI, Hugging Face, Ollama eller llama.cpp-servern
+type OpenAIConfig struct {
+	// Name är leverantörens namn i loggar och mätvärden
+	Name    string
+	BaseURL string
+	// APIKey skickas som Bearer-token om den är satt. Lokala servrar
+	// brukar inte kräva någon nyckel.
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+
+	// Stream läser svaret som en SSE-ström i stället för ett helt JSON-svar
+	Stream bool
+	// JSONMode skickar response_format när anropet vill ha JSON. Stängs av
+	// för servrar som avvisar fältet.
+	JSONMode bool
+	// JSONSchema skickar anropets schema som response_format json_schema
+	// i stället för json_object
+	JSONSchema bool
+}
+
+// OpenAIConfigFromEnv läser OPENAI_BASE_URL, OPENAI_API_KEY, OPENAI_MODEL
+// och OPENAI_STREAM
+func OpenAIConfigFromEnv() OpenAIConfig {
+	cfg := OpenAIConfig{
+		Name:       "openai",
+		BaseURL:    os.Getenv("OPENAI_BASE_URL"),
+		APIKey:     os.Getenv("OPENAI_API_KEY"),
+		Model:      os.Getenv("OPENAI_MODEL"),
+		Timeout:    60 * time.Second,
+		Stream:     envBool("OPENAI_STREAM", false),
+		JSONMode:   true,
+		JSONSchema: envBool("OPENAI_JSON_SCHEMA", true),
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = openAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = "gpt-4o-mini"
+	}
+	return cfg
+}
+
+// LocalConfigFromEnv läser inställningarna för en egen server som Ollama
+// (standard, http://localhost:11434/v1) eller llama.cpp
+// (http://localhost:8080/v1) från LOCAL_LLM_*
+func LocalConfigFromEnv() OpenAIConfig {
+	cfg := OpenAIConfig{
+		Name:       "local",
+		BaseURL:    os.Getenv("LOCAL_LLM_BASE_URL"),
+		APIKey:     os.Getenv("LOCAL_LLM_API_KEY"),
+		Model:      os.Getenv("LOCAL_LLM_MODEL"),
+		Timeout:    5 * time.Minute,
+		Stream:     envBool("LOCAL_LLM_STREAM", true),
+		JSONMode:   envBool("LOCAL_LLM_JSON_MODE", true),
+		JSONSchema: envBool("LOCAL_LLM_JSON_SCHEMA", false),
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:11434/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "llama3.2"
+	}
+	if val := os.Getenv("LOCAL_LLM_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.Timeout = d
+		}
+	}
+	return cfg
+}
+
+func envBool(key string, def bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// OpenAI anropar /chat/completions hos en OpenAI-kompatibel tjänst
+type OpenAI struct {
+	cfg        OpenAIConfig
+	httpClient *http.Client
+}
+
+// NewOpenAI skapar en leverantör för en OpenAI-kompatibel endpoint. Nyckel
+// krävs bara mot OpenAI:s eget API.
+func NewOpenAI(cfg OpenAIConfig) (*OpenAI, error) {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Name == "" {
+		cfg.Name = "openai"
+	}
+	if cfg.APIKey == "" && cfg.BaseURL == openAIBaseURL {
+		return nil, fmt.Errorf("OPENAI_API_KEY är inte satt")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("ingen modell angiven för %s", cfg.Name)
+	}
+	return &OpenAI{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (o *OpenAI) Name() string { return o.cfg.Name }
+
+func (o *OpenAI) Model() string { return o.cfg.Model }
+
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// streamOptions ber servern skicka förbrukningen i en sista chunk, annars
+// saknas den helt i strömmade svar
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type responseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *jsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type jsonSchemaFormat struct {
+	Name   string `json:"name"`
+	Schema Schema `json:"schema"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *chatUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+// chatStreamChunk är en rad i SSE-strömmen
+type chatStreamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+func (o *OpenAI) newChatRequest(req Request, stream bool) chatCompletionRequest {
+	body := chatCompletionRequest{
+		Model:     o.cfg.Model,
+		Messages:  req.Messages,
+		MaxTokens: req.MaxTokens,
+		Stream:    stream,
+	}
+	if stream {
+		body.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if req.Temperature > 0 {
+		t := req.Temperature
+		body.Temperature = &t
+	}
+	if req.JSON && o.cfg.JSONMode {
+		body.ResponseFormat = &responseFormat{Type: "json_object"}
+		if o.cfg.JSONSchema && req.Schema != nil {
+			name := req.Feature
+			if name == "" {
+				name = "response"
+			}
+			body.ResponseFormat = &responseFormat{
+				Type:       "json_schema",
+				JSONSchema: &jsonSchemaFormat{Name: name, Schema: req.Schema},
+			}
+		}
+	}
+	return body
+}
+
+func (o *OpenAI) Generate(ctx context.Context, req Request) (*Response, error) {
+	return o.generate(ctx, req, o.cfg.Stream, nil)
+}
+
+// GenerateStream strömmar alltid svaret, oavsett Stream i konfigurationen
+func (o *OpenAI) GenerateStream(ctx context.Context, req Request, onToken func(string)) (*Response, error) {
+	return o.generate(ctx, req, true, onToken)
+}
+
+func (o *OpenAI) generate(ctx context.Context, req Request, stream bool, onToken func(string)) (*Response, error) {
+	jsonData, err := json.Marshal(o.newChatRequest(req, stream))
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte skapa request body: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte skapa request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+
+	log.Printf("🔍 Skickar förfrågan till %s (%s, %s)", o.cfg.Name, o.cfg.Model, req.Feature)
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s-anrop misslyckades: %v", o.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s returnerade status %d: %s", o.cfg.Name, resp.StatusCode, string(body))
+	}
+
+	if stream {
+		return o.readStream(resp.Body, req, onToken)
+	}
+
+	var result chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("kunde inte avkoda svar: %v", err)
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("tomt svar från %s", o.cfg.Name)
+	}
+
+	return &Response{
+		Text:     result.Choices[0].Message.Content,
+		Provider: o.Name(),
+		Model:    o.model(result.Model),
+		Usage:    result.Usage.toUsage(),
+	}, nil
+}
+
+// readStream sätter ihop texten från en SSE-ström med "data: "-rader och
+// skickar varje bit till onToken om den är satt
+func (o *OpenAI) readStream(body io.Reader, req Request, onToken func(string)) (*Response, error) {
+	var fullResponse strings.Builder
+	var usage Usage
+	var model string
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			log.Printf("⚠️ Kunde inte parsa stream response: %v", err)
+			continue
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			fullResponse.WriteString(chunk.Choices[0].Delta.Content)
+			if onToken != nil {
+				onToken(chunk.Choices[0].Delta.Content)
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toUsage()
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fel vid läsning av stream: %v", err)
+	}
+	if fullResponse.Len() == 0 {
+		return nil, fmt.Errorf("tomt svar från %s", o.cfg.Name)
+	}
+	// Servrar som ignorerar stream_options skickar ingen förbrukning. Utan
+	// uppskattning skulle anropet inte räknas mot tokenkvoterna alls.
+	if usage.TotalTokens == 0 {
+		usage = estimateUsage(req, fullResponse.String())
+		log.Printf("⚠️ %s skickade ingen förbrukning, uppskattar %d tokens", o.cfg.Name, usage.TotalTokens)
+	}
+
+	return &Response{
+		Text:     fullResponse.String(),
+		Provider: o.Name(),
+		Model:    o.model(model),
+		Usage:    usage,
+	}, nil
+}
+
+// model föredrar modellnamnet servern rapporterar
+func (o *OpenAI) model(reported string) string {
+	if reported != "" {
+		return reported
+	}
+	return o.cfg.Model
+}
+
+// estimateUsage uppskattar förbrukningen till ungefär fyra tecken per token
+func estimateUsage(req Request, text string) Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += estimateTokens(m.Content)
+	}
+	completion := estimateTokens(text)
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
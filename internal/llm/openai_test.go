@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/usage"
+)
+
+// streamServer svarar med en SSE-ström och skickar förbrukningen bara om
+// withUsage är satt, som servrar som ignorerar stream_options
+func streamServer(t *testing.T, withUsage bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream        bool `json:"stream"`
+			StreamOptions *struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("kunde inte avkoda anropet: %v", err)
+		}
+		if !body.Stream || body.StreamOptions == nil || !body.StreamOptions.IncludeUsage {
+			t.Errorf("strömmat anrop saknar stream_options.include_usage")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Hej ", "från ", "servern"} {
+			fmt.Fprintf(w, "data: {\"model\":\"test-modell\",\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		if withUsage {
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15}}\n\n")
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIStreamRecordsUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		withUsage  bool
+		prompt     int
+		completion int
+	}{
+		{"förbrukning från servern", true, 12, 3},
+		{"uppskattad förbrukning", false, 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemoryLedger(t)
+			server := streamServer(t, tt.withUsage)
+			provider, err := NewOpenAI(OpenAIConfig{
+				Name:    "lokal",
+				BaseURL: server.URL,
+				Model:   "test-modell",
+				Timeout: time.Second,
+				Stream:  true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain := NewChain(ChainConfig{BreakerThreshold: 2, BreakerCooldown: time.Minute, Timeout: time.Second}, provider)
+
+			start := time.Now()
+			resp, err := chain.Generate(context.Background(), Request{
+				Messages: []Message{User("Säg hej, tack")},
+				Feature:  "test",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "Hej från servern" {
+				t.Errorf("Text = %q", resp.Text)
+			}
+
+			records := usage.Default().Records(start, time.Now().Add(time.Second))
+			if len(records) != 1 {
+				t.Fatalf("förväntade en post i loggen, fick %d", len(records))
+			}
+			record := records[0]
+			if record.PromptTokens != tt.prompt || record.CompletionTokens != tt.completion {
+				t.Errorf("tokens = %d/%d, förväntade %d/%d", record.PromptTokens, record.CompletionTokens, tt.prompt, tt.completion)
+			}
+			if record.Tokens() == 0 {
+				t.Error("strömmat anrop debiterades inte")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultProvider används om AI_PROVIDER inte är satt
+const DefaultProvider = "huggingface"
+
+// Factory skapar en leverantör utifrån miljövariablerna
+type Factory func() (LLM, error)
+
+var (
+	registryMu sync.RWMutex
+	factories  = make(map[string]Factory)
+
+	// aliases gör att äldre och alternativa namn på AI_PROVIDER fungerar
+	aliases = map[string]string{
+		"google":    "gemini",
+		"hf":        "huggingface",
+		"ollama":    "local",
+		"llamacpp":  "local",
+		"llama.cpp": "local",
+	}
+
+	defaultOnce sync.Once
+	defaultLLM  LLM
+)
+
+// Register gör en leverantör valbar via AI_PROVIDER
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	factories[name] = factory
+}
+
+// Providers returnerar namnen på alla registrerade leverantörer
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New skapar leverantören med det angivna namnet
+func New(name string) (LLM, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	registryMu.RLock()
+	factory, ok := factories[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("okänd AI-leverantör '%s' (tillgängliga: %s)", name, strings.Join(Providers(), ", "))
+	}
+	return factory()
+}
+
+// Default returnerar kedjan av leverantörer från AI_PROVIDER_CHAIN, eller
+// bara AI_PROVIDER om ingen kedja är satt. Om ingen leverantör går att
+// skapa returneras en leverantör som svarar med felet, så att servern kan
+// starta och felet syns vid första anropet.
+func Default() LLM {
+	defaultOnce.Do(func() {
+		cfg := ChainConfigFromEnv()
+
+		chain, err := NewChainFromConfig(cfg)
+		if err != nil {
+			log.Printf("❌ Kunde inte skapa AI-leverantör: %v", err)
+			defaultLLM = unavailable{name: strings.Join(cfg.Providers, ","), err: err}
+			return
+		}
+		log.Printf("🤖 AI-leverantörer i ordning: %s (timeout %s, brytare efter %d fel)",
+			chain.Name(), cfg.Timeout, cfg.BreakerThreshold)
+		defaultLLM = chain
+	})
+	return defaultLLM
+}
+
+// SetDefault ersätter leverantören som Default returnerar, t.ex. med en
+// Fake i tester
+func SetDefault(provider LLM) {
+	defaultOnce.Do(func() {})
+	defaultLLM = provider
+}
+
+// unavailable är en leverantör som inte gick att skapa
+type unavailable struct {
+	name string
+	err  error
+}
+
+func (u unavailable) Name() string { return u.name }
+
+func (u unavailable) Generate(ctx context.Context, req Request) (*Response, error) {
+	return nil, fmt.Errorf("AI-leverantören %s är inte tillgänglig: %v", u.name, u.err)
+}
@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema är ett JSON Schema. Det byggs från Go-typen som svaret ska
+// avkodas till, så att typen är den enda sanningen om formatet.
+type Schema map[string]interface{}
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// SchemaFor bygger ett JSON Schema från en Go-typ. Fälten namnges efter
+// json-taggarna. Fält utan omitempty är obligatoriska, pekare får vara null.
+// Fält med taggen schema:"-" fylls i av oss och visas inte för modellen.
+func SchemaFor(v interface{}) Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return Schema{}
+	}
+	return schemaForType(t, make(map[reflect.Type]bool))
+}
+
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	if t == jsonNumberType {
+		return Schema{"type": "number"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := schemaForType(t.Elem(), seen)
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return Schema{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := Schema{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Tag.Get("schema") == "-" {
+				continue
+			}
+			name, omitempty, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+			properties[name] = schemaForType(field.Type, seen)
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+		return Schema{"type": "object", "properties": properties, "required": required}
+	default:
+		return Schema{}
+	}
+}
+
+// jsonFieldName läser json-taggen på samma sätt som encoding/json
+func jsonFieldName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// Validate kontrollerar ett avkodat JSON-värde mot schemat och returnerar
+// ett fel per avvikelse, med sökväg, t.ex. "utbildning[0].examen: saknas"
+func (s Schema) Validate(value interface{}) []string {
+	var errs []string
+	validateValue(s, value, "", &errs)
+	return errs
+}
+
+func validateValue(s Schema, value interface{}, path string, errs *[]string) {
+	label := path
+	if label == "" {
+		label = "svaret"
+	}
+
+	types := schemaTypes(s)
+	if len(types) == 0 {
+		return
+	}
+	actual := jsonType(value)
+	if !typeAllowed(types, actual) {
+		*errs = append(*errs, fmt.Sprintf("%s: ska vara %s men är %s", label, strings.Join(types, " eller "), actual))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := s["required"].([]string); ok {
+			for _, name := range required {
+				if _, exists := v[name]; !exists {
+					*errs = append(*errs, fmt.Sprintf("%s: saknas", joinPath(path, name)))
+				}
+			}
+		}
+		if properties, ok := s["properties"].(Schema); ok {
+			names := make([]string, 0, len(v))
+			for name := range v {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if prop, ok := properties[name].(Schema); ok {
+					validateValue(prop, v[name], joinPath(path, name), errs)
+				}
+			}
+		}
+		if additional, ok := s["additionalProperties"].(Schema); ok {
+			for name, item := range v {
+				validateValue(additional, item, joinPath(path, name), errs)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(Schema); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func schemaTypes(s Schema) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func typeAllowed(types []string, actual string) bool {
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returnerar JSON Schema-typen för ett värde från encoding/json
+// avkodat med UseNumber
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,26 @@
+package llm
+
+import "context"
+
+// Streamer är en leverantör som kan skicka svaret bit för bit medan det
+// genereras. onToken anropas med varje ny textbit i ordning.
+type Streamer interface {
+	GenerateStream(ctx context.Context, req Request, onToken func(string)) (*Response, error)
+}
+
+// Stream genererar ett svar och skickar texten till onToken. Leverantörer
+// som inte kan strömma skickar hela svaret som en enda bit.
+func Stream(ctx context.Context, model LLM, req Request, onToken func(string)) (*Response, error) {
+	if streamer, ok := model.(Streamer); ok {
+		return streamer.GenerateStream(ctx, req, onToken)
+	}
+
+	resp, err := model.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Text != "" {
+		onToken(resp.Text)
+	}
+	return resp, nil
+}
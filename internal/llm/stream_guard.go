@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"awesomeProject/internal/guardrails"
+)
+
+const (
+	// streamHoldback är hur många byte av den strömmade texten som hålls
+	// kvar tills texten efter dem har granskats. Det räcker för att skript,
+	// läckta instruktioner och blockerade ord ska synas i sin helhet innan
+	// någon del av dem når klienten.
+	streamHoldback = 256
+	// streamScanStep är hur mycket ny text som samlas innan den granskas, så
+	// att ScanOutput inte körs för varje enskild textbit
+	streamScanStep = 128
+)
+
+// streamGuard står mellan modellen och StreamCallbacks.Token och släpper
+// bara vidare text som har granskats med guardrails.ScanOutput. Hittas ett
+// problem avbryts försöket och inget mer skickas vidare.
+type streamGuard struct {
+	forward      func(string)
+	instructions []string
+	cancel       context.CancelFunc
+
+	text     strings.Builder
+	scanned  int
+	sent     int
+	problems []string
+}
+
+func newStreamGuard(forward func(string), instructions []string, cancel context.CancelFunc) *streamGuard {
+	return &streamGuard{forward: forward, instructions: instructions, cancel: cancel}
+}
+
+// token tar emot nästa textbit från modellen
+func (g *streamGuard) token(chunk string) {
+	if g.problems != nil {
+		return
+	}
+	g.text.WriteString(chunk)
+	text := g.text.String()
+	if len(text)-g.scanned < streamScanStep {
+		return
+	}
+
+	// Granska bara fram till sista ordgränsen, ett halvt ord kan annars
+	// likna ett blockerat ord
+	cut := strings.LastIndexFunc(text, notWordRune)
+	if cut <= g.scanned {
+		return
+	}
+
+	// Text som redan skickats har granskats, men ett mönster kan börja i
+	// den och sluta i den nya texten
+	from := 0
+	if g.sent > streamHoldback {
+		from = g.sent - streamHoldback
+		if i := strings.IndexFunc(text[from:], notWordRune); i > 0 {
+			from += i
+		}
+	}
+	if problems := guardrails.ScanOutput(text[from:cut], g.instructions); len(problems) > 0 {
+		g.problems = problems
+		g.cancel()
+		return
+	}
+	g.scanned = cut
+	g.release(text, cut-streamHoldback)
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// flush skickar resten av texten när hela svaret har godkänts
+func (g *streamGuard) flush() {
+	text := g.text.String()
+	g.release(text, len(text))
+}
+
+// release skickar texten fram till end, justerat till början av ett tecken
+func (g *streamGuard) release(text string, end int) {
+	for end > g.sent && end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	if end <= g.sent {
+		return
+	}
+	g.forward(text[g.sent:end])
+	g.sent = end
+}
@@ -0,0 +1,351 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"awesomeProject/internal/guardrails"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var structuredResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "llm_structured_output_total",
+	Help: "Antal strukturerade AI-svar per funktion, promptversion och utfall (valid, repaired, reasked, cached, invalid, blocked)",
+}, []string{"feature", "prompt_version", "result"})
+
+func init() {
+	prometheus.MustRegister(structuredResults)
+}
+
+// DefaultStructuredAttempts är hur många gånger modellen får försöka ge ett
+// giltigt svar om AI_STRUCTURED_MAX_ATTEMPTS inte är satt
+const DefaultStructuredAttempts = 3
+
+// ValidationError returneras när modellen inte gav giltig JSON på något försök
+type ValidationError struct {
+	Errors   []string
+	Response string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("AI-svaret följer inte formatet: %s", strings.Join(e.Errors, "; "))
+}
+
+// GenerateJSON ber modellen om ett svar som avkodas till target. Schemat
+// byggs från targets typ och skickas med anropet så att leverantörer med
+// inbyggt stöd kan använda det; övriga får det i prompten. Svaret lagas
+// om modellen gjort vanliga misstag, valideras och om det ändå är ogiltigt
+// skickas felen tillbaka till modellen som ett nytt försök. Ett giltigt svar
+// granskas också med guardrails.ScanOutput och skickas tillbaka på samma
+// sätt om det innehåller skript eller återger instruktionerna.
+func GenerateJSON(ctx context.Context, model LLM, req Request, target interface{}) (*Response, error) {
+	return generateJSON(ctx, model, req, target, nil)
+}
+
+// StreamCallbacks tar emot texten från GenerateJSONStream medan den skrivs
+type StreamCallbacks struct {
+	// Token anropas med modellens text i bitar. Texten granskas med
+	// guardrails.ScanOutput innan den skickas, så den kommer något efter
+	// modellen och resten av ett svar som stoppas skickas aldrig.
+	Token func(string)
+	// Retry anropas när ett svar var ogiltigt och modellen får försöka igen.
+	// Texten som strömmats hittills ska då kastas.
+	Retry func(attempt int, errors []string)
+}
+
+// GenerateJSONStream fungerar som GenerateJSON men strömmar modellens text
+// till callbacks under tiden. Det validerade resultatet finns i target när
+// funktionen returnerar.
+func GenerateJSONStream(ctx context.Context, model LLM, req Request, target interface{}, callbacks StreamCallbacks) (*Response, error) {
+	return generateJSON(ctx, model, req, target, &callbacks)
+}
+
+func generateJSON(ctx context.Context, model LLM, req Request, target interface{}, callbacks *StreamCallbacks) (*Response, error) {
+	schema := SchemaFor(target)
+	schemaJSON, _ := json.Marshal(schema)
+	instructions := systemTexts(req.Messages)
+
+	req.JSON = true
+	req.Schema = schema
+	req.Messages = append([]Message{System("Svara endast med ett JSON-värde som följer detta JSON Schema, utan förklaringar eller kodblock:\n" + string(schemaJSON))},
+		req.Messages...)
+
+	feature := req.Feature
+	if feature == "" {
+		feature = "unknown"
+	}
+
+	// Ett cachat svar har redan validerats, men schemat kan ha ändrats sedan dess
+	if resp, ok := DefaultCache().get(ctx, model, req); ok {
+		if errs := checkOutput(schema, resp.Text, target, instructions); len(errs) == 0 {
+			if callbacks != nil && callbacks.Token != nil {
+				callbacks.Token(resp.Text)
+			}
+			structuredResults.WithLabelValues(feature, req.PromptVersion, "cached").Inc()
+			return resp, nil
+		}
+	}
+
+	attempts := structuredAttempts()
+	var lastErr *ValidationError
+	blocked := false
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var resp *Response
+		var err error
+		var guard *streamGuard
+		if callbacks != nil && callbacks.Token != nil {
+			// Texten granskas innan den strömmas vidare, ett avbrutet försök
+			// behandlas som ett blockerat svar
+			attemptCtx, cancel := context.WithCancel(ctx)
+			guard = newStreamGuard(callbacks.Token, instructions, cancel)
+			resp, err = Stream(attemptCtx, model, req, guard.token)
+			cancel()
+			if guard.problems != nil && ctx.Err() == nil {
+				resp, err = &Response{Text: guard.text.String()}, nil
+			}
+		} else {
+			resp, err = model.Generate(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []string
+		repaired := RepairJSON(resp.Text)
+		blocked = guard != nil && guard.problems != nil
+		if blocked {
+			errs = guard.problems
+		} else if errs = decodeValid(schema, repaired, target); len(errs) == 0 {
+			errs = guardrails.ScanOutput(repaired, instructions)
+			blocked = len(errs) > 0
+		}
+		if len(errs) == 0 {
+			if guard != nil {
+				guard.flush()
+			}
+			result := "valid"
+			switch {
+			case attempt > 1:
+				result = "reasked"
+			case repaired != strings.TrimSpace(resp.Text):
+				result = "repaired"
+			}
+			structuredResults.WithLabelValues(feature, req.PromptVersion, result).Inc()
+			resp.Text = repaired
+			DefaultCache().set(ctx, model, req, resp)
+			return resp, nil
+		}
+
+		lastErr = &ValidationError{Errors: errs, Response: resp.Text}
+		log.Printf("⚠️ Ogiltigt AI-svar för %s (försök %d av %d): %s", feature, attempt, attempts, strings.Join(errs, "; "))
+		if attempt < attempts && callbacks != nil && callbacks.Retry != nil {
+			callbacks.Retry(attempt+1, errs)
+		}
+
+		// Låt modellen se sitt eget svar och vad som var fel
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: resp.Text},
+			User("Svaret var inte giltigt:\n- "+strings.Join(errs, "\n- ")+"\nSvara igen med endast korrigerad JSON enligt schemat."),
+		)
+	}
+
+	result := "invalid"
+	if blocked {
+		result = "blocked"
+	}
+	structuredResults.WithLabelValues(feature, req.PromptVersion, result).Inc()
+	return nil, lastErr
+}
+
+func structuredAttempts() int {
+	if val := os.Getenv("AI_STRUCTURED_MAX_ATTEMPTS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultStructuredAttempts
+}
+
+// checkOutput validerar text mot schemat och granskar innehållet
+func checkOutput(schema Schema, text string, target interface{}, instructions []string) []string {
+	if errs := decodeValid(schema, text, target); len(errs) > 0 {
+		return errs
+	}
+	return guardrails.ScanOutput(text, instructions)
+}
+
+// systemTexts är innehållet i anropets systemmeddelanden
+func systemTexts(messages []Message) []string {
+	var texts []string
+	for _, m := range messages {
+		if m.Role == RoleSystem {
+			texts = append(texts, m.Content)
+		}
+	}
+	return texts
+}
+
+// decodeValid validerar text mot schemat och avkodar den till target
+func decodeValid(schema Schema, text string, target interface{}) []string {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("ogiltig JSON: %v", err)}
+	}
+	if errs := schema.Validate(value); len(errs) > 0 {
+		return errs
+	}
+	if err := json.Unmarshal([]byte(text), target); err != nil {
+		return []string{fmt.Sprintf("kunde inte avkoda svaret: %v", err)}
+	}
+	return nil
+}
+
+var (
+	codeFencePattern     = regexp.MustCompile("(?s)```(?:json|JSON)?\\s*(.*?)```")
+	trailingCommaPattern = regexp.MustCompile(`,(\s*[}\]])`)
+)
+
+// RepairJSON lagar vanliga misstag i modellsvar: kodblock, text runt
+// JSON-värdet, enkla citattecken och avslutande kommatecken
+func RepairJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if m := codeFencePattern.FindStringSubmatch(text); m != nil {
+		text = strings.TrimSpace(m[1])
+	}
+	text = extractValue(text)
+	text = replaceSingleQuotes(text)
+	text = removeTrailingCommas(text)
+	return text
+}
+
+// extractValue plockar ut det första hela JSON-objektet eller -arrayen
+func extractValue(text string) string {
+	start := strings.IndexAny(text, "{[")
+	if start == -1 {
+		return text
+	}
+
+	depth := 0
+	var quote byte
+	escaped := false
+	for i := start; i < len(text); i++ {
+		ch := text[i]
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return text[start : i+1]
+			}
+		}
+	}
+	// Avklippt svar: returnera resten och låt valideringen rapportera felet
+	return text[start:]
+}
+
+// replaceSingleQuotes gör om 'sträng' till "sträng" utanför strängar med
+// dubbla citattecken. Apostrofer inuti ord lämnas ifred.
+func replaceSingleQuotes(text string) string {
+	if !strings.Contains(text, "'") {
+		return text
+	}
+
+	var b strings.Builder
+	inDouble, inSingle, escaped := false, false, false
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			escaped = false
+			if inSingle && ch == '\'' {
+				// \' behöver inget escape inom dubbla citattecken
+				s := b.String()
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+			}
+		case ch == '\\' && (inDouble || inSingle):
+			escaped = true
+		case inDouble:
+			if ch == '"' {
+				inDouble = false
+			}
+		case inSingle:
+			if ch == '\'' && !isWordByte(text, i+1) {
+				inSingle = false
+				ch = '"'
+			} else if ch == '"' {
+				b.WriteString(`\"`)
+				continue
+			}
+		case ch == '"':
+			inDouble = true
+		case ch == '\'':
+			inSingle = true
+			ch = '"'
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+func isWordByte(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	ch := text[i]
+	return ch == '_' || ch >= 0x80 || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// removeTrailingCommas tar bort kommatecken före } och ] utanför strängar
+func removeTrailingCommas(text string) string {
+	var b strings.Builder
+	inString, escaped := false, false
+	start := 0
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\' && inString:
+			escaped = true
+		case ch == '"':
+			if !inString {
+				b.WriteString(trailingCommaPattern.ReplaceAllString(text[start:i], "$1"))
+				start = i
+			} else {
+				b.WriteString(text[start : i+1])
+				start = i + 1
+			}
+			inString = !inString
+		}
+	}
+	if inString {
+		b.WriteString(text[start:])
+	} else {
+		b.WriteString(trailingCommaPattern.ReplaceAllString(text[start:], "$1"))
+	}
+	return b.String()
+}
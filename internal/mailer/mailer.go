@@ -0,0 +1,271 @@
+// Package mailer skickar e-post via en SMTP-relä, t.ex. för jobbevakningar.
+// Lokalt kan man peka SMTP_HOST/SMTP_PORT mot MailHog eller liknande.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config innehåller SMTP-inställningarna
+type Config struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string
+	MaxRetries int
+	RetryDelay time.Duration
+	// Timeout är hur lång tid ett försök får ta om ctx saknar deadline
+	Timeout time.Duration
+}
+
+// ConfigFromEnv läser SMTP-inställningarna från miljövariabler
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Host:       os.Getenv("SMTP_HOST"),
+		Port:       587,
+		Username:   os.Getenv("SMTP_USERNAME"),
+		Password:   os.Getenv("SMTP_PASSWORD"),
+		From:       os.Getenv("SMTP_FROM"),
+		MaxRetries: 3,
+		RetryDelay: 5 * time.Second,
+		Timeout:    30 * time.Second,
+	}
+
+	if val := os.Getenv("SMTP_PORT"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.Port = n
+		}
+	}
+	if val := os.Getenv("SMTP_MAX_RETRIES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.MaxRetries = n
+		}
+	}
+	if val := os.Getenv("SMTP_RETRY_DELAY"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.RetryDelay = d
+		}
+	}
+	if val := os.Getenv("SMTP_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.Timeout = d
+		}
+	}
+	if cfg.From == "" {
+		cfg.From = "no-reply@smidra.com"
+	}
+
+	return cfg
+}
+
+// Message är ett mejl med HTML- och textversion
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string
+}
+
+// Sender är det som behövs för att skicka ett mejl
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Mailer skickar mejl via SMTP med omförsök
+type Mailer struct {
+	cfg Config
+}
+
+// New skapar en mailer. Returnerar nil om SMTP_HOST inte är satt.
+func New(cfg Config) *Mailer {
+	if cfg.Host == "" {
+		return nil
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Mailer{cfg: cfg}
+}
+
+// Send skickar ett mejl och försöker igen vid fel enligt konfigurationen
+func (m *Mailer) Send(ctx context.Context, msg Message) error {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return fmt.Errorf("ogiltig mottagare %q: %v", msg.To, err)
+	}
+
+	body, err := m.build(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	// Utan användarnamn skickar vi utan autentisering, t.ex. mot MailHog
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	var lastErr error
+	for i := 0; i < m.cfg.MaxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.cfg.RetryDelay):
+			}
+		}
+
+		if lastErr = m.sendMail(ctx, addr, auth, msg.To, body); lastErr == nil {
+			log.Printf("📧 Skickade mejl till %s: %s", msg.To, msg.Subject)
+			return nil
+		}
+		log.Printf("⚠️ Försök %d/%d att skicka mejl till %s misslyckades: %v", i+1, m.cfg.MaxRetries, msg.To, lastErr)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("kunde inte skicka mejl efter %d försök: %v", m.cfg.MaxRetries, lastErr)
+}
+
+// sendMail gör samma sak som smtp.SendMail men avbryts när ctx avbryts,
+// när dess deadline passeras eller efter cfg.Timeout, så att en SMTP-server som slutar svara
+// inte låser schemaläggaren
+func (m *Mailer) sendMail(ctx context.Context, addr string, auth smtp.Auth, to string, body []byte) error {
+	deadline := time.Now().Add(m.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP-servern stöder inte AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// build bygger ett multipart/alternative-meddelande med text och HTML
+func (m *Mailer) build(msg Message) ([]byte, error) {
+	boundary := randomBoundary()
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		// Radbrytningar i headers skulle kunna användas för header injection
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	writeHeader("From", m.cfg.From)
+	writeHeader("To", msg.To)
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+	for key, value := range msg.Headers {
+		writeHeader(key, value)
+	}
+	writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, part.content)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 skriver innehållet base64-kodat med högst 76 tecken per rad
+func writeBase64(buf *bytes.Buffer, content string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+}
+
+func randomBoundary() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("boundary-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/mailer/mailertest"
+)
+
+func newTestMailer(server *mailertest.Server, retries int) *Mailer {
+	return New(Config{
+		Host:       server.Host,
+		Port:       server.Port,
+		From:       "no-reply@example.se",
+		MaxRetries: retries,
+		RetryDelay: time.Millisecond,
+	})
+}
+
+func TestSend(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	err := newTestMailer(server, 1).Send(context.Background(), Message{
+		To:      "anna@example.se",
+		Subject: "3 nya jobb för Göteborg",
+		HTML:    "<p>Hej Anna</p>",
+		Text:    "Hej Anna",
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<https://example.se/avregistrera>",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click\r\nBcc: angripare@example.se",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mails := server.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("antal mejl = %d, vill ha 1", len(mails))
+	}
+	if mails[0].From != "no-reply@example.se" || len(mails[0].To) != 1 || mails[0].To[0] != "anna@example.se" {
+		t.Errorf("kuvert = %s -> %v", mails[0].From, mails[0].To)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(mails[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "3 nya jobb för Göteborg" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<https://example.se/avregistrera>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); !strings.HasPrefix(got, "List-Unsubscribe=One-Click") {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+	if got := msg.Header.Get("Bcc"); got != "" {
+		t.Errorf("radbrytning i en header gav en ny header: Bcc = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		parts[part.Header.Get("Content-Type")] = string(content)
+	}
+	if parts["text/plain; charset=utf-8"] != "Hej Anna" || parts["text/html; charset=utf-8"] != "<p>Hej Anna</p>" {
+		t.Errorf("delar = %q", parts)
+	}
+}
+
+func TestSendRetry(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+	msg := Message{To: "anna@example.se", Subject: "Test", Text: "Hej"}
+
+	server.FailNext(2)
+	if err := newTestMailer(server, 3).Send(context.Background(), msg); err != nil {
+		t.Fatalf("tredje försöket borde ha lyckats: %v", err)
+	}
+	if n := len(server.Mails()); n != 1 {
+		t.Errorf("antal mejl = %d, vill ha 1", n)
+	}
+
+	server.FailNext(2)
+	err := newTestMailer(server, 2).Send(context.Background(), msg)
+	if err == nil || !strings.Contains(err.Error(), "efter 2 försök") {
+		t.Errorf("fel = %v, vill ha misslyckande efter 2 försök", err)
+	}
+}
+
+// En server som tar emot anslutningen men aldrig svarar får inte låsa Send
+func TestSendRespectsContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m := New(Config{Host: addr.IP.String(), Port: addr.Port, MaxRetries: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := m.Send(ctx, Message{To: "anna@example.se", Text: "Hej"}); err == nil {
+		t.Fatal("Send lyckades mot en server som inte svarar")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send tog %s trots att ctx gick ut efter 100ms", elapsed)
+	}
+}
+
+func TestSendInvalidRecipient(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	if err := newTestMailer(server, 1).Send(context.Background(), Message{To: "inte en adress"}); err == nil {
+		t.Error("ogiltig mottagare accepterades")
+	}
+	if n := len(server.Mails()); n != 0 {
+		t.Errorf("antal mejl = %d, vill ha 0", n)
+	}
+}
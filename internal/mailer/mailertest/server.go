@@ -0,0 +1,152 @@
+// Package mailertest har en minimal SMTP-server för tester, på samma sätt
+// som httptest har en HTTP-server. Den tar emot mejl utan TLS och
+// autentisering och sparar dem i minnet.
+package mailertest
+
+import (
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mail är ett mejl som servern har tagit emot
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Server är en SMTP-server som lyssnar på en slumpad port på 127.0.0.1
+type Server struct {
+	Host string
+	Port int
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	mails    []Mail
+	failures int
+}
+
+// NewServer startar en server. Anropa Close när testet är klart.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("mailertest: kunde inte lyssna: " + err.Error())
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	s := &Server{Host: addr.IP.String(), Port: addr.Port, listener: listener}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close stänger servern och väntar på pågående anslutningar
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Mails returnerar de mejl som tagits emot hittills
+func (s *Server) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+// FailNext gör att de n nästa mejlen avvisas med ett tillfälligt fel
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// handle talar tillräckligt mycket SMTP för net/smtp
+func (s *Server) handle(conn *textproto.Conn) {
+	reply := func(code int, text string) {
+		conn.PrintfLine("%d %s", code, text)
+	}
+
+	reply(220, "mailertest ESMTP")
+	var mail Mail
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply(250, "mailertest")
+		case "MAIL":
+			mail = Mail{From: address(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			mail.To = append(mail.To, address(arg))
+			reply(250, "OK")
+		case "DATA":
+			reply(354, "Skicka innehållet, avsluta med <CRLF>.<CRLF>")
+			lines, err := conn.ReadDotLines()
+			if err != nil {
+				return
+			}
+			mail.Data = strings.Join(lines, "\r\n")
+			if s.accept(mail) {
+				reply(250, "OK")
+			} else {
+				reply(451, "Tillfälligt fel, försök igen")
+			}
+		case "RSET":
+			mail = Mail{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Hej då")
+			return
+		default:
+			reply(502, "Kommandot stöds inte: "+strconv.Quote(verb))
+		}
+	}
+}
+
+// accept sparar mejlet om servern inte ska avvisa det
+func (s *Server) accept(mail Mail) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return false
+	}
+	s.mails = append(s.mails, mail)
+	return true
+}
+
+// address plockar ut adressen ur "FROM:<a@b.se>" och "TO:<a@b.se>"
+func address(arg string) string {
+	if _, after, ok := strings.Cut(arg, ":"); ok {
+		arg = after
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.Trim(arg, "<>")
+}
@@ -0,0 +1,169 @@
+package matching
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+var (
+	yearPattern    = regexp.MustCompile(`(19|20)\d{2}`)
+	ongoingPattern = regexp.MustCompile(`(?i)(nu|pågående|idag|present|current|now)\s*$`)
+
+	// requiredYearsPattern hittar t.ex. "minst 3 års erfarenhet" eller "5+ years of experience"
+	requiredYearsPattern = regexp.MustCompile(`(?i)(\d{1,2})\s*\+?\s*(?:års|år|years?)[^.]{0,40}?(?:erfarenhet|experience)`)
+)
+
+func explainSkills(matched, missing []string, required int) string {
+	text := fmt.Sprintf("Matchar %d av %d efterfrågade kompetenser", len(matched), required)
+	if len(matched) > 0 {
+		text += " (" + strings.Join(matched, ", ") + ")"
+	}
+	if len(missing) > 0 {
+		text += ", saknar " + strings.Join(missing, ", ")
+	}
+	return text
+}
+
+// bestTitleMatch returnerar den av kandidatens titlar som mest liknar annonsens titel
+func bestTitleMatch(jobTitle string, titles []string) (string, float64) {
+	var best string
+	var bestScore float64
+	for _, title := range titles {
+		if score := trigramSimilarity(jobTitle, title); score > bestScore || best == "" {
+			best, bestScore = title, score
+		}
+	}
+	return best, bestScore
+}
+
+// trigramSimilarity jämför två korta texter med Dice-koefficienten på
+// teckentrigram. Det fungerar bättre än hela ord för svenska sammansättningar
+// som "backendutvecklare" och "go-utvecklare".
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ta)+len(tb))
+}
+
+func trigrams(text string) map[string]bool {
+	result := make(map[string]bool)
+	for _, token := range terms(text) {
+		runes := []rune(" " + token + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			result[string(runes[i:i+3])] = true
+		}
+	}
+	return result
+}
+
+// locationScore jämför kandidatens ort med arbetsplatsens kommun och län.
+// ok är false om någon av orterna saknas.
+func locationScore(location string, job *platsbanken.JobDetail) (float64, string, bool) {
+	if strings.TrimSpace(location) == "" {
+		return 0, "", false
+	}
+
+	candidate := newPhraseIndex(location)
+	for _, place := range []string{job.Field("workplace", "municipality"), job.Field("workplace", "city")} {
+		if place != "" && candidate.contains(place) {
+			return 1, "Jobbet ligger i " + place + ", samma ort som du", true
+		}
+	}
+
+	region := job.Field("workplace", "region")
+	if region != "" && candidate.contains(region) {
+		return 0.6, "Jobbet ligger i " + region + ", samma län som du", true
+	}
+
+	place := job.Field("workplace", "municipality")
+	if place == "" {
+		place = region
+	}
+	if place == "" {
+		return 0, "", false
+	}
+	return 0, "Jobbet ligger i " + place, true
+}
+
+// experienceScore jämför kandidatens år i arbetslivet med annonsens krav
+func experienceScore(years float64, job *platsbanken.JobDetail) (float64, string) {
+	if !job.RequiresExperience {
+		return 1, "Jobbet kräver ingen tidigare erfarenhet"
+	}
+
+	required := requiredYears(job.Description)
+	if years <= 0 {
+		return 0, "Jobbet kräver erfarenhet, men CV:t anger ingen"
+	}
+	if required == 0 {
+		return 1, fmt.Sprintf("Jobbet kräver erfarenhet, du har cirka %s", formatYears(years))
+	}
+
+	score := math.Min(1, years/float64(required))
+	return score, fmt.Sprintf("Jobbet kräver minst %d års erfarenhet, du har cirka %s", required, formatYears(years))
+}
+
+// requiredYears letar efter ett antal år i annonsens erfarenhetskrav, 0 om inget anges
+func requiredYears(description string) int {
+	match := requiredYearsPattern.FindStringSubmatch(cleanText(description))
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// experienceYears summerar perioderna i arbetslivserfarenheten, t.ex.
+// "2018 - 2021" eller "2020 - nu". Perioder utan årtal räknas inte.
+func experienceYears(periods []string) float64 {
+	currentYear := time.Now().Year()
+
+	var total float64
+	for _, period := range periods {
+		years := yearPattern.FindAllString(period, -1)
+		if len(years) == 0 {
+			continue
+		}
+
+		start, _ := strconv.Atoi(years[0])
+		end := start
+		if len(years) > 1 {
+			end, _ = strconv.Atoi(years[len(years)-1])
+		} else if ongoingPattern.MatchString(period) {
+			end = currentYear
+		}
+
+		if end < start {
+			continue
+		}
+		// Ett år som både start och slut räknas som ett halvår
+		total += math.Max(0.5, float64(end-start))
+	}
+	return total
+}
+
+func formatYears(years float64) string {
+	if years < 1 {
+		return "mindre än ett år"
+	}
+	rounded := int(math.Round(years))
+	if rounded == 1 {
+		return "1 år"
+	}
+	return strconv.Itoa(rounded) + " år"
+}
@@ -0,0 +1,129 @@
+package matching
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+func testJob(t *testing.T, raw string) *platsbanken.JobDetail {
+	t.Helper()
+	var job platsbanken.JobDetail
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		t.Fatal(err)
+	}
+	return &job
+}
+
+func TestExplainSkills(t *testing.T) {
+	tests := []struct {
+		matched, missing []string
+		want             string
+	}{
+		{[]string{"Go", "Docker"}, nil, "Matchar 2 av 2 efterfrågade kompetenser (Go, Docker)"},
+		{[]string{"Go"}, []string{"Rust", "AWS"}, "Matchar 1 av 3 efterfrågade kompetenser (Go), saknar Rust, AWS"},
+		{nil, []string{"React"}, "Matchar 0 av 1 efterfrågade kompetenser, saknar React"},
+	}
+	for _, tt := range tests {
+		if got := explainSkills(tt.matched, tt.missing, len(tt.matched)+len(tt.missing)); got != tt.want {
+			t.Errorf("explainSkills = %q, vill ha %q", got, tt.want)
+		}
+	}
+}
+
+func TestLocationScore(t *testing.T) {
+	job := testJob(t, `{"workplace": {"municipality": "Mölndal", "region": "Västra Götalands län"}}`)
+	tests := []struct {
+		location  string
+		wantScore float64
+		wantText  string
+		wantOK    bool
+	}{
+		{"Mölndal", 1, "Jobbet ligger i Mölndal, samma ort som du", true},
+		{"Storgatan 1, 431 30 Mölndal", 1, "Jobbet ligger i Mölndal, samma ort som du", true},
+		{"Västra Götalands län", 0.6, "Jobbet ligger i Västra Götalands län, samma län som du", true},
+		{"Malmö", 0, "Jobbet ligger i Mölndal", true},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		score, text, ok := locationScore(tt.location, job)
+		if score != tt.wantScore || text != tt.wantText || ok != tt.wantOK {
+			t.Errorf("locationScore(%q) = %v, %q, %v", tt.location, score, text, ok)
+		}
+	}
+
+	if _, _, ok := locationScore("Malmö", testJob(t, `{}`)); ok {
+		t.Error("ort utan arbetsplats i annonsen borde hoppas över")
+	}
+}
+
+func TestExperienceScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		years     float64
+		job       string
+		wantScore float64
+		wantText  string
+	}{
+		{"inget krav", 0, `{"requiresExperience": false}`, 1, "Jobbet kräver ingen tidigare erfarenhet"},
+		{"krav utan år", 4, `{"requiresExperience": true, "description": "Erfarenhet av Go"}`, 1, "Jobbet kräver erfarenhet, du har cirka 4 år"},
+		{"uppfyller kravet", 6, `{"requiresExperience": true, "description": "Minst 3 års erfarenhet av Go"}`, 1, "Jobbet kräver minst 3 års erfarenhet, du har cirka 6 år"},
+		{"halva kravet", 2.5, `{"requiresExperience": true, "description": "5+ years of professional experience"}`, 0.5, "Jobbet kräver minst 5 års erfarenhet, du har cirka 3 år"},
+		{"ingen erfarenhet", 0, `{"requiresExperience": true, "description": "2 års erfarenhet"}`, 0, "Jobbet kräver erfarenhet, men CV:t anger ingen"},
+		{"mindre än ett år", 0.5, `{"requiresExperience": true}`, 1, "Jobbet kräver erfarenhet, du har cirka mindre än ett år"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, text := experienceScore(tt.years, testJob(t, tt.job))
+			if score != tt.wantScore || text != tt.wantText {
+				t.Errorf("experienceScore = %v, %q, vill ha %v, %q", score, text, tt.wantScore, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestExperienceYears(t *testing.T) {
+	ongoing := float64(time.Now().Year() - 2020)
+	tests := []struct {
+		periods []string
+		want    float64
+	}{
+		{[]string{"2018 - 2021"}, 3},
+		{[]string{"2018 - 2021", "jan 2021 – dec 2023"}, 5},
+		{[]string{"2020 - nu"}, ongoing},
+		{[]string{"2020 – pågående"}, ongoing},
+		{[]string{"2022"}, 0.5},
+		{[]string{"sommarjobb", "2021 - 2019"}, 0},
+	}
+	for _, tt := range tests {
+		if got := experienceYears(tt.periods); got != tt.want {
+			t.Errorf("experienceYears(%q) = %v, vill ha %v", tt.periods, got, tt.want)
+		}
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Backendutvecklare", "backendutvecklare", 1, 1},
+		{"Backendutvecklare Go", "Backendutvecklare", 0.9, 1},
+		{"Systemutvecklare", "Backendutvecklare", 0.5, 0.8},
+		{"Lagerarbetare", "Backendutvecklare", 0, 0.3},
+		{"", "Backendutvecklare", 0, 0},
+	}
+	for _, tt := range tests {
+		got := trigramSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("trigramSimilarity(%q, %q) = %.2f, vill ha %v–%v", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+
+	title, _ := bestTitleMatch("Senior Go-utvecklare", []string{"Lärare", "Go-utvecklare", "Utvecklare"})
+	if title != "Go-utvecklare" {
+		t.Errorf("bestTitleMatch = %q", title)
+	}
+}
@@ -0,0 +1,223 @@
+// Package matching jämför ett CV med jobbannonser och räknar ut hur väl de
+// passar ihop. Poängen är deterministisk: samma CV och annons ger alltid
+// samma resultat, AI-omrankning är ett valfritt steg ovanpå.
+package matching
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/platsbanken"
+)
+
+// Delpoängen som vägs ihop till totalpoängen
+const (
+	ComponentSkills     = "skills"
+	ComponentText       = "text"
+	ComponentTitle      = "title"
+	ComponentLocation   = "location"
+	ComponentExperience = "experience"
+)
+
+// componentWeights är vikterna för delpoängen. Delpoäng som inte går att
+// räkna ut (t.ex. ort när CV:t saknar adress) hoppas över och de övriga
+// vikterna skalas upp.
+var componentWeights = map[string]float64{
+	ComponentSkills:     0.4,
+	ComponentText:       0.2,
+	ComponentTitle:      0.2,
+	ComponentLocation:   0.1,
+	ComponentExperience: 0.1,
+}
+
+// textSimilarityScale skalar upp cosinuslikheten, eftersom ett CV och en
+// annons sällan får mer än ~0.5 även när de passar bra ihop
+const textSimilarityScale = 2.0
+
+// Candidate är ett CV förberett för matchning
+type Candidate struct {
+	skills          []string
+	skillSet        map[string]bool
+	text            string
+	index           phraseIndex
+	titles          []string
+	location        string
+	experienceYears float64
+}
+
+// NewCandidate plockar ut färdigheter, titlar, ort och erfarenhet ur ett CV
+func NewCandidate(cv *data.CVData) *Candidate {
+	c := &Candidate{skillSet: make(map[string]bool)}
+
+	addSkill := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" || c.skillSet[strings.ToLower(name)] {
+			return
+		}
+		c.skillSet[strings.ToLower(name)] = true
+		c.skills = append(c.skills, name)
+	}
+	for _, s := range cv.Fardigheter {
+		addSkill(s)
+	}
+	for _, s := range cv.Sprak {
+		addSkill(s.Sprak)
+	}
+
+	addTitle := func(title string) {
+		if strings.TrimSpace(title) != "" {
+			c.titles = append(c.titles, title)
+		}
+	}
+	addTitle(cv.PersonligInfo.Titel)
+
+	parts := []string{cv.PersonligInfo.Titel, cv.Profil}
+	parts = append(parts, cv.Fardigheter...)
+	var periods []string
+	for _, exp := range cv.Arbetslivserfarenhet {
+		parts = append(parts, exp.Titel)
+		parts = append(parts, exp.Beskrivning...)
+		addTitle(exp.Titel)
+		periods = append(periods, exp.Period)
+	}
+	for _, edu := range cv.Utbildning {
+		parts = append(parts, edu.Examen)
+		parts = append(parts, edu.Beskrivning...)
+	}
+	parts = append(parts, cv.Projekt...)
+	parts = append(parts, cv.Certifieringar...)
+
+	for _, k := range cv.PersonligInfo.Kontakt {
+		switch strings.ToLower(k.Typ) {
+		case "adress", "ort", "plats", "location":
+			c.location = k.Varde
+		}
+	}
+
+	c.text = strings.Join(parts, "\n")
+	c.index = newPhraseIndex(c.text)
+	c.experienceYears = experienceYears(periods)
+	return c
+}
+
+// PreferLocation används när CV:t saknar ort, t.ex. med kommunen från sökningen
+func (c *Candidate) PreferLocation(location string) {
+	if c.location == "" {
+		c.location = location
+	}
+}
+
+// Empty anger om CV:t saknar innehåll att matcha mot
+func (c *Candidate) Empty() bool {
+	return len(c.skills) == 0 && strings.TrimSpace(c.text) == ""
+}
+
+// Result är matchningen mellan CV:t och en annons
+type Result struct {
+	JobID          string         `json:"jobId"`
+	Title          string         `json:"title"`
+	Employer       string         `json:"employer,omitempty"`
+	Score          int            `json:"score"`
+	Components     map[string]int `json:"components"`
+	Explanation    []string       `json:"explanation"`
+	RequiredSkills []string       `json:"requiredSkills"`
+	MatchedSkills  []string       `json:"matchedSkills"`
+	MissingSkills  []string       `json:"missingSkills"`
+	AIScore        *int           `json:"aiScore,omitempty"`
+	AIReason       string         `json:"aiReason,omitempty"`
+}
+
+// Score matchar kandidaten mot annonserna och returnerar resultaten
+// sorterade med bäst matchning först
+func Score(c *Candidate, jobs []*platsbanken.JobDetail) []Result {
+	docs := make([][]string, 0, len(jobs)+1)
+	docs = append(docs, terms(c.text))
+	for _, job := range jobs {
+		docs = append(docs, terms(job.Title+"\n"+job.Description))
+	}
+	vectors := tfidfVectors(docs)
+
+	results := make([]Result, 0, len(jobs))
+	for i, job := range jobs {
+		ad := newPhraseIndex(job.Title + "\n" + job.Description)
+		scores := make(map[string]float64)
+		var explanation []string
+
+		required := requiredSkills(ad, c.skills)
+		matched := []string{}
+		missing := []string{}
+		for _, name := range required {
+			if hasSkill(name, c.skillSet, c.index) {
+				matched = append(matched, name)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+		if len(required) > 0 {
+			scores[ComponentSkills] = float64(len(matched)) / float64(len(required))
+			explanation = append(explanation, explainSkills(matched, missing, len(required)))
+		}
+
+		scores[ComponentText] = math.Min(1, cosine(vectors[0], vectors[i+1])*textSimilarityScale)
+
+		if title, similarity := bestTitleMatch(job.Title, c.titles); title != "" {
+			scores[ComponentTitle] = similarity
+			if similarity >= 0.5 {
+				explanation = append(explanation, "Titeln liknar din erfarenhet som "+title)
+			}
+		}
+
+		if score, text, ok := locationScore(c.location, job); ok {
+			scores[ComponentLocation] = score
+			explanation = append(explanation, text)
+		}
+
+		score, text := experienceScore(c.experienceYears, job)
+		scores[ComponentExperience] = score
+		explanation = append(explanation, text)
+
+		components := make(map[string]int, len(scores))
+		for name, s := range scores {
+			components[name] = percent(s)
+		}
+
+		results = append(results, Result{
+			JobID:          job.ID,
+			Title:          job.Title,
+			Employer:       job.Company.Name,
+			Score:          percent(weightedScore(scores)),
+			Components:     components,
+			Explanation:    explanation,
+			RequiredSkills: append([]string{}, required...),
+			MatchedSkills:  matched,
+			MissingSkills:  missing,
+		})
+	}
+
+	SortByScore(results)
+	return results
+}
+
+// weightedScore väger ihop de delpoäng som gick att räkna ut
+func weightedScore(scores map[string]float64) float64 {
+	var total, weights float64
+	for name, score := range scores {
+		total += componentWeights[name] * score
+		weights += componentWeights[name]
+	}
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// SortByScore sorterar bäst först, lika poäng behåller ursprunglig ordning
+func SortByScore(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+}
+
+func percent(f float64) int {
+	return int(math.Round(math.Max(0, math.Min(1, f)) * 100))
+}
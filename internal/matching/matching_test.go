@@ -0,0 +1,121 @@
+package matching
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/platsbanken"
+)
+
+// Kör go test ./internal/matching -update för att skriva om golden-filerna
+// efter en avsiktlig ändring av poängsättningen
+var update = flag.Bool("update", false, "skriv om golden-filerna i testdata")
+
+func loadJSON(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// golden jämför got med testdata/name, eller skriver filen med -update
+func golden(t *testing.T, name string, got interface{}) {
+	t.Helper()
+	b, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = append(b, '\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (kör med -update för att skapa filen)", err)
+	}
+	if string(b) != string(want) {
+		t.Errorf("resultatet skiljer sig från %s:\n%s", path, b)
+	}
+}
+
+func testCandidateAndJobs(t *testing.T) (*Candidate, []*platsbanken.JobDetail) {
+	t.Helper()
+	var cv data.CVData
+	loadJSON(t, "cv.json", &cv)
+	var jobs []*platsbanken.JobDetail
+	loadJSON(t, "jobs.json", &jobs)
+	return NewCandidate(&cv), jobs
+}
+
+func TestScoreGolden(t *testing.T) {
+	candidate, jobs := testCandidateAndJobs(t)
+	results := Score(candidate, jobs)
+
+	var order []string
+	for _, r := range results {
+		order = append(order, r.JobID)
+	}
+	want := []string{"1001", "1003", "1002", "1004"}
+	if len(order) != len(want) {
+		t.Fatalf("ordning = %v, vill ha %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ordning = %v, vill ha %v", order, want)
+		}
+	}
+
+	golden(t, "score.golden.json", results)
+}
+
+// Samma indata ska alltid ge samma resultat, oavsett map-ordning
+func TestScoreDeterministic(t *testing.T) {
+	candidate, jobs := testCandidateAndJobs(t)
+	first, _ := json.Marshal(Score(candidate, jobs))
+	for i := 0; i < 20; i++ {
+		again, _ := json.Marshal(Score(candidate, jobs))
+		if string(again) != string(first) {
+			t.Fatalf("körning %d gav ett annat resultat:\n%s\n%s", i, again, first)
+		}
+	}
+}
+
+func TestTFIDF(t *testing.T) {
+	vectors := tfidfVectors([][]string{
+		{"go", "kubernetes", "go"},
+		{"go", "kubernetes"},
+		{"react", "typescript"},
+		{},
+	})
+
+	if got := cosine(vectors[0], vectors[0]); got < 0.999 || got > 1.001 {
+		t.Errorf("likhet med sig själv = %f, vill ha 1", got)
+	}
+	if got := cosine(vectors[0], vectors[2]); got != 0 {
+		t.Errorf("likhet utan gemensamma termer = %f, vill ha 0", got)
+	}
+	if got := cosine(vectors[0], vectors[3]); got != 0 {
+		t.Errorf("likhet med tomt dokument = %f, vill ha 0", got)
+	}
+	// Termen som förekommer två gånger väger tyngre
+	if vectors[0]["go"] <= vectors[0]["kubernetes"] {
+		t.Errorf("vikter = %v", vectors[0])
+	}
+	// Termer som bara finns i ett dokument har högre IDF
+	if vectors[2]["react"] <= vectors[1]["go"] {
+		t.Errorf("react = %f, go = %f", vectors[2]["react"], vectors[1]["go"])
+	}
+}
@@ -0,0 +1,178 @@
+package matching
+
+import "strings"
+
+// skill är en kompetens med de varianter den kan skrivas på i en annons
+type skill struct {
+	name    string
+	aliases []string
+}
+
+// aliasOnlySkills är namn som också är vanliga ord, för dem letar vi bara efter varianterna
+var aliasOnlySkills = toSet("Go")
+
+// skillVocabulary är de kompetenser vi letar efter i annonstexter. Listan
+// behöver inte vara komplett eftersom kandidatens egna färdigheter också
+// söks i annonsen.
+var skillVocabulary = []skill{
+	// Programmeringsspråk
+	{"Go", []string{"golang", "go-utvecklare", "go developer"}},
+	{"Java", nil},
+	{"JavaScript", []string{"js"}},
+	{"TypeScript", nil},
+	{"Python", nil},
+	{"C#", []string{"csharp"}},
+	{"C++", []string{"cpp"}},
+	{"Rust", nil},
+	{"Kotlin", nil},
+	{"Swift", nil},
+	{"PHP", nil},
+	{"Ruby", nil},
+	{"Scala", nil},
+	{"SQL", nil},
+	{"HTML", []string{"html5"}},
+	{"CSS", []string{"css3"}},
+	{"Bash", []string{"shell"}},
+	{"PowerShell", nil},
+
+	// Ramverk och plattformar
+	{".NET", []string{"dotnet", "asp.net"}},
+	{"React", []string{"react.js", "reactjs"}},
+	{"Angular", nil},
+	{"Vue", []string{"vue.js", "vuejs"}},
+	{"Node.js", []string{"nodejs", "node"}},
+	{"Spring", []string{"spring boot"}},
+	{"Django", nil},
+	{"Flask", nil},
+	{"Android", nil},
+	{"iOS", nil},
+	{"Linux", nil},
+
+	// Moln, drift och verktyg
+	{"AWS", []string{"amazon web services"}},
+	{"Azure", nil},
+	{"GCP", []string{"google cloud"}},
+	{"Docker", nil},
+	{"Kubernetes", []string{"k8s"}},
+	{"Terraform", nil},
+	{"CI/CD", []string{"ci cd", "continuous integration"}},
+	{"Git", []string{"github", "gitlab"}},
+	{"Jenkins", nil},
+	{"PostgreSQL", []string{"postgres"}},
+	{"MySQL", nil},
+	{"MongoDB", nil},
+	{"Redis", nil},
+	{"Kafka", nil},
+	{"REST", []string{"rest api", "restful"}},
+	{"GraphQL", nil},
+	{"Microservices", []string{"mikrotjänster"}},
+
+	// Data och analys
+	{"Machine learning", []string{"maskininlärning", "ml"}},
+	{"AI", []string{"artificiell intelligens"}},
+	{"Power BI", []string{"powerbi"}},
+	{"Excel", nil},
+	{"Tableau", nil},
+	{"Dataanalys", []string{"data analysis", "dataanalytiker"}},
+
+	// Arbetssätt och roller
+	{"Agile", []string{"agil", "agilt", "agila"}},
+	{"Scrum", nil},
+	{"Projektledning", []string{"project management", "projektledare"}},
+	{"Testning", []string{"testing", "testautomation"}},
+	{"UX", []string{"user experience"}},
+	{"Figma", nil},
+
+	// Allmänna kompetenser som ofta efterfrågas i Platsbanken
+	{"Kundservice", []string{"customer service", "kundtjänst"}},
+	{"Försäljning", []string{"sales", "säljare"}},
+	{"Ekonomi", []string{"redovisning", "bokföring", "accounting"}},
+	{"SAP", nil},
+	{"Körkort", []string{"b-körkort", "driving license"}},
+	{"Truckkort", []string{"truckkörkort"}},
+	{"Svenska", []string{"swedish"}},
+	{"Engelska", []string{"english"}},
+	{"Undersköterska", []string{"usk"}},
+	{"Sjuksköterska", []string{"legitimerad sjuksköterska"}},
+	{"Lager", []string{"logistik", "warehouse"}},
+	{"Svetsning", []string{"svetsare"}},
+	{"CAD", []string{"autocad"}},
+}
+
+// requiredSkills plockar ut de kompetenser som annonsen nämner. Kandidatens
+// egna färdigheter räknas också, även om de saknas i vokabulären.
+func requiredSkills(ad phraseIndex, candidateSkills []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, name)
+		}
+	}
+
+	for _, s := range skillVocabulary {
+		if !aliasOnlySkills[s.name] && ad.contains(s.name) {
+			add(s.name)
+			continue
+		}
+		for _, alias := range s.aliases {
+			if ad.contains(alias) {
+				add(s.name)
+				break
+			}
+		}
+	}
+
+	for _, name := range candidateSkills {
+		// Färdigheter som finns i vokabulären har redan sökts ovan
+		if canonicalSkill(name) != "" {
+			continue
+		}
+		if ad.contains(name) {
+			add(name)
+		}
+	}
+
+	return result
+}
+
+// canonicalSkill returnerar vokabulärens namn för en färdighet, eller "" om den saknas där
+func canonicalSkill(name string) string {
+	normalized := strings.Join(tokenize(name), " ")
+	for _, s := range skillVocabulary {
+		if strings.Join(tokenize(s.name), " ") == normalized {
+			return s.name
+		}
+		for _, alias := range s.aliases {
+			if strings.Join(tokenize(alias), " ") == normalized {
+				return s.name
+			}
+		}
+	}
+	return ""
+}
+
+// hasSkill anger om kandidaten har färdigheten, antingen i listan över
+// färdigheter eller någonstans i CV-texten
+func hasSkill(name string, skills map[string]bool, cv phraseIndex) bool {
+	if skills[strings.ToLower(name)] {
+		return true
+	}
+	if cv.contains(name) {
+		return true
+	}
+	for _, s := range skillVocabulary {
+		if s.name != name {
+			continue
+		}
+		for _, alias := range s.aliases {
+			if skills[strings.ToLower(alias)] || cv.contains(alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package matching
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequiredSkills(t *testing.T) {
+	tests := []struct {
+		name            string
+		ad              string
+		candidateSkills []string
+		want            []string
+	}{
+		{"vokabulär i vokabulärens ordning", "Vi använder Kubernetes, Go och Python", nil, []string{"Python", "Kubernetes"}},
+		{"Go räknas bara via varianter", "Go-utvecklare till vårt team, erfarenhet av golang", nil, []string{"Go"}},
+		{"ordet go räknas inte", "Let's go! Vi söker en säljare", nil, []string{"Försäljning"}},
+		{"varianter ger vokabulärens namn", "Erfarenhet av k8s, postgres och reactjs", nil, []string{"React", "Kubernetes", "PostgreSQL"}},
+		{"specialtecken", "C#, C++ och Node.js samt .NET", nil, []string{"C#", "C++", ".NET", "Node.js"}},
+		{"HTML i annonsen", "<li>Java</li><li>Spring Boot</li>", nil, []string{"Java", "Spring"}},
+		{"hela ord", "Javaprogrammerare med Rusty-erfarenhet", nil, nil},
+		{"kandidatens egna färdigheter", "Vi använder gRPC och Protobuf", []string{"gRPC", "Protobuf", "Elixir"}, []string{"gRPC", "Protobuf"}},
+		{"inga dubbletter", "Docker, docker och DOCKER", []string{"docker"}, []string{"Docker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requiredSkills(newPhraseIndex(tt.ad), tt.candidateSkills)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("requiredSkills = %v, vill ha %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalSkill(t *testing.T) {
+	tests := map[string]string{
+		"golang":        "Go",
+		"Go":            "Go",
+		"K8S":           "Kubernetes",
+		"spring boot":   "Spring",
+		"mikrotjänster": "Microservices",
+		"gRPC":          "",
+	}
+	for name, want := range tests {
+		if got := canonicalSkill(name); got != want {
+			t.Errorf("canonicalSkill(%q) = %q, vill ha %q", name, got, want)
+		}
+	}
+}
+
+func TestHasSkill(t *testing.T) {
+	skills := map[string]bool{"golang": true, "docker": true}
+	cv := newPhraseIndex("Byggde tjänster i Kubernetes. Maskininlärning i Python.")
+
+	tests := map[string]bool{
+		"Docker":           true,  // i listan
+		"Go":               true,  // variant i listan
+		"Kubernetes":       true,  // i CV-texten
+		"Machine learning": true,  // variant i CV-texten
+		"Java":             false, // saknas
+	}
+	for name, want := range tests {
+		if got := hasSkill(name, skills, cv); got != want {
+			t.Errorf("hasSkill(%q) = %v, vill ha %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package matching
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// stopWords är vanliga svenska och engelska ord som inte säger något om jobbet
+var stopWords = toSet(
+	// svenska
+	"och", "att", "det", "som", "en", "ett", "på", "är", "av", "för", "med", "till", "den", "har",
+	"de", "inte", "om", "vi", "du", "i", "kan", "eller", "vara", "din", "dig", "oss", "vår", "våra",
+	"ska", "sig", "så", "även", "samt", "hos", "från", "där", "när", "man", "under", "inom", "över",
+	"efter", "sin", "sina", "sitt", "vill", "vid", "alla", "andra", "mer", "mycket", "också", "ha",
+	"bli", "blir", "får", "dina", "ditt", "era", "ni", "er", "detta", "denna", "dessa", "hur", "vad",
+	// engelska
+	"the", "and", "to", "of", "a", "in", "for", "is", "on", "with", "as", "you", "we", "our", "are",
+	"be", "an", "or", "will", "your", "at", "by", "this", "that", "have", "from", "it", "us", "can",
+	"who", "all", "has", "their", "they", "not", "but", "more", "about", "within", "into", "also",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// cleanText tar bort HTML-taggar och entiteter som brukar finnas i annonstexter
+func cleanText(text string) string {
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	return strings.NewReplacer("&nbsp;", " ", "&amp;", "&", "&lt;", "<", "&gt;", ">").Replace(text)
+}
+
+// tokenize delar upp texten i gemena ord. Tecken som +, # och . behålls inne
+// i ord så att t.ex. "c++", "c#" och "node.js" blir hela tokens.
+func tokenize(text string) []string {
+	text = strings.ToLower(cleanText(text))
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+#.-", r))
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimRight(f, ".-")
+		f = strings.TrimLeft(f, "-")
+		if f == "" || f == "." {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+// terms returnerar tokens utan stoppord, för TF-IDF
+func terms(text string) []string {
+	var result []string
+	for _, t := range tokenize(text) {
+		if len([]rune(t)) < 2 || stopWords[t] {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// phraseIndex gör det snabbt att se om en fras förekommer i en text
+type phraseIndex string
+
+func newPhraseIndex(text string) phraseIndex {
+	return phraseIndex(" " + strings.Join(tokenize(text), " ") + " ")
+}
+
+// contains anger om frasen finns som hela ord i texten
+func (p phraseIndex) contains(phrase string) bool {
+	normalized := strings.Join(tokenize(phrase), " ")
+	if normalized == "" {
+		return false
+	}
+	return strings.Contains(string(p), " "+normalized+" ")
+}
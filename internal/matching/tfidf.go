@@ -0,0 +1,63 @@
+package matching
+
+import "math"
+
+// vector är en gles TF-IDF-vektor
+type vector map[string]float64
+
+// tfidfVectors bygger TF-IDF-vektorer för dokumenten. IDF räknas över just
+// de här dokumenten, med utjämning så att termer som finns överallt inte
+// väger noll.
+func tfidfVectors(docs [][]string) []vector {
+	df := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool)
+		for _, term := range doc {
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+
+	n := float64(len(docs))
+	vectors := make([]vector, len(docs))
+	for i, doc := range docs {
+		tf := make(map[string]int)
+		for _, term := range doc {
+			tf[term]++
+		}
+
+		v := make(vector, len(tf))
+		for term, count := range tf {
+			idf := math.Log((1+n)/(1+float64(df[term]))) + 1
+			v[term] = (1 + math.Log(float64(count))) * idf
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// cosine returnerar cosinuslikheten mellan två vektorer (0–1)
+func cosine(a, b vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (norm(a) * norm(b))
+}
+
+func norm(v vector) float64 {
+	var sum float64
+	for _, weight := range v {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,186 @@
+package platsbanken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics för jobbdetalj-cachen
+var (
+	jobCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "platsbanken_job_cache_hits_total",
+		Help: "Antal jobbdetaljer som hämtades från cachen",
+	})
+	jobCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "platsbanken_job_cache_misses_total",
+		Help: "Antal jobbdetaljer som saknades i cachen och hämtades från Platsbanken",
+	})
+	jobCacheRevalidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "platsbanken_job_cache_revalidations_total",
+		Help: "Antal villkorliga förnyelser av utgångna cachevärden",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(jobCacheHits, jobCacheMisses, jobCacheRevalidations)
+}
+
+// CacheConfig styr cachen framför GetJob
+type CacheConfig struct {
+	Backend string // "memory", "disk" eller "none"
+	TTL     time.Duration
+	// MaxStale är hur länge efter TTL ett utgånget värde får användas när
+	// Platsbanken inte svarar
+	MaxStale   time.Duration
+	MaxEntries int
+	Dir        string
+}
+
+// CacheConfigFromEnv läser cacheinställningar från miljövariabler
+func CacheConfigFromEnv() CacheConfig {
+	cfg := CacheConfig{
+		Backend:    strings.ToLower(os.Getenv("PLATSBANKEN_CACHE")),
+		TTL:        6 * time.Hour,
+		MaxStale:   24 * time.Hour,
+		MaxEntries: 5000,
+		Dir:        os.Getenv("PLATSBANKEN_CACHE_DIR"),
+	}
+
+	if cfg.Backend == "" {
+		cfg.Backend = "memory"
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "data/cache/jobs"
+	}
+	if val := os.Getenv("PLATSBANKEN_CACHE_TTL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.TTL = d
+		}
+	}
+	if val := os.Getenv("PLATSBANKEN_CACHE_MAX_STALE"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			cfg.MaxStale = d
+		}
+	}
+	if n := positiveIntEnv("PLATSBANKEN_CACHE_MAX_ENTRIES"); n > 0 {
+		cfg.MaxEntries = n
+	}
+
+	return cfg
+}
+
+// NewCacheStore skapar den cache-backend som konfigurationen anger.
+// Returnerar nil om cachen är avstängd.
+func NewCacheStore(cfg CacheConfig) (cache.Store, error) {
+	switch cfg.Backend {
+	case "none", "off":
+		log.Printf("🗄️ Jobbcache avstängd")
+		return nil, nil
+	case "disk":
+		log.Printf("🗄️ Jobbcache på disk: %s (max %d, TTL %s)", cfg.Dir, cfg.MaxEntries, cfg.TTL)
+		return cache.NewDiskStore(cfg.Dir, cfg.MaxEntries)
+	default:
+		log.Printf("🗄️ Jobbcache i minnet (max %d, TTL %s)", cfg.MaxEntries, cfg.TTL)
+		return cache.NewLRU(cfg.MaxEntries), nil
+	}
+}
+
+// WithCache kopplar en cache till klienten så att GetJob i första hand
+// svarar från cachen. Utgångna värden förnyas villkorligt med ETag/Last-Modified.
+// Om förnyelsen misslyckas används det gamla värdet i högst maxStale till.
+func (c *Client) WithCache(store cache.Store, ttl, maxStale time.Duration) *Client {
+	c.cache = store
+	c.cacheTTL = ttl
+	c.cacheMaxStale = maxStale
+	return c
+}
+
+// getJobCached är GetJob med cache framför
+func (c *Client) getJobCached(ctx context.Context, jobID string) (*JobDetail, error) {
+	entry, found := c.cache.Get(jobID)
+	if found {
+		if entry.Fresh(c.cacheTTL) {
+			if detail, err := decodeJobEntry(entry); err == nil {
+				jobCacheHits.Inc()
+				return detail, nil
+			}
+			c.cache.Delete(jobID)
+			found = false
+		}
+	}
+
+	if !found {
+		jobCacheMisses.Inc()
+		resp, err := c.fetchJob(ctx, jobID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		c.storeJob(jobID, resp)
+		return resp.detail, nil
+	}
+
+	// Värdet har gått ut, fråga Platsbanken om annonsen har ändrats
+	resp, err := c.fetchJob(ctx, jobID, entry.Meta["etag"], entry.Meta["last_modified"])
+	if errors.Is(err, ErrJobNotFound) {
+		// Annonsen är borttagen ur Platsbanken
+		jobCacheRevalidations.WithLabelValues("deleted").Inc()
+		c.cache.Delete(jobID)
+		return nil, err
+	}
+	if err != nil {
+		// Hellre ett lite gammalt svar än inget alls, men inte hur länge som helst
+		if entry.Fresh(c.cacheTTL + c.cacheMaxStale) {
+			if detail, decodeErr := decodeJobEntry(entry); decodeErr == nil {
+				log.Printf("⚠️ Kunde inte förnya jobb %s, använder cachat värde: %v", jobID, err)
+				jobCacheRevalidations.WithLabelValues("stale").Inc()
+				return detail, nil
+			}
+		}
+		jobCacheRevalidations.WithLabelValues("error").Inc()
+		c.cache.Delete(jobID)
+		return nil, err
+	}
+
+	if resp.notModified {
+		jobCacheRevalidations.WithLabelValues("not_modified").Inc()
+		entry.StoredAt = time.Now()
+		c.cache.Set(jobID, entry)
+		return decodeJobEntry(entry)
+	}
+
+	jobCacheRevalidations.WithLabelValues("modified").Inc()
+	c.storeJob(jobID, resp)
+	return resp.detail, nil
+}
+
+func (c *Client) storeJob(jobID string, resp *jobResponse) {
+	meta := map[string]string{}
+	if resp.etag != "" {
+		meta["etag"] = resp.etag
+	}
+	if resp.lastModified != "" {
+		meta["last_modified"] = resp.lastModified
+	}
+
+	c.cache.Set(jobID, cache.Entry{
+		Value:    resp.body,
+		StoredAt: time.Now(),
+		Meta:     meta,
+	})
+}
+
+func decodeJobEntry(entry cache.Entry) (*JobDetail, error) {
+	var detail JobDetail
+	if err := json.Unmarshal(entry.Value, &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
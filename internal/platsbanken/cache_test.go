@@ -0,0 +1,195 @@
+package platsbanken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/cache"
+)
+
+// jobServer är en falsk /job/{id}-endpoint. handle anropas för varje förfrågan.
+type jobServer struct {
+	*httptest.Server
+	calls  int32
+	handle func(w http.ResponseWriter, r *http.Request)
+}
+
+func newJobServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request)) *jobServer {
+	t.Helper()
+	s := &jobServer{handle: handle}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.calls, 1)
+		s.handle(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jobServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+// cachedClient skapar en klient mot servern med TTL 1h och MaxStale 1h
+func cachedClient(server *jobServer, store cache.Store) *Client {
+	client := NewClient(Config{JobDetailURL: server.URL + "/job/", MaxRetries: 3, RetryDelay: time.Millisecond})
+	return client.WithCache(store, time.Hour, time.Hour)
+}
+
+// staleEntry är ett cachat jobb som är age gammalt
+func staleEntry(title string, age time.Duration, meta map[string]string) cache.Entry {
+	return cache.Entry{
+		Value:    []byte(`{"id": "1", "title": "` + title + `"}`),
+		StoredAt: time.Now().Add(-age),
+		Meta:     meta,
+	}
+}
+
+func TestGetJobCached(t *testing.T) {
+	server := newJobServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id": "1", "title": "Utvecklare"}`))
+	})
+	store := cache.NewLRU(10)
+	client := cachedClient(server, store)
+
+	for i := 0; i < 2; i++ {
+		detail, err := client.GetJob(context.Background(), "1")
+		if err != nil || detail.Title != "Utvecklare" {
+			t.Fatalf("GetJob = %+v, %v", detail, err)
+		}
+	}
+	if server.Calls() != 1 {
+		t.Errorf("antal anrop = %d, andra GetJob ska svaras från cachen", server.Calls())
+	}
+	if entry, _ := store.Get("1"); entry.Meta["etag"] != `"v1"` {
+		t.Errorf("ETag sparades inte: %+v", entry.Meta)
+	}
+}
+
+func TestGetJobRevalidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		meta      map[string]string
+		handle    func(w http.ResponseWriter, r *http.Request)
+		wantTitle string
+	}{
+		{
+			name: "ETag oförändrad",
+			meta: map[string]string{"etag": `"v1"`},
+			handle: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte(`{"id": "1", "title": "Fel"}`))
+			},
+			wantTitle: "Cachad",
+		},
+		{
+			name: "Last-Modified oförändrad",
+			meta: map[string]string{"last_modified": "Mon, 02 Jan 2006 15:04:05 GMT"},
+			handle: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte(`{"id": "1", "title": "Fel"}`))
+			},
+			wantTitle: "Cachad",
+		},
+		{
+			name: "ändrad",
+			meta: map[string]string{"etag": `"v1"`},
+			handle: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v2"`)
+				w.Write([]byte(`{"id": "1", "title": "Ny titel"}`))
+			},
+			wantTitle: "Ny titel",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newJobServer(t, tt.handle)
+			store := cache.NewLRU(10)
+			store.Set("1", staleEntry("Cachad", 2*time.Hour, tt.meta))
+
+			detail, err := cachedClient(server, store).GetJob(context.Background(), "1")
+			if err != nil || detail.Title != tt.wantTitle {
+				t.Fatalf("GetJob = %+v, %v, vill ha %q", detail, err, tt.wantTitle)
+			}
+			entry, ok := store.Get("1")
+			if !ok || !entry.Fresh(time.Hour) {
+				t.Errorf("cachevärdet förnyades inte: %+v", entry)
+			}
+			if server.Calls() != 1 {
+				t.Errorf("antal anrop = %d, vill ha 1", server.Calls())
+			}
+		})
+	}
+}
+
+// En annons som tagits bort ur Platsbanken ska inte serveras från cachen
+func TestGetJobDeleted(t *testing.T) {
+	server := newJobServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	store := cache.NewLRU(10)
+	store.Set("1", staleEntry("Borttagen", 2*time.Hour, map[string]string{"etag": `"v1"`}))
+
+	_, err := cachedClient(server, store).GetJob(context.Background(), "1")
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("fel = %v, vill ha ErrJobNotFound", err)
+	}
+	if _, ok := store.Get("1"); ok {
+		t.Error("den borttagna annonsen finns kvar i cachen")
+	}
+	if server.Calls() != 1 {
+		t.Errorf("antal anrop = %d, 404 ska inte försökas igen", server.Calls())
+	}
+}
+
+func TestGetJobStale(t *testing.T) {
+	server := newJobServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nere", http.StatusServiceUnavailable)
+	})
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		wantErr bool
+	}{
+		{"inom MaxStale", 90 * time.Minute, false},
+		{"för gammal", 3 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache.NewLRU(10)
+			store.Set("1", staleEntry("Gammal", tt.age, nil))
+
+			detail, err := cachedClient(server, store).GetJob(context.Background(), "1")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "503") {
+					t.Fatalf("GetJob = %+v, %v, vill ha fel", detail, err)
+				}
+				if _, ok := store.Get("1"); ok {
+					t.Error("det för gamla värdet finns kvar i cachen")
+				}
+				return
+			}
+			if err != nil || detail.Title != "Gammal" {
+				t.Fatalf("GetJob = %+v, %v, vill ha det cachade värdet", detail, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package platsbanken
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"awesomeProject/internal/cache"
+)
+
+// Config innehåller inställningarna för Platsbanken-klienten
+type Config struct {
+	APIURL         string
+	JobDetailURL   string
+	APIKey         string
+	MaxRecords     int
+	DefaultMaxJobs int
+	MaxRetries     int
+	RetryDelay     time.Duration
+}
+
+// ConfigFromEnv läser konfigurationen från miljövariabler
+func ConfigFromEnv() Config {
+	cfg := Config{
+		APIURL:         os.Getenv("PLATSBANKEN_API_URL"),
+		JobDetailURL:   os.Getenv("PLATSBANKEN_JOB_DETAIL_URL"),
+		APIKey:         os.Getenv("PLATSBANKEN_API_KEY"),
+		MaxRecords:     100, // Standardvärde
+		DefaultMaxJobs: 500, // Ändrat från 1000 till 500
+		MaxRetries:     3,
+		RetryDelay:     1 * time.Second,
+	}
+
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://platsbanken-api.arbetsformedlingen.se/jobs/v1/"
+	}
+	if cfg.JobDetailURL == "" {
+		cfg.JobDetailURL = "https://platsbanken-api.arbetsformedlingen.se/jobs/v1/job/"
+	}
+
+	if n := positiveIntEnv("PLATSBANKEN_MAX_RECORDS"); n > 0 {
+		cfg.MaxRecords = n
+	}
+	if n := positiveIntEnv("PLATSBANKEN_DEFAULT_MAX_JOBS"); n > 0 {
+		cfg.DefaultMaxJobs = n
+	}
+	if n := positiveIntEnv("PLATSBANKEN_MAX_RETRIES"); n > 0 {
+		cfg.MaxRetries = n
+	}
+	if val := os.Getenv("PLATSBANKEN_RETRY_DELAY"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.RetryDelay = d
+		}
+	}
+
+	return cfg
+}
+
+func positiveIntEnv(name string) int {
+	if val := os.Getenv(name); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// ErrJobNotFound returneras av GetJob när annonsen inte finns, t.ex. för att
+// den har tagits bort
+var ErrJobNotFound = errors.New("annonsen finns inte i Platsbanken")
+
+// Client pratar med Platsbankens API
+type Client struct {
+	cfg           Config
+	httpClient    *http.Client
+	cache         cache.Store
+	cacheTTL      time.Duration
+	cacheMaxStale time.Duration
+}
+
+// NewClient skapar en ny klient
+func NewClient(cfg Config) *Client {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 1
+	}
+	if cfg.MaxRecords <= 0 {
+		cfg.MaxRecords = 100
+	}
+
+	return &Client{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Config returnerar klientens konfiguration
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// Search gör ett enskilt anrop mot /search och returnerar en sida med annonser
+func (c *Client) Search(ctx context.Context, searchReq SearchRequest) (*SearchResponse, error) {
+	if searchReq.Filters == nil {
+		searchReq.Filters = []Filter{}
+	}
+	if searchReq.Order == "" {
+		searchReq.Order = OrderRelevance
+	}
+	if searchReq.Source == "" {
+		searchReq.Source = SourcePlatsbanken
+	}
+	if searchReq.ToDate == "" {
+		searchReq.ToDate = time.Now().Format(time.RFC3339)
+	}
+	if searchReq.MaxRecords <= 0 {
+		searchReq.MaxRecords = c.cfg.MaxRecords
+	}
+
+	jsonData, err := json.Marshal(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("fel vid JSON-marshalling: %v", err)
+	}
+
+	// Logga payload som skickas till Arbetsförmedlingen
+	log.Printf("\n=== PAYLOAD TILL ARBETSFÖRMEDLINGEN ===")
+	log.Printf("URL: %s", c.cfg.APIURL+"search")
+	prettyJSON, _ := json.MarshalIndent(searchReq, "", "  ")
+	log.Printf("Payload:\n%s", string(prettyJSON))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.APIURL+"search", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("fel vid skapande av HTTP-förfrågan: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	c.setAPIKey(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fel vid HTTP-förfrågan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returnerade status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("fel vid JSON-dekodning: %v", err)
+	}
+
+	return &result, nil
+}
+
+// Iterate bläddrar igenom alla sidor för en sökning och anropar fn för varje
+// unik annons. Iterationen avslutas när maxJobs annonser har lämnats ut
+// (0 betyder ingen gräns), när fn returnerar false eller när resultaten tar slut.
+func (c *Client) Iterate(ctx context.Context, searchReq SearchRequest, maxJobs int, fn func(Ad) bool) error {
+	seenJobs := make(map[string]bool)
+	delivered := 0
+	searchReq.ToDate = time.Now().Format(time.RFC3339)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		currentMaxRecords := c.cfg.MaxRecords
+		if maxJobs > 0 {
+			remaining := maxJobs - delivered
+			if remaining <= 0 {
+				return nil
+			}
+			if remaining < currentMaxRecords {
+				currentMaxRecords = remaining
+			}
+		}
+		searchReq.MaxRecords = currentMaxRecords
+
+		result, err := c.Search(ctx, searchReq)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Ads) == 0 {
+			return nil
+		}
+
+		newAdsCount := 0
+		for _, ad := range result.Ads {
+			if ad.ID == "" || seenJobs[ad.ID] {
+				continue
+			}
+			seenJobs[ad.ID] = true
+			newAdsCount++
+			delivered++
+			if !fn(ad) {
+				return nil
+			}
+			if maxJobs > 0 && delivered >= maxJobs {
+				return nil
+			}
+		}
+
+		if newAdsCount == 0 {
+			return nil
+		}
+
+		searchReq.StartIndex += len(result.Ads)
+
+		if len(result.Ads) < currentMaxRecords {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// GetJob hämtar detaljerna för en annons, med omförsök enligt konfigurationen.
+// Om klienten har en cache används den i första hand.
+func (c *Client) GetJob(ctx context.Context, jobID string) (*JobDetail, error) {
+	if c.cache != nil {
+		return c.getJobCached(ctx, jobID)
+	}
+
+	resp, err := c.fetchJob(ctx, jobID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.detail, nil
+}
+
+// jobResponse är resultatet av ett anrop mot /job/{id}
+type jobResponse struct {
+	detail       *JobDetail
+	body         []byte
+	etag         string
+	lastModified string
+	notModified  bool
+}
+
+// fetchJob hämtar en annons från Platsbanken. Om etag eller lastModified
+// anges görs ett villkorligt anrop och notModified sätts vid 304.
+func (c *Client) fetchJob(ctx context.Context, jobID, etag, lastModified string) (*jobResponse, error) {
+	jobURL := c.cfg.JobDetailURL + url.PathEscape(jobID)
+
+	var lastErr error
+	for i := 0; i < c.cfg.MaxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.cfg.RetryDelay):
+			}
+		}
+
+		resp, retry, err := c.fetchJobOnce(ctx, jobURL, etag, lastModified)
+		if err == nil {
+			return resp, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("kunde inte hämta jobbdetaljer efter %d försök: %v", c.cfg.MaxRetries, lastErr)
+}
+
+// fetchJobOnce gör ett enskilt anrop och anger om felet är värt att försöka igen
+func (c *Client) fetchJobOnce(ctx context.Context, url, etag, lastModified string) (*jobResponse, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("kunde inte skapa request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	c.setAPIKey(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && (etag != "" || lastModified != "") {
+		return &jobResponse{notModified: true}, false, nil
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, false, ErrJobNotFound
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		// Andra klientfel blir inte bättre av att försöka igen
+		return nil, false, fmt.Errorf("fick status %d vid hämtning av jobbdetaljer", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, true, fmt.Errorf("fick status %d vid hämtning av jobbdetaljer", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("kunde inte läsa jobbdetaljer: %v", err)
+	}
+
+	var detail JobDetail
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return nil, false, fmt.Errorf("kunde inte avkoda jobbdetaljer: %v", err)
+	}
+
+	return &jobResponse{
+		detail:       &detail,
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, false, nil
+}
+
+func (c *Client) setAPIKey(req *http.Request) {
+	if c.cfg.APIKey != "" {
+		req.Header.Set("api-key", c.cfg.APIKey)
+	}
+}
@@ -0,0 +1,249 @@
+package platsbanken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// searchServer är en falsk /search som delar upp total annonser i sidor
+// enligt förfrågans startIndex och maxRecords
+type searchServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []SearchRequest
+	apiKeys  []string
+}
+
+func newSearchServer(t *testing.T, total int) *searchServer {
+	t.Helper()
+	s := &searchServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/search" {
+			http.NotFound(w, r)
+			return
+		}
+		var req SearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.apiKeys = append(s.apiKeys, r.Header.Get("api-key"))
+		s.mu.Unlock()
+
+		ads := []map[string]interface{}{}
+		for i := req.StartIndex; i < total && i < req.StartIndex+req.MaxRecords; i++ {
+			ads = append(ads, map[string]interface{}{"id": fmt.Sprint(1000 + i), "title": fmt.Sprintf("Annons %d", i)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ads": ads, "numberOfAds": total, "positions": total * 2})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *searchServer) Requests() []SearchRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SearchRequest(nil), s.requests...)
+}
+
+func TestSearch(t *testing.T) {
+	server := newSearchServer(t, 3)
+	client := NewClient(Config{APIURL: server.URL + "/", APIKey: "nyckel", MaxRecords: 25})
+
+	result, err := client.Search(context.Background(), SearchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Ads) != 3 || result.Ads[0].ID != "1000" || result.NumberOfAds != 3 || result.Positions != 6 {
+		t.Errorf("resultat = %+v", result)
+	}
+
+	// Tomma fält får standardvärden innan anropet
+	req := server.Requests()[0]
+	if req.Filters == nil || req.Order != OrderRelevance || req.Source != SourcePlatsbanken || req.MaxRecords != 25 || req.ToDate == "" {
+		t.Errorf("förfrågan = %+v", req)
+	}
+	if server.apiKeys[0] != "nyckel" {
+		t.Errorf("api-key = %q", server.apiKeys[0])
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "trasig förfrågan", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(Config{APIURL: server.URL + "/"}).Search(context.Background(), SearchRequest{})
+	if err == nil {
+		t.Fatal("Search lyckades trots status 400")
+	}
+}
+
+func TestIterate(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		maxJobs    int
+		stopAfter  int
+		wantIDs    int
+		wantStarts []int
+	}{
+		{"alla sidor", 5, 0, 0, 5, []int{0, 2, 4}},
+		{"maxJobs", 5, 3, 0, 3, []int{0, 2}},
+		{"fn avbryter", 5, 0, 1, 1, []int{0}},
+		{"jämnt antal", 4, 0, 0, 4, []int{0, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSearchServer(t, tt.total)
+			client := NewClient(Config{APIURL: server.URL + "/", MaxRecords: 2})
+
+			var ids []string
+			err := client.Iterate(context.Background(), SearchRequest{}, tt.maxJobs, func(ad Ad) bool {
+				ids = append(ids, ad.ID)
+				return tt.stopAfter == 0 || len(ids) < tt.stopAfter
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != tt.wantIDs {
+				t.Errorf("annonser = %v, vill ha %d", ids, tt.wantIDs)
+			}
+
+			var starts []int
+			for _, req := range server.Requests() {
+				starts = append(starts, req.StartIndex)
+			}
+			if !reflect.DeepEqual(starts, tt.wantStarts) {
+				t.Errorf("startIndex = %v, vill ha %v", starts, tt.wantStarts)
+			}
+		})
+	}
+}
+
+// En sida utan nya annonser avslutar iterationen, annars skulle en
+// Platsbanken som ignorerar startIndex ge en oändlig loop
+func TestIterateStopsOnRepeatedPage(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"ads": [{"id": "1"}, {"id": "2"}], "numberOfAds": 100}`))
+	}))
+	defer server.Close()
+
+	var ids []string
+	err := NewClient(Config{APIURL: server.URL + "/", MaxRecords: 2}).Iterate(context.Background(), SearchRequest{}, 0, func(ad Ad) bool {
+		ids = append(ids, ad.ID)
+		return true
+	})
+	if err != nil || len(ids) != 2 || calls != 2 {
+		t.Errorf("annonser = %v, anrop = %d, fel = %v", ids, calls, err)
+	}
+}
+
+func TestGetJobRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"lyckas direkt", []int{200}, 1, false},
+		{"tillfälliga fel", []int{503, 502, 200}, 3, false},
+		{"för många förfrågningar", []int{429, 200}, 2, false},
+		{"ger upp", []int{500, 500, 500, 200}, 3, true},
+		{"finns inte", []int{404, 200}, 1, true},
+		{"klientfel", []int{400, 200}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := tt.statuses[calls]
+				calls++
+				mu.Unlock()
+				w.WriteHeader(status)
+				w.Write([]byte(`{"id": "1", "title": "Utvecklare"}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{JobDetailURL: server.URL + "/job/", MaxRetries: 3, RetryDelay: 10 * time.Millisecond})
+			start := time.Now()
+			detail, err := client.GetJob(context.Background(), "1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetJob = %+v, %v", detail, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("antal anrop = %d, vill ha %d", calls, tt.wantCalls)
+			}
+			// Mellan försöken väntar klienten RetryDelay
+			if wait := time.Duration(calls-1) * 10 * time.Millisecond; time.Since(start) < wait {
+				t.Errorf("GetJob tog %s, vill ha minst %s", time.Since(start), wait)
+			}
+		})
+	}
+}
+
+func TestGetJobNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(Config{JobDetailURL: server.URL + "/job/", MaxRetries: 3}).GetJob(context.Background(), "1")
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("fel = %v, vill ha ErrJobNotFound", err)
+	}
+}
+
+func TestGetJobRetryCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nere", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := NewClient(Config{JobDetailURL: server.URL + "/job/", MaxRetries: 3, RetryDelay: time.Minute})
+
+	start := time.Now()
+	if _, err := client.GetJob(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fel = %v, vill ha context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("GetJob väntade ut RetryDelay trots att ctx gick ut")
+	}
+}
+
+// Annons-ID:t får inte kunna ändra vilken adress som anropas
+func TestGetJobEscapesID(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{JobDetailURL: server.URL + "/job/", MaxRetries: 1})
+	client.GetJob(context.Background(), "../search?q=1")
+
+	if len(paths) != 1 || paths[0] != "/job/..%2Fsearch%3Fq=1?" {
+		t.Errorf("anropade %v", paths)
+	}
+}
@@ -0,0 +1,233 @@
+package platsbanken
+
+import (
+	"encoding/json"
+)
+
+// FilterType anger vilken sorts filter som skickas till Platsbankens sök-API
+type FilterType string
+
+const (
+	FilterFreetext               FilterType = "freetext"
+	FilterMunicipality           FilterType = "municipality"
+	FilterRegion                 FilterType = "region"
+	FilterWorkExtent             FilterType = "workExtent"
+	FilterRemote                 FilterType = "remote"
+	FilterDrivingLicenseRequired FilterType = "drivingLicenseRequired"
+	FilterOccupationField        FilterType = "occupationField"
+	FilterOccupationGroup        FilterType = "occupationGroup"
+	FilterEmploymentType         FilterType = "employmentType"
+)
+
+// Koncept-ID:n för arbetstid i JobTechs taxonomi
+const (
+	WorkExtentFullTime = "947z_JGS_Uk2"
+	WorkExtentPartTime = "947z_JGS_Uk3"
+)
+
+const (
+	OrderRelevance    = "relevance"
+	SourcePlatsbanken = "pb"
+)
+
+// Filter är ett enskilt sökfilter, t.ex. {"type": "municipality", "value": "AvNB_uwa_6n6"}
+type Filter struct {
+	Type  FilterType `json:"type"`
+	Value string     `json:"value"`
+}
+
+// Freetext skapar ett fritextfilter
+func Freetext(term string) Filter {
+	return Filter{Type: FilterFreetext, Value: term}
+}
+
+// Municipality skapar ett kommunfilter utifrån kommunens koncept-ID
+func Municipality(id string) Filter {
+	return Filter{Type: FilterMunicipality, Value: id}
+}
+
+// Region skapar ett länsfilter utifrån länets koncept-ID
+func Region(id string) Filter {
+	return Filter{Type: FilterRegion, Value: id}
+}
+
+// SearchRequest är den payload som skickas till /search
+type SearchRequest struct {
+	Filters    []Filter `json:"filters"`
+	FromDate   *string  `json:"fromDate"`
+	Order      string   `json:"order"`
+	MaxRecords int      `json:"maxRecords"`
+	StartIndex int      `json:"startIndex"`
+	ToDate     string   `json:"toDate"`
+	Source     string   `json:"source"`
+}
+
+// SearchResponse är svaret från /search
+type SearchResponse struct {
+	Ads         []Ad `json:"ads"`
+	NumberOfAds int  `json:"numberOfAds"`
+	Positions   int  `json:"positions"`
+}
+
+// Ad är en annons i en söklista. Endast de fält vi använder är typade,
+// originalsvaret sparas och skickas vidare oförändrat till klienten.
+type Ad struct {
+	ID                  string
+	Title               string
+	Occupation          string
+	WorkplaceName       string
+	PublishedDate       string
+	LastApplicationDate string
+	Positions           int
+
+	raw json.RawMessage
+}
+
+// UnmarshalJSON läser annonsen tolerant så att oväntade fälttyper inte
+// gör att hela sökningen misslyckas
+func (a *Ad) UnmarshalJSON(b []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	a.ID = getString(m, "id")
+	a.Title = getString(m, "title")
+	a.Occupation = getString(m, "occupation")
+	a.WorkplaceName = getString(m, "workplaceName")
+	a.PublishedDate = getString(m, "publishedDate")
+	a.LastApplicationDate = getString(m, "lastApplicationDate")
+	a.Positions = getInt(m, "positions")
+	a.raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// MarshalJSON skickar vidare originalannonsen
+func (a Ad) MarshalJSON() ([]byte, error) {
+	if len(a.raw) > 0 {
+		return a.raw, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"id":                  a.ID,
+		"title":               a.Title,
+		"occupation":          a.Occupation,
+		"workplaceName":       a.WorkplaceName,
+		"publishedDate":       a.PublishedDate,
+		"lastApplicationDate": a.LastApplicationDate,
+		"positions":           a.Positions,
+	})
+}
+
+// Company är arbetsgivaren i en jobbannons
+type Company struct {
+	Name string `json:"name"`
+}
+
+// JobDetail är en fullständig jobbannons från /job/{id}. Precis som för Ad
+// behålls originalsvaret så att frontend får exakt samma JSON som tidigare.
+type JobDetail struct {
+	ID                  string
+	Title               string
+	Description         string
+	Company             Company
+	Occupation          string
+	RequiresExperience  bool
+	SalaryType          string
+	SalaryTypeID        string // koncept-ID för lönetypen, om annonsen har det
+	PublishedDate       string
+	LastApplicationDate string
+
+	raw    json.RawMessage
+	fields map[string]interface{}
+}
+
+// UnmarshalJSON läser jobbdetaljerna tolerant
+func (j *JobDetail) UnmarshalJSON(b []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	j.ID = getString(m, "id")
+	j.Title = getString(m, "title")
+	j.Description = getString(m, "description")
+	j.Company = Company{Name: getString(m, "company", "name")}
+	j.Occupation = getString(m, "occupation")
+	if j.Occupation == "" {
+		j.Occupation = getString(m, "occupation", "label")
+	}
+	j.RequiresExperience = getBool(m, "requiresExperience")
+	j.SalaryType = getString(m, "salaryType")
+	if j.SalaryType == "" {
+		j.SalaryType = getString(m, "salaryType", "label")
+		j.SalaryTypeID = getString(m, "salaryType", "conceptId")
+	}
+	if j.SalaryType == "" && j.SalaryTypeID == "" {
+		j.SalaryType = getString(m, "salary_type", "label")
+		j.SalaryTypeID = getString(m, "salary_type", "concept_id")
+	}
+	j.PublishedDate = getString(m, "publishedDate")
+	j.LastApplicationDate = getString(m, "lastApplicationDate")
+	j.raw = append(json.RawMessage(nil), b...)
+	j.fields = m
+	return nil
+}
+
+// MarshalJSON skickar vidare originaldetaljerna
+func (j JobDetail) MarshalJSON() ([]byte, error) {
+	if len(j.raw) > 0 {
+		return j.raw, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"id":                  j.ID,
+		"title":               j.Title,
+		"description":         j.Description,
+		"company":             j.Company,
+		"occupation":          j.Occupation,
+		"requiresExperience":  j.RequiresExperience,
+		"salaryType":          j.SalaryType,
+		"publishedDate":       j.PublishedDate,
+		"lastApplicationDate": j.LastApplicationDate,
+	})
+}
+
+// Field hämtar ett godtyckligt (nästlat) strängfält ur originalsvaret,
+// t.ex. detail.Field("workplace", "municipality")
+func (j *JobDetail) Field(keys ...string) string {
+	return getString(j.fields, keys...)
+}
+
+// getString hämtar ett nästlat strängvärde ur en map
+func getString(m map[string]interface{}, keys ...string) string {
+	if v, ok := lookup(m, keys...).(string); ok {
+		return v
+	}
+	return ""
+}
+
+func getBool(m map[string]interface{}, keys ...string) bool {
+	v, _ := lookup(m, keys...).(bool)
+	return v
+}
+
+func getInt(m map[string]interface{}, keys ...string) int {
+	if v, ok := lookup(m, keys...).(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+func lookup(m map[string]interface{}, keys ...string) interface{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	current := m
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current[keys[len(keys)-1]]
+}
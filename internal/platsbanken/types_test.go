@@ -0,0 +1,28 @@
+package platsbanken
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJobDetailSalaryType(t *testing.T) {
+	tests := []struct {
+		raw       string
+		wantLabel string
+		wantID    string
+	}{
+		{`{"salaryType": "Fast och rörlig lön"}`, "Fast och rörlig lön", ""},
+		{`{"salaryType": {"label": "Fast och rörlig lön", "conceptId": "asrX_9Df_ukn"}}`, "Fast och rörlig lön", "asrX_9Df_ukn"},
+		{`{"salary_type": {"label": "Rörlig ackords- eller provisionslön", "concept_id": "vVtj_qm6_GQu"}}`, "Rörlig ackords- eller provisionslön", "vVtj_qm6_GQu"},
+		{`{}`, "", ""},
+	}
+	for _, tt := range tests {
+		var detail JobDetail
+		if err := json.Unmarshal([]byte(tt.raw), &detail); err != nil {
+			t.Fatal(err)
+		}
+		if detail.SalaryType != tt.wantLabel || detail.SalaryTypeID != tt.wantID {
+			t.Errorf("%s: lönetyp = %q, %q", tt.raw, detail.SalaryType, detail.SalaryTypeID)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package prompts
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"awesomeProject/internal/data"
+	"awesomeProject/internal/guardrails"
+)
+
+// funcs är hjälpfunktionerna som mallarna kan använda
+var funcs = template.FuncMap{
+	"truncate":       truncate,
+	"counties":       counties,
+	"municipalities": municipalities,
+	"join":           strings.Join,
+	"today":          today,
+	"clean":          guardrails.Clean,
+	"untrusted":      untrusted,
+	"toJSON":         toJSON,
+}
+
+// truncate begränsar texten till max tecken och markerar att den kortats
+func truncate(max int, text string) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "..."
+}
+
+// untrusted städar text från användaren eller en annons, begränsar den till
+// max tecken och sätter den inom <<< >>> så att mallen kan be modellen
+// behandla den som data
+func untrusted(max int, text string) string {
+	return guardrails.DataStart + truncate(max, guardrails.Clean(text)) + guardrails.DataEnd
+}
+
+// toJSON skriver value som indenterad JSON
+func toJSON(value interface{}) (string, error) {
+	b, err := json.MarshalIndent(value, "", "  ")
+	return string(b), err
+}
+
+// counties listar länen i data.MunicipalityMap i bokstavsordning
+func counties() []string {
+	return municipalityNames(true)
+}
+
+// municipalities listar kommunerna i data.MunicipalityMap i bokstavsordning
+func municipalities() []string {
+	return municipalityNames(false)
+}
+
+func municipalityNames(counties bool) []string {
+	var names []string
+	for name := range data.MunicipalityMap {
+		if strings.HasSuffix(name, " län") == counties {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// today är dagens datum i formatet 2006-01-02
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
@@ -0,0 +1,273 @@
+// Package prompts laddar AI-promptarna från mallfiler på disk. Varje prompt
+// finns i en eller flera versioner och på flera språk:
+//
+//	<katalog>/<prompt>/<version>.<språk>.tmpl, t.ex. cv/v1.sv.tmpl
+//
+// En mallfil är en text/template som definierar "user" och valfritt
+// "system", ett block per meddelande till modellen. Senaste versionen
+// används om den inte låsts med PROMPT_VERSIONS.
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultLanguage används när ett språk saknas för en prompt
+const DefaultLanguage = "sv"
+
+// Config styr var promptarna finns och vilka versioner som används
+type Config struct {
+	// Dir är katalogen med en underkatalog per prompt
+	Dir string
+	// Language är språket när anroparen inte anger något
+	Language string
+	// Versions låser promptar till en viss version, t.ex. {"cv": "v1"}
+	Versions map[string]string
+}
+
+// ConfigFromEnv läser PROMPTS_DIR, PROMPT_LANGUAGE och PROMPT_VERSIONS
+// (t.ex. "cv=v1,search_analysis=v2")
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Dir:      os.Getenv("PROMPTS_DIR"),
+		Language: os.Getenv("PROMPT_LANGUAGE"),
+		Versions: make(map[string]string),
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "internal/prompts/templates"
+	}
+	if cfg.Language == "" {
+		cfg.Language = DefaultLanguage
+	}
+	for _, pair := range strings.Split(os.Getenv("PROMPT_VERSIONS"), ",") {
+		name, version, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(name) != "" && strings.TrimSpace(version) != "" {
+			cfg.Versions[strings.TrimSpace(name)] = strings.TrimSpace(version)
+		}
+	}
+	return cfg
+}
+
+// Prompt är en renderad prompt redo att skickas till modellen
+type Prompt struct {
+	Name     string
+	Version  string
+	Language string
+	System   string
+	User     string
+}
+
+// Label identifierar prompten i genererade dokument och mätvärden,
+// t.ex. "cv@v1/sv"
+func (p *Prompt) Label() string {
+	return fmt.Sprintf("%s@%s/%s", p.Name, p.Version, p.Language)
+}
+
+// promptSet är alla versioner och språk av en prompt
+type promptSet struct {
+	// versions sorteras från äldst till nyast
+	versions  []string
+	templates map[string]map[string]*template.Template
+}
+
+// Registry håller de inlästa promptarna. Load kan anropas när som helst;
+// pågående renderingar använder de mallar som gällde när de startade.
+type Registry struct {
+	cfg Config
+
+	mu          sync.RWMutex
+	prompts     map[string]*promptSet
+	fingerprint string
+}
+
+// NewRegistry skapar ett register och läser in promptarna
+func NewRegistry(cfg Config) (*Registry, error) {
+	if cfg.Language == "" {
+		cfg.Language = DefaultLanguage
+	}
+	r := &Registry{cfg: cfg, prompts: make(map[string]*promptSet)}
+	if err := r.Load(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// Load läser in alla mallar i katalogen. Om någon mall är trasig behålls
+// de tidigare inlästa så att en felaktig ändring inte tar ner tjänsten.
+func (r *Registry) Load() error {
+	fingerprint, err := r.scan()
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.cfg.Dir, "*", "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("inga promptar hittades i %s", r.cfg.Dir)
+	}
+
+	prompts := make(map[string]*promptSet)
+	for _, path := range files {
+		name := filepath.Base(filepath.Dir(path))
+		version, language, ok := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".tmpl"), ".")
+		if !ok || version == "" || language == "" {
+			return fmt.Errorf("%s: filnamnet ska vara <version>.<språk>.tmpl", path)
+		}
+
+		tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+		if err != nil {
+			return fmt.Errorf("kunde inte läsa prompten %s: %v", path, err)
+		}
+		if tmpl.Lookup("user") == nil {
+			return fmt.Errorf("%s: mallen saknar {{define \"user\"}}", path)
+		}
+
+		set, ok := prompts[name]
+		if !ok {
+			set = &promptSet{templates: make(map[string]map[string]*template.Template)}
+			prompts[name] = set
+		}
+		if _, ok := set.templates[version]; !ok {
+			set.templates[version] = make(map[string]*template.Template)
+			set.versions = append(set.versions, version)
+		}
+		set.templates[version][language] = tmpl
+	}
+
+	for name, set := range prompts {
+		sort.Slice(set.versions, func(i, j int) bool {
+			return versionLess(set.versions[i], set.versions[j])
+		})
+		if pinned, ok := r.cfg.Versions[name]; ok && set.templates[pinned] == nil {
+			log.Printf("⚠️ PROMPT_VERSIONS låser %s till %s som inte finns, använder %s", name, pinned, set.versions[len(set.versions)-1])
+		}
+	}
+
+	r.mu.Lock()
+	r.prompts = prompts
+	r.fingerprint = fingerprint
+	r.mu.Unlock()
+	return nil
+}
+
+// Render fyller prompten name med data. language kan vara tomt eller en
+// språkkod som "en" eller "en-US"; saknas språket används standardspråket.
+func (r *Registry) Render(name, language string, data interface{}) (*Prompt, error) {
+	r.mu.RLock()
+	set := r.prompts[name]
+	r.mu.RUnlock()
+	if set == nil {
+		return nil, fmt.Errorf("prompten %s finns inte", name)
+	}
+
+	version := set.versions[len(set.versions)-1]
+	if pinned, ok := r.cfg.Versions[name]; ok && set.templates[pinned] != nil {
+		version = pinned
+	}
+	language, tmpl := r.pickLanguage(set.templates[version], language)
+
+	prompt := &Prompt{Name: name, Version: version, Language: language}
+	if system := tmpl.Lookup("system"); system != nil {
+		text, err := execute(system, data)
+		if err != nil {
+			return nil, fmt.Errorf("kunde inte rendera prompten %s: %v", prompt.Label(), err)
+		}
+		prompt.System = text
+	}
+	text, err := execute(tmpl.Lookup("user"), data)
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte rendera prompten %s: %v", prompt.Label(), err)
+	}
+	prompt.User = text
+
+	renders.WithLabelValues(name, version, language).Inc()
+	return prompt, nil
+}
+
+// pickLanguage väljer önskat språk, annars standardspråket, annars svenska
+// och till sist det första som finns
+func (r *Registry) pickLanguage(templates map[string]*template.Template, language string) (string, *template.Template) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if base, _, ok := strings.Cut(language, "-"); ok {
+		language = base
+	}
+	for _, candidate := range []string{language, r.cfg.Language, DefaultLanguage} {
+		if tmpl, ok := templates[candidate]; ok {
+			return candidate, tmpl
+		}
+	}
+
+	languages := make([]string, 0, len(templates))
+	for l := range templates {
+		languages = append(languages, l)
+	}
+	sort.Strings(languages)
+	return languages[0], templates[languages[0]]
+}
+
+// Versions listar de inlästa versionerna av varje prompt, äldst först
+func (r *Registry) Versions() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make(map[string][]string, len(r.prompts))
+	for name, set := range r.prompts {
+		versions[name] = append([]string(nil), set.versions...)
+	}
+	return versions
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// versionLess jämför versioner som "v2" och "v10" numeriskt och faller
+// tillbaka på vanlig strängjämförelse
+func versionLess(a, b string) bool {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default är registret som AI-funktionerna använder, konfigurerat från miljön
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		cfg := ConfigFromEnv()
+		registry, err := NewRegistry(cfg)
+		if err != nil {
+			log.Printf("❌ Kunde inte läsa promptar från %s: %v", cfg.Dir, err)
+		} else {
+			log.Printf("📝 Läste promptar från %s: %v", cfg.Dir, registry.Versions())
+		}
+		defaultRegistry = registry
+	})
+	return defaultRegistry
+}
+
+// Render renderar en prompt från standardregistret
+func Render(name, language string, data interface{}) (*Prompt, error) {
+	return Default().Render(name, language, data)
+}
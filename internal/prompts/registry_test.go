@@ -0,0 +1,169 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePrompt skriver en mallfil i dir/name/file
+func writePrompt(t *testing.T, dir, name, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, file), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "greeting", "v1.sv.tmpl", `{{define "user"}}Hej {{.Name}}{{end}}`)
+	writePrompt(t, dir, "greeting", "v2.sv.tmpl", `{{define "system"}}Var artig{{end}}{{define "user"}}Hej igen {{.Name}}{{end}}`)
+	writePrompt(t, dir, "greeting", "v2.en.tmpl", `{{define "user"}}Hello {{.Name}}{{end}}`)
+	writePrompt(t, dir, "greeting", "v10.sv.tmpl", `{{define "user"}}Tjena {{.Name}}{{end}}`)
+
+	tests := []struct {
+		name     string
+		versions map[string]string
+		language string
+		label    string
+		user     string
+	}{
+		{"senaste versionen", nil, "", "greeting@v10/sv", "Tjena Anna"},
+		{"låst version", map[string]string{"greeting": "v2"}, "", "greeting@v2/sv", "Hej igen Anna"},
+		{"engelska", map[string]string{"greeting": "v2"}, "en-GB", "greeting@v2/en", "Hello Anna"},
+		{"språket saknas", map[string]string{"greeting": "v1"}, "en", "greeting@v1/sv", "Hej Anna"},
+		{"låst version saknas", map[string]string{"greeting": "v9"}, "", "greeting@v10/sv", "Tjena Anna"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := NewRegistry(Config{Dir: dir, Versions: tt.versions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			prompt, err := registry.Render("greeting", tt.language, struct{ Name string }{"Anna"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prompt.Label() != tt.label || prompt.User != tt.user {
+				t.Errorf("Render = %s %q, vill ha %s %q", prompt.Label(), prompt.User, tt.label, tt.user)
+			}
+		})
+	}
+
+	registry, _ := NewRegistry(Config{Dir: dir, Versions: map[string]string{"greeting": "v2"}})
+	if prompt, _ := registry.Render("greeting", "", struct{ Name string }{"Anna"}); prompt.System != "Var artig" {
+		t.Errorf("System = %q", prompt.System)
+	}
+	if _, err := registry.Render("saknas", "", nil); err == nil {
+		t.Error("okänd prompt ska ge fel")
+	}
+}
+
+func TestLoadRejectsInvalidTemplates(t *testing.T) {
+	for name, content := range map[string]string{
+		"v1.sv.tmpl": `{{define "system"}}Bara system{{end}}`,
+		"v1.tmpl":    `{{define "user"}}Hej{{end}}`,
+		"v2.sv.tmpl": `{{define "user"}}{{.Name}{{end}}`,
+	} {
+		dir := t.TempDir()
+		writePrompt(t, dir, "greeting", name, content)
+		if _, err := NewRegistry(Config{Dir: dir}); err == nil {
+			t.Errorf("%s: %q ska inte gå att läsa in", name, content)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "greeting", "v1.sv.tmpl", `{{define "user"}}Hej{{end}}`)
+	registry, err := NewRegistry(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded, err := registry.Reload(); reloaded || err != nil {
+		t.Errorf("Reload utan ändringar = %v, %v", reloaded, err)
+	}
+
+	writePrompt(t, dir, "greeting", "v2.sv.tmpl", `{{define "user"}}Hej igen{{end}}`)
+	if reloaded, err := registry.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload efter ny version = %v, %v", reloaded, err)
+	}
+	if prompt, _ := registry.Render("greeting", "", nil); prompt.Version != "v2" {
+		t.Errorf("version efter omladdning = %s, vill ha v2", prompt.Version)
+	}
+
+	// En trasig mall ska inte ersätta de fungerande
+	writePrompt(t, dir, "greeting", "v3.sv.tmpl", `{{define "user"}}{{if}}{{end}}`)
+	if _, err := registry.Reload(); err == nil {
+		t.Fatal("trasig mall ska ge fel")
+	}
+	if prompt, _ := registry.Render("greeting", "", nil); prompt.Version != "v2" {
+		t.Errorf("version efter misslyckad omladdning = %s, vill ha v2", prompt.Version)
+	}
+}
+
+// TestTemplates renderar alla promptar som följer med tjänsten på alla språk
+func TestTemplates(t *testing.T) {
+	registry, err := NewRegistry(Config{Dir: "templates"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := struct {
+		JobTitle, JobDescription, JobDesc, CompanyName, Query     string
+		Experience, Education, Skills, Certifications, Bio, Phone string
+		Location, Name, Email                                     string
+		CV                                                        interface{}
+	}{JobTitle: "Utvecklare", CompanyName: "Exempelbolaget AB", Query: "jobb i gävle", Bio: strings.Repeat("å", 300)}
+
+	for name, versions := range registry.Versions() {
+		for _, language := range []string{"sv", "en"} {
+			prompt, err := registry.Render(name, language, data)
+			if err != nil {
+				t.Errorf("%s/%s: %v", name, language, err)
+				continue
+			}
+			if prompt.Language != language || prompt.Version != versions[len(versions)-1] {
+				t.Errorf("%s/%s renderades som %s", name, language, prompt.Label())
+			}
+			if strings.Contains(prompt.System+prompt.User, "<no value>") {
+				t.Errorf("%s: mallen använder ett fält som saknas", prompt.Label())
+			}
+		}
+	}
+
+	prompt, _ := registry.Render("search_analysis", "", data)
+	if !strings.Contains(prompt.User, "Gävleborgs län") || !strings.Contains(prompt.User, "Östersund") {
+		t.Error("kommunlistan saknas i search_analysis")
+	}
+	if prompt, _ := registry.Render("cv", "", data); strings.Contains(prompt.User, strings.Repeat("å", 201)) {
+		t.Error("truncate kortade inte texten")
+	}
+
+	// Med strukturerade uppgifter får CV-prompten inte be modellen hitta på
+	data.CV = map[string]interface{}{"fardigheter": []string{"Go", "PostgreSQL"}}
+	invented := map[string][]string{
+		"sv": {"hitta på så", "kreativ", `"exemple"`, "exempel@email.se"},
+		"en": {"creatively", `"example"`, "example@email.com"},
+	}
+	for language, phrases := range invented {
+		prompt, err := registry.Render("cv", language, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(prompt.User, "PostgreSQL") {
+			t.Errorf("cv/%s: uppgifterna saknas i prompten", language)
+		}
+		for _, phrase := range phrases {
+			if strings.Contains(prompt.User, phrase) {
+				t.Errorf("cv/%s: prompten innehåller %q trots strukturerade uppgifter", language, phrase)
+			}
+		}
+	}
+}
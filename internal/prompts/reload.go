@@ -0,0 +1,117 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	renders = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prompt_renders_total",
+		Help: "Antal renderade promptar per prompt, version och språk",
+	}, []string{"prompt", "version", "language"})
+	reloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prompt_reloads_total",
+		Help: "Antal omladdningar av promptfilerna, med resultat success eller error",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(renders, reloads)
+}
+
+// scan sammanfattar mallfilernas namn, storlek och ändringstid så att
+// ändringar kan upptäckas utan att filerna läses
+func (r *Registry) scan() (string, error) {
+	files, err := filepath.Glob(filepath.Join(r.cfg.Dir, "*", "*.tmpl"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	var b strings.Builder
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// Reload läser om mallarna om någon fil har ändrats, lagts till eller tagits
+// bort sedan förra inläsningen. Returnerar true om mallarna lästes om.
+func (r *Registry) Reload() (bool, error) {
+	fingerprint, err := r.scan()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := fingerprint == r.fingerprint
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	if err := r.Load(); err != nil {
+		reloads.WithLabelValues("error").Inc()
+		return false, err
+	}
+	reloads.WithLabelValues("success").Inc()
+	return true, nil
+}
+
+// Watch kontrollerar katalogen var interval och läser om ändrade mallar
+// tills ctx avbryts
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			reloaded, err := r.Reload()
+			switch {
+			case err != nil:
+				log.Printf("❌ Kunde inte läsa om promptarna, behåller tidigare versioner: %v", err)
+			case reloaded:
+				log.Printf("🔄 Läste om promptarna: %v", r.Versions())
+			}
+		}
+	}()
+}
+
+// StartHotReload läser om standardregistrets mallar när de ändras på disk.
+// Intervallet sätts med PROMPT_RELOAD_INTERVAL (standard 10s, 0 stänger av).
+func StartHotReload(ctx context.Context) {
+	interval := 10 * time.Second
+	if val := os.Getenv("PROMPT_RELOAD_INTERVAL"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			log.Printf("⚠️ Ogiltigt PROMPT_RELOAD_INTERVAL %q, använder %s", val, interval)
+		} else {
+			interval = d
+		}
+	}
+	if interval <= 0 {
+		log.Printf("📝 Omladdning av promptar är avstängd")
+		return
+	}
+
+	log.Printf("📝 Bevakar promptarna i %s (kontroll var %s)", Default().cfg.Dir, interval)
+	Default().Watch(ctx, interval)
+}
@@ -6,15 +6,41 @@ import (
 )
 
 func SetupRoutes(router *gin.Engine) {
+	// Routes som anropar AI bokförs per användare och begränsas av dagskvoterna
+	aiQuota := handlers.AIQuota()
+
 	// CV routes
-	router.POST("/api/generate-cv", handlers.GenerateCV)
+	router.POST("/api/generate-cv", aiQuota, handlers.GenerateCV)
+	router.POST("/api/generate-cv/stream", aiQuota, handlers.GenerateCVStream)
+	router.POST("/api/cv/export", handlers.ExportCV)
+	router.POST("/api/cv/import", handlers.ImportJSONResume)
 	
 	// Cover letter routes
-	router.POST("/api/generate-cover-letter", handlers.GenerateCoverLetter)
-	router.POST("/api/generate-ai-cover-letter", handlers.GenerateAICoverLetter)
+	router.POST("/api/generate-cover-letter", aiQuota, handlers.GenerateCoverLetter)
+	router.POST("/api/generate-ai-cover-letter", aiQuota, handlers.GenerateAICoverLetter)
+	router.POST("/api/generate-ai-cover-letter/stream", aiQuota, handlers.GenerateAICoverLetterStream)
 
 	// Job routes
 	router.POST("/api/search", handlers.SearchJobs)
-	router.POST("/api/analyze-search", handlers.AnalyzeSearchQuery)
+	router.GET("/api/search/stream", handlers.SearchJobsStream)
+	router.POST("/api/search/stream", handlers.SearchJobsStream)
+	router.POST("/api/analyze-search", aiQuota, handlers.AnalyzeSearchQuery)
 	router.POST("/api/recommended-jobs", handlers.GetRecommendedJobs)
-} 
\ No newline at end of file
+
+	// Matchning mellan CV och annonser
+	router.POST("/api/match", handlers.MatchJobs)
+
+	// Sparade sökningar och notiser
+	router.GET("/api/saved-searches", handlers.ListSavedSearches)
+	router.POST("/api/saved-searches", handlers.CreateSavedSearch)
+	router.GET("/api/saved-searches/:id", handlers.GetSavedSearch)
+	router.PUT("/api/saved-searches/:id", handlers.UpdateSavedSearch)
+	router.DELETE("/api/saved-searches/:id", handlers.DeleteSavedSearch)
+	router.GET("/api/notifications", handlers.GetNotifications)
+	router.POST("/api/notifications/:id/read", handlers.MarkNotificationRead)
+	router.GET("/api/alerts/unsubscribe", handlers.UnsubscribeAlerts)
+	router.POST("/api/alerts/unsubscribe", handlers.UnsubscribeAlerts)
+
+	// Admin
+	router.GET("/api/admin/usage", handlers.GetUsageReport)
+}
@@ -0,0 +1,287 @@
+package savedsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/mailer"
+)
+
+// Hur ofta en e-postsammanfattning skickas
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// platsbankenAdURL är adressen till en annons på Arbetsförmedlingens webbplats
+const platsbankenAdURL = "https://arbetsformedlingen.se/platsbanken/annonser/"
+
+// ValidDigestFrequency anger om frekvensen är en som stöds
+func ValidDigestFrequency(frequency string) bool {
+	return frequency == DigestDaily || frequency == DigestWeekly
+}
+
+func digestPeriod(frequency string) time.Duration {
+	if frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digestEnabled anger om sökningen ska samla annonser till en sammanfattning
+func (s *SavedSearch) digestEnabled() bool {
+	return s.Email != "" && ValidDigestFrequency(s.DigestFrequency)
+}
+
+// digestDue anger om det är dags att skicka sammanfattningen
+func (s *SavedSearch) digestDue(now time.Time) bool {
+	if !s.digestEnabled() || len(s.PendingDigest) == 0 {
+		return false
+	}
+	last := s.CreatedAt
+	if s.LastDigestAt != nil {
+		last = *s.LastDigestAt
+	}
+	return now.Sub(last) >= digestPeriod(s.DigestFrequency)
+}
+
+// QueueDigest lägger nya annonser i kö till sökningens nästa sammanfattning
+func (s *Store) QueueDigest(id string, jobs []NotificationJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok || !search.digestEnabled() {
+		return
+	}
+
+	search.PendingDigest = append(search.PendingDigest, jobs...)
+	if len(search.PendingDigest) > maxPendingDigestJobs {
+		search.PendingDigest = search.PendingDigest[len(search.PendingDigest)-maxPendingDigestJobs:]
+	}
+	s.save()
+}
+
+// DueDigests returnerar kopior av alla sökningar vars sammanfattning ska skickas nu
+func (s *Store) DueDigests(now time.Time) []*SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*SavedSearch
+	for _, search := range s.searches {
+		if search.digestDue(now) {
+			due = append(due, copySearch(search))
+		}
+	}
+	return due
+}
+
+// MarkDigestSent tar bort de sent första annonserna ur kön och noterar att
+// sammanfattningen skickades vid at. Annonser som tillkommit medan mejlet
+// skickades ligger kvar till nästa sammanfattning.
+func (s *Store) MarkDigestSent(id string, sent int, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok {
+		return
+	}
+
+	if sent > len(search.PendingDigest) {
+		sent = len(search.PendingDigest)
+	}
+	search.PendingDigest = append([]NotificationJob(nil), search.PendingDigest[sent:]...)
+	search.LastDigestAt = &at
+	s.save()
+}
+
+// Unsubscribe stänger av e-postsammanfattningar för alla användarens
+// sökningar och returnerar hur många som påverkades
+func (s *Store) Unsubscribe(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, search := range s.searches {
+		if search.UserID != userID || search.Email == "" {
+			continue
+		}
+		search.Email = ""
+		search.DigestFrequency = ""
+		search.PendingDigest = nil
+		search.UpdatedAt = time.Now()
+		count++
+	}
+	if count > 0 {
+		s.save()
+	}
+	return count
+}
+
+// UnsubscribeToken skapar en signerad token som låter användaren avregistrera
+// sig från mejlen utan att vara inloggad
+func UnsubscribeToken(secret []byte, userID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("unsubscribe:" + userID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidUnsubscribeToken kontrollerar en token från UnsubscribeToken
+func ValidUnsubscribeToken(secret []byte, userID, token string) bool {
+	return hmac.Equal([]byte(UnsubscribeToken(secret, userID)), []byte(token))
+}
+
+// DigestJob är en annons i sammanfattningsmejlet
+type DigestJob struct {
+	Title         string
+	WorkplaceName string
+	PublishedDate string
+	URL           string
+}
+
+// DigestSearch är en sparad sökning med dess nya annonser
+type DigestSearch struct {
+	Name string
+	Jobs []DigestJob
+}
+
+// DigestData är det som skickas till job-alert-digest.html
+type DigestData struct {
+	Searches       []DigestSearch
+	TotalJobs      int
+	Period         string
+	UnsubscribeURL string
+	GeneratedAt    string
+}
+
+// Digester skickar e-postsammanfattningar med nya annonser
+type Digester struct {
+	store    *Store
+	sender   mailer.Sender
+	template *template.Template
+	baseURL  string
+	secret   []byte
+	now      func() time.Time
+}
+
+// NewDigester skapar en Digester. Mallen läses direkt så att en felaktig
+// sökväg märks när servern startar. baseURL används för avregistreringslänken.
+func NewDigester(store *Store, sender mailer.Sender, templatePath, baseURL string, secret []byte) (*Digester, error) {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte läsa mall: %v", err)
+	}
+	return &Digester{
+		store:    store,
+		sender:   sender,
+		template: tmpl,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		secret:   secret,
+		now:      time.Now,
+	}, nil
+}
+
+// SendDue skickar alla sammanfattningar som är på tur. Sökningar med samma
+// användare och mejladress slås ihop till ett mejl.
+func (d *Digester) SendDue(ctx context.Context) {
+	groups := make(map[string][]*SavedSearch)
+	now := d.now()
+	for _, search := range d.store.DueDigests(now) {
+		key := search.UserID + "\x00" + strings.ToLower(search.Email)
+		groups[key] = append(groups[key], search)
+	}
+
+	for _, searches := range groups {
+		if ctx.Err() != nil {
+			return
+		}
+		sort.Slice(searches, func(i, j int) bool { return searches[i].CreatedAt.Before(searches[j].CreatedAt) })
+
+		if err := d.send(ctx, searches); err != nil {
+			log.Printf("❌ Kunde inte skicka sammanfattning till %s: %v", searches[0].Email, err)
+			continue
+		}
+		for _, search := range searches {
+			d.store.MarkDigestSent(search.ID, len(search.PendingDigest), now)
+		}
+	}
+}
+
+func (d *Digester) send(ctx context.Context, searches []*SavedSearch) error {
+	first := searches[0]
+	unsubscribeURL := fmt.Sprintf("%s/api/alerts/unsubscribe?user=%s&token=%s",
+		d.baseURL, url.QueryEscape(first.UserID), UnsubscribeToken(d.secret, first.UserID))
+
+	data := DigestData{
+		Period:         "dagliga",
+		UnsubscribeURL: unsubscribeURL,
+		GeneratedAt:    d.now().Format("2006-01-02"),
+	}
+	if first.DigestFrequency == DigestWeekly {
+		data.Period = "veckovisa"
+	}
+	for _, search := range searches {
+		section := DigestSearch{Name: search.Name}
+		for _, job := range search.PendingDigest {
+			section.Jobs = append(section.Jobs, DigestJob{
+				Title:         job.Title,
+				WorkplaceName: job.WorkplaceName,
+				PublishedDate: job.PublishedDate,
+				URL:           platsbankenAdURL + url.PathEscape(job.ID),
+			})
+		}
+		data.TotalJobs += len(section.Jobs)
+		data.Searches = append(data.Searches, section)
+	}
+
+	var html bytes.Buffer
+	if err := d.template.Execute(&html, data); err != nil {
+		return fmt.Errorf("kunde inte rendera mall: %v", err)
+	}
+
+	subject := fmt.Sprintf("%d nya jobb för dina bevakningar", data.TotalJobs)
+	if len(searches) == 1 {
+		subject = fmt.Sprintf("%d nya jobb för %s", data.TotalJobs, first.Name)
+	}
+
+	return d.sender.Send(ctx, mailer.Message{
+		To:      first.Email,
+		Subject: subject,
+		HTML:    html.String(),
+		Text:    digestText(data),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// digestText bygger textversionen av mejlet för klienter utan HTML
+func digestText(data DigestData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Din %s jobbevakning: %d nya annonser\n\n", data.Period, data.TotalJobs)
+	for _, search := range data.Searches {
+		fmt.Fprintf(&b, "%s\n%s\n", search.Name, strings.Repeat("-", len([]rune(search.Name))))
+		for _, job := range search.Jobs {
+			fmt.Fprintf(&b, "* %s", job.Title)
+			if job.WorkplaceName != "" {
+				fmt.Fprintf(&b, " – %s", job.WorkplaceName)
+			}
+			fmt.Fprintf(&b, "\n  %s\n", job.URL)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Avsluta prenumerationen: %s\n", data.UnsubscribeURL)
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package savedsearch
+
+import (
+	"context"
+	"net/mail"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"awesomeProject/internal/mailer"
+	"awesomeProject/internal/mailer/mailertest"
+)
+
+func newTestDigester(t *testing.T, store *Store, server *mailertest.Server, secret []byte) *Digester {
+	t.Helper()
+	m := mailer.New(mailer.Config{Host: server.Host, Port: server.Port, MaxRetries: 1})
+	d, err := NewDigester(store, m, "../templates/job-alert-digest.html", "https://example.se/", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDigesterSendDue(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	store, _ := NewStore("")
+	daily := store.Create(SavedSearch{UserID: "anna", Name: "Go i Göteborg", Email: "anna@example.se", DigestFrequency: DigestDaily})
+	weekly := store.Create(SavedSearch{UserID: "bertil", Name: "Rust", Email: "bertil@example.se", DigestFrequency: DigestWeekly})
+	store.QueueDigest(daily.ID, []NotificationJob{{ID: "1", Title: "Backendutvecklare"}})
+	store.QueueDigest(weekly.ID, []NotificationJob{{ID: "2", Title: "Systemutvecklare"}})
+
+	d := newTestDigester(t, store, server, []byte("hemlig"))
+	start := daily.CreatedAt
+	steps := []struct {
+		name  string
+		after time.Duration
+		queue string
+		want  []string
+	}{
+		{"för tidigt", time.Hour, "", nil},
+		{"ett dygn", 25 * time.Hour, "", []string{"anna@example.se"}},
+		{"ny annons samma dygn", 26 * time.Hour, daily.ID, nil},
+		{"nästa dygn", 50 * time.Hour, "", []string{"anna@example.se"}},
+		{"en vecka", 8 * 24 * time.Hour, "", []string{"bertil@example.se"}},
+		{"inget nytt", 10 * 24 * time.Hour, "", nil},
+	}
+	sent := 0
+	for _, step := range steps {
+		if step.queue != "" {
+			store.QueueDigest(step.queue, []NotificationJob{{ID: "3", Title: "Plattformsutvecklare"}})
+		}
+		d.now = func() time.Time { return start.Add(step.after) }
+		d.SendDue(context.Background())
+
+		mails := server.Mails()[sent:]
+		sent += len(mails)
+		var got []string
+		for _, m := range mails {
+			got = append(got, m.To...)
+		}
+		if strings.Join(got, ",") != strings.Join(step.want, ",") {
+			t.Errorf("%s: mejl till %v, vill ha %v", step.name, got, step.want)
+		}
+	}
+
+	stored, _ := store.Get("anna", daily.ID)
+	if len(stored.PendingDigest) != 0 {
+		t.Errorf("annonser kvar i kön: %+v", stored.PendingDigest)
+	}
+}
+
+// Avregistreringslänken i mejlet ska gå att verifiera med samma nyckel
+func TestDigestUnsubscribeToken(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	store, _ := NewStore("")
+	search := store.Create(SavedSearch{UserID: "anna b", Name: "Go", Email: "anna@example.se", DigestFrequency: DigestDaily})
+	store.QueueDigest(search.ID, []NotificationJob{{ID: "1", Title: "Backendutvecklare"}})
+
+	secret := []byte("hemlig")
+	d := newTestDigester(t, store, server, secret)
+	d.now = func() time.Time { return search.CreatedAt.Add(25 * time.Hour) }
+	d.SendDue(context.Background())
+
+	mails := server.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("antal mejl = %d, vill ha 1", len(mails))
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(mails[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post := msg.Header.Get("List-Unsubscribe-Post"); post != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", post)
+	}
+	link, err := url.Parse(strings.Trim(msg.Header.Get("List-Unsubscribe"), "<>"))
+	if err != nil || link.Host != "example.se" || link.Path != "/api/alerts/unsubscribe" {
+		t.Fatalf("List-Unsubscribe = %q", msg.Header.Get("List-Unsubscribe"))
+	}
+
+	user, token := link.Query().Get("user"), link.Query().Get("token")
+	if user != "anna b" {
+		t.Errorf("user = %q", user)
+	}
+	if !ValidUnsubscribeToken(secret, user, token) {
+		t.Error("token från mejlet godkänns inte")
+	}
+	if ValidUnsubscribeToken([]byte("annan"), user, token) || ValidUnsubscribeToken(secret, "bertil", token) {
+		t.Error("token godkänns med fel nyckel eller användare")
+	}
+}
+
+func TestNewDigesterMissingTemplate(t *testing.T) {
+	store, _ := NewStore("")
+	if _, err := NewDigester(store, nil, "finns-inte.html", "", nil); err == nil {
+		t.Error("NewDigester godtog en mall som inte finns")
+	}
+}
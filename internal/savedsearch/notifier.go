@@ -0,0 +1,89 @@
+package savedsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// WebhookPayload är det som skickas med POST till en sparad söknings webhook
+type WebhookPayload struct {
+	Event         string            `json:"event"`
+	SavedSearchID string            `json:"savedSearchId"`
+	SearchName    string            `json:"searchName"`
+	UserID        string            `json:"userId"`
+	Jobs          []NotificationJob `json:"jobs"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
+
+// Notifier skapar notiser i flödet och skickar webhooks
+type Notifier struct {
+	store      *Store
+	httpClient *http.Client
+}
+
+// NewNotifier skapar en notifierare som skriver notiser till store
+func NewNotifier(store *Store) *Notifier {
+	return &Notifier{
+		store:      store,
+		httpClient: newWebhookClient(),
+	}
+}
+
+// Notify lägger en notis i användarens flöde, köar annonserna till nästa
+// e-postsammanfattning och anropar webhooken om en sådan finns.
+// Ett misslyckat webhook-anrop loggas men stoppar inte notisen i flödet.
+func (n *Notifier) Notify(ctx context.Context, search *SavedSearch, jobs []NotificationJob) {
+	notification := n.store.AddNotification(Notification{
+		UserID:        search.UserID,
+		SavedSearchID: search.ID,
+		SearchName:    search.Name,
+		Jobs:          jobs,
+	})
+	n.store.QueueDigest(search.ID, jobs)
+
+	if search.WebhookURL == "" {
+		return
+	}
+
+	payload := WebhookPayload{
+		Event:         "new_jobs",
+		SavedSearchID: search.ID,
+		SearchName:    search.Name,
+		UserID:        search.UserID,
+		Jobs:          jobs,
+		CreatedAt:     notification.CreatedAt,
+	}
+	if err := n.postWebhook(ctx, search.WebhookURL, payload); err != nil {
+		log.Printf("⚠️ Webhook för sparad sökning %s misslyckades: %v", search.ID, err)
+	}
+}
+
+func (n *Notifier) postWebhook(ctx context.Context, url string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("kunde inte serialisera webhook: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("kunde inte skapa webhook-förfrågan: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "awesome-cv-saved-search")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook svarade med status %d", resp.StatusCode)
+	}
+	return nil
+}
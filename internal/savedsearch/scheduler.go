@@ -0,0 +1,123 @@
+package savedsearch
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"awesomeProject/internal/platsbanken"
+)
+
+// SearchFunc kör en sparad sökning och returnerar de annonser som matchar och
+// är publicerade efter since (alla om since är noll). Den tillhandahålls av
+// handlers-paketet som äger SearchRequest och fetchAllJobs.
+type SearchFunc func(ctx context.Context, request json.RawMessage, since time.Time) ([]platsbanken.Ad, error)
+
+// Scheduler kör sparade sökningar som är på tur och notifierar om nya annonser
+type Scheduler struct {
+	store    *Store
+	search   SearchFunc
+	notifier *Notifier
+	digester *Digester
+	tick     time.Duration
+	timeout  time.Duration
+}
+
+// NewScheduler skapar en schemaläggare som kontrollerar sökningarna var tick
+func NewScheduler(store *Store, search SearchFunc, notifier *Notifier, tick time.Duration) *Scheduler {
+	if tick <= 0 {
+		tick = time.Minute
+	}
+	return &Scheduler{
+		store:    store,
+		search:   search,
+		notifier: notifier,
+		tick:     tick,
+		timeout:  2 * time.Minute,
+	}
+}
+
+// WithDigester gör att schemaläggaren även skickar e-postsammanfattningar
+func (s *Scheduler) WithDigester(digester *Digester) *Scheduler {
+	s.digester = digester
+	return s
+}
+
+// Start kör schemaläggaren i bakgrunden tills ctx avbryts
+func (s *Scheduler) Start(ctx context.Context) {
+	log.Printf("⏰ Startar bevakning av sparade sökningar (kontroll var %s)", s.tick)
+
+	go func() {
+		ticker := time.NewTicker(s.tick)
+		defer ticker.Stop()
+
+		for {
+			s.RunDue(ctx)
+			if s.digester != nil {
+				s.digester.SendDue(ctx)
+			}
+
+			select {
+			case <-ctx.Done():
+				log.Printf("⏰ Bevakning av sparade sökningar stoppad")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunDue kör alla sökningar som är på tur, en i taget för att inte belasta Platsbanken
+func (s *Scheduler) RunDue(ctx context.Context) {
+	for _, search := range s.store.DueSearches(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		s.run(ctx, search)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, search *SavedSearch) {
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Bara annonser publicerade sedan förra körningen hämtas. Annars avgör
+	// relevansordningen vilka annonser som får plats, och äldre annonser
+	// som hamnar högre upp skulle räknas som nya.
+	ads, err := s.search(runCtx, search.Request, search.PublishedSince())
+	if err != nil {
+		log.Printf("❌ Sparad sökning %s (%s) misslyckades: %v", search.ID, search.Name, err)
+	}
+
+	jobIDs := make([]string, 0, len(ads))
+	adsByID := make(map[string]platsbanken.Ad, len(ads))
+	for _, ad := range ads {
+		jobIDs = append(jobIDs, ad.ID)
+		adsByID[ad.ID] = ad
+	}
+
+	newIDs, recordErr := s.store.RecordRun(search.ID, jobIDs, err)
+	if recordErr != nil {
+		// Sökningen togs bort medan den kördes
+		return
+	}
+	if len(newIDs) == 0 {
+		return
+	}
+
+	log.Printf("🔔 Sparad sökning %s (%s) hittade %d nya annonser", search.ID, search.Name, len(newIDs))
+
+	jobs := make([]NotificationJob, 0, len(newIDs))
+	for _, id := range newIDs {
+		ad := adsByID[id]
+		jobs = append(jobs, NotificationJob{
+			ID:            ad.ID,
+			Title:         ad.Title,
+			WorkplaceName: ad.WorkplaceName,
+			PublishedDate: ad.PublishedDate,
+		})
+	}
+
+	s.notifier.Notify(ctx, search, jobs)
+}
@@ -0,0 +1,426 @@
+// Package savedsearch lagrar användares sparade sökningar, kör dem igen med
+// jämna mellanrum och skapar notiser när nya annonser dyker upp.
+package savedsearch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSeenJobs begränsar hur många annons-ID:n vi kommer ihåg per sökning
+const maxSeenJobs = 5000
+
+// publishedOverlap är hur långt före den senaste lyckade körningen nästa
+// körning börjar leta. Annonser kan dyka upp i sökningen en stund efter sitt
+// publiceringsdatum, och de som redan setts filtreras bort med SeenJobIDs.
+const publishedOverlap = time.Hour
+
+// maxPendingDigestJobs begränsar hur många annonser som väntar på nästa sammanfattning
+const maxPendingDigestJobs = 500
+
+// maxNotificationsPerUser begränsar notisflödet så att filen inte växer obegränsat
+const maxNotificationsPerUser = 200
+
+var ErrNotFound = errors.New("sparad sökning hittades inte")
+
+// SavedSearch är en sökning som en användare vill bevaka
+type SavedSearch struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"userId"`
+	Name            string          `json:"name"`
+	Request         json.RawMessage `json:"request"`
+	WebhookURL      string          `json:"webhookUrl,omitempty"`
+	IntervalMinutes int             `json:"intervalMinutes"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+	LastRunAt       *time.Time      `json:"lastRunAt,omitempty"`
+	LastError       string          `json:"lastError,omitempty"`
+	// BaselineAt är när den första lyckade körningen gjordes. Annonser som
+	// fanns då räknas inte som nya.
+	BaselineAt *time.Time `json:"baselineAt,omitempty"`
+	// LastSuccessAt är när den senaste lyckade körningen gjordes
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	SeenJobIDs    []string   `json:"seenJobIds,omitempty"`
+
+	// E-postsammanfattning, avstängd om Email eller DigestFrequency saknas
+	Email           string            `json:"email,omitempty"`
+	DigestFrequency string            `json:"digestFrequency,omitempty"`
+	PendingDigest   []NotificationJob `json:"pendingDigest,omitempty"`
+	LastDigestAt    *time.Time        `json:"lastDigestAt,omitempty"`
+}
+
+// Due anger om sökningen ska köras igen
+func (s *SavedSearch) Due(now time.Time) bool {
+	if s.LastRunAt == nil {
+		return true
+	}
+	return now.Sub(*s.LastRunAt) >= time.Duration(s.IntervalMinutes)*time.Minute
+}
+
+// PublishedSince är den publiceringstid som nästa körning hämtar annonser
+// från. Före baslinjen är den noll, då hämtas alla annonser som matchar.
+func (s *SavedSearch) PublishedSince() time.Time {
+	since := s.LastSuccessAt
+	if since == nil {
+		since = s.BaselineAt
+	}
+	if since == nil {
+		return time.Time{}
+	}
+	return since.Add(-publishedOverlap)
+}
+
+// NotificationJob är en kort beskrivning av en ny annons i en notis
+type NotificationJob struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	WorkplaceName string `json:"workplaceName,omitempty"`
+	PublishedDate string `json:"publishedDate,omitempty"`
+}
+
+// Notification skapas när en sparad sökning hittar nya annonser
+type Notification struct {
+	ID            string            `json:"id"`
+	UserID        string            `json:"userId"`
+	SavedSearchID string            `json:"savedSearchId"`
+	SearchName    string            `json:"searchName"`
+	Jobs          []NotificationJob `json:"jobs"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	Read          bool              `json:"read"`
+}
+
+// Store håller sparade sökningar och notiser i minnet och skriver dem till
+// en JSON-fil vid varje ändring
+type Store struct {
+	mu            sync.Mutex
+	path          string
+	searches      map[string]*SavedSearch
+	notifications []*Notification
+}
+
+type storeFile struct {
+	Searches      []*SavedSearch  `json:"searches"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+// NewStore läser in befintliga data från path. Om path är tom hålls allt
+// bara i minnet.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:     path,
+		searches: make(map[string]*SavedSearch),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kunde inte läsa %s: %v", path, err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("kunde inte tolka %s: %v", path, err)
+	}
+	for _, search := range file.Searches {
+		// Filer från före BaselineAt: sedda annonser finns bara efter en
+		// lyckad körning
+		if search.BaselineAt == nil && len(search.SeenJobIDs) > 0 {
+			search.BaselineAt = search.LastRunAt
+		}
+		s.searches[search.ID] = search
+	}
+	s.notifications = file.Notifications
+
+	log.Printf("💾 Läste in %d sparade sökningar och %d notiser från %s", len(s.searches), len(s.notifications), path)
+	return s, nil
+}
+
+// save skriver allt till disk. Anroparen måste hålla s.mu.
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+
+	file := storeFile{Notifications: s.notifications}
+	for _, search := range s.searches {
+		file.Searches = append(file.Searches, search)
+	}
+	sort.Slice(file.Searches, func(i, j int) bool { return file.Searches[i].CreatedAt.Before(file.Searches[j].CreatedAt) })
+
+	b, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		log.Printf("⚠️ Kunde inte serialisera sparade sökningar: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		log.Printf("⚠️ Kunde inte skapa katalog för %s: %v", s.path, err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		log.Printf("⚠️ Kunde inte skriva %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Printf("⚠️ Kunde inte spara %s: %v", s.path, err)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// copySearch returnerar en kopia så att anroparen inte kan ändra lagrad data
+func copySearch(s *SavedSearch) *SavedSearch {
+	c := *s
+	c.SeenJobIDs = append([]string(nil), s.SeenJobIDs...)
+	c.PendingDigest = append([]NotificationJob(nil), s.PendingDigest...)
+	return &c
+}
+
+// Create sparar en ny sökning och sätter ID och tidsstämplar
+func (s *Store) Create(search SavedSearch) *SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	search.ID = newID()
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	search.LastRunAt = nil
+	search.BaselineAt = nil
+	search.LastSuccessAt = nil
+	search.SeenJobIDs = nil
+	search.PendingDigest = nil
+	search.LastDigestAt = nil
+	s.searches[search.ID] = &search
+	s.save()
+
+	return copySearch(&search)
+}
+
+// Get hämtar en sökning som tillhör användaren
+func (s *Store) Get(userID, id string) (*SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok || search.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return copySearch(search), nil
+}
+
+// List returnerar användarens sökningar, äldst först
+func (s *Store) List(userID string) []*SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []*SavedSearch{}
+	for _, search := range s.searches {
+		if search.UserID == userID {
+			result = append(result, copySearch(search))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result
+}
+
+// Update ersätter namn, sökning, webhook, intervall och e-postinställningar.
+// Om själva sökningen ändras nollställs mängden sedda annonser så att nästa
+// körning blir en ny baslinje.
+func (s *Store) Update(userID, id string, update SavedSearch) (*SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok || search.UserID != userID {
+		return nil, ErrNotFound
+	}
+
+	if string(search.Request) != string(update.Request) {
+		search.SeenJobIDs = nil
+		search.LastRunAt = nil
+		search.BaselineAt = nil
+		search.LastSuccessAt = nil
+	}
+	search.Name = update.Name
+	search.Request = update.Request
+	search.WebhookURL = update.WebhookURL
+	search.IntervalMinutes = update.IntervalMinutes
+	if update.Email == "" || update.DigestFrequency == "" {
+		search.PendingDigest = nil
+	}
+	search.Email = update.Email
+	search.DigestFrequency = update.DigestFrequency
+	search.UpdatedAt = time.Now()
+	s.save()
+
+	return copySearch(search), nil
+}
+
+// Delete tar bort en sökning och dess notiser
+func (s *Store) Delete(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok || search.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.searches, id)
+
+	kept := s.notifications[:0]
+	for _, n := range s.notifications {
+		if n.SavedSearchID != id {
+			kept = append(kept, n)
+		}
+	}
+	s.notifications = kept
+	s.save()
+
+	return nil
+}
+
+// DueSearches returnerar kopior av alla sökningar som ska köras nu
+func (s *Store) DueSearches(now time.Time) []*SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*SavedSearch
+	for _, search := range s.searches {
+		if search.Due(now) {
+			due = append(due, copySearch(search))
+		}
+	}
+	return due
+}
+
+// RecordRun sparar resultatet av en körning och returnerar de annons-ID:n
+// som inte setts tidigare. Första lyckade körningen blir en baslinje och ger
+// inga nya annonser, annars skulle användaren få en notis om hela
+// träfflistan. Misslyckade körningar påverkar inte baslinjen.
+func (s *Store) RecordRun(id string, jobIDs []string, runErr error) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	search.LastRunAt = &now
+
+	if runErr != nil {
+		search.LastError = runErr.Error()
+		s.save()
+		return nil, nil
+	}
+	search.LastError = ""
+	search.LastSuccessAt = &now
+	firstRun := search.BaselineAt == nil
+	if firstRun {
+		search.BaselineAt = &now
+	}
+
+	seen := make(map[string]bool, len(search.SeenJobIDs))
+	for _, jobID := range search.SeenJobIDs {
+		seen[jobID] = true
+	}
+
+	var newIDs []string
+	for _, jobID := range jobIDs {
+		if !seen[jobID] {
+			seen[jobID] = true
+			newIDs = append(newIDs, jobID)
+			search.SeenJobIDs = append(search.SeenJobIDs, jobID)
+		}
+	}
+	if len(search.SeenJobIDs) > maxSeenJobs {
+		search.SeenJobIDs = search.SeenJobIDs[len(search.SeenJobIDs)-maxSeenJobs:]
+	}
+	s.save()
+
+	if firstRun {
+		return nil, nil
+	}
+	return newIDs, nil
+}
+
+// AddNotification lägger till en notis i användarens flöde
+func (s *Store) AddNotification(n Notification) *Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n.ID = newID()
+	n.CreatedAt = time.Now()
+	s.notifications = append(s.notifications, &n)
+
+	// Släng de äldsta notiserna om användaren har för många
+	count := 0
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		if s.notifications[i].UserID != n.UserID {
+			continue
+		}
+		count++
+		if count > maxNotificationsPerUser {
+			s.notifications = append(s.notifications[:i], s.notifications[i+1:]...)
+		}
+	}
+	s.save()
+
+	c := n
+	return &c
+}
+
+// Notifications returnerar användarens notiser, nyast först
+func (s *Store) Notifications(userID string, unreadOnly bool) []Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []Notification{}
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		n := s.notifications[i]
+		if n.UserID != userID || (unreadOnly && n.Read) {
+			continue
+		}
+		result = append(result, *n)
+	}
+	return result
+}
+
+// MarkRead markerar en notis som läst
+func (s *Store) MarkRead(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.notifications {
+		if n.ID == id && n.UserID == userID {
+			n.Read = true
+			s.save()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
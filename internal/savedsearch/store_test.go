@@ -0,0 +1,79 @@
+package savedsearch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRecordRunBaseline(t *testing.T) {
+	store, _ := NewStore("")
+	search := store.Create(SavedSearch{UserID: "anna", Name: "Go", IntervalMinutes: 60})
+
+	steps := []struct {
+		name   string
+		jobIDs []string
+		err    error
+		want   []string
+	}{
+		// En misslyckad första körning får inte räknas som baslinje
+		{"misslyckad", nil, errors.New("Platsbanken svarar inte"), nil},
+		{"baslinje", []string{"1", "2", "3"}, nil, nil},
+		{"ny annons", []string{"1", "2", "3", "4"}, nil, []string{"4"}},
+		{"misslyckad igen", nil, errors.New("timeout"), nil},
+		{"efter fel", []string{"2", "4", "5"}, nil, []string{"5"}},
+	}
+	for _, step := range steps {
+		got, err := store.RecordRun(search.ID, step.jobIDs, step.err)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: nya annonser = %v, vill ha %v", step.name, got, step.want)
+		}
+	}
+
+	stored, _ := store.Get("anna", search.ID)
+	if stored.BaselineAt == nil || stored.LastError != "" {
+		t.Errorf("sökningen = %+v", stored)
+	}
+}
+
+func TestRecordRunNewRequestResetsBaseline(t *testing.T) {
+	store, _ := NewStore("")
+	search := store.Create(SavedSearch{UserID: "anna", Request: []byte(`{"q":"go"}`), IntervalMinutes: 60})
+	store.RecordRun(search.ID, []string{"1"}, nil)
+
+	update := *search
+	update.Request = []byte(`{"q":"rust"}`)
+	if _, err := store.Update("anna", search.ID, update); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.RecordRun(search.ID, []string{"1", "2"}, nil); got != nil {
+		t.Errorf("första körningen efter ändrad sökning gav %v", got)
+	}
+}
+
+func TestPublishedSince(t *testing.T) {
+	store, _ := NewStore("")
+	search := store.Create(SavedSearch{UserID: "anna", Name: "Go", IntervalMinutes: 60})
+
+	// Före baslinjen hämtas alla annonser
+	if since := search.PublishedSince(); !since.IsZero() {
+		t.Errorf("före baslinjen: %s", since)
+	}
+
+	store.RecordRun(search.ID, []string{"1"}, nil)
+	stored, _ := store.Get("anna", search.ID)
+	want := stored.LastSuccessAt.Add(-publishedOverlap)
+	if since := stored.PublishedSince(); !since.Equal(want) {
+		t.Errorf("efter lyckad körning: %s, vill ha %s", since, want)
+	}
+
+	// En misslyckad körning flyttar inte fram gränsen
+	store.RecordRun(search.ID, nil, errors.New("timeout"))
+	stored, _ = store.Get("anna", search.ID)
+	if since := stored.PublishedSince(); !since.Equal(want) {
+		t.Errorf("efter misslyckad körning: %s, vill ha %s", since, want)
+	}
+}
@@ -0,0 +1,86 @@
+package savedsearch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Webhooks anropas från servern, så adresser i det interna nätet får inte
+// användas. Kontrollen görs både när sökningen sparas och när anslutningen
+// öppnas, så att en DNS-post som byts efter kontrollen inte tar sig förbi.
+
+// blockedIP anger om ip är en adress som en webhook inte får anropa
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast()
+}
+
+// ValidateWebhookURL kontrollerar att raw är en http(s)-adress vars värd
+// bara pekar på publika adresser
+func ValidateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("webhookUrl måste vara en http- eller https-adress")
+	}
+
+	host := u.Hostname()
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return fmt.Errorf("webhookUrl: värden %s gick inte att slå upp", host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if blockedIP(ip) {
+			return fmt.Errorf("webhookUrl får inte peka på en intern adress (%s)", ip)
+		}
+	}
+	return nil
+}
+
+// dialControl stoppar anslutningar till interna adresser efter att namnet
+// slagits upp, även efter omdirigeringar
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+		return fmt.Errorf("webhooken får inte anropa den interna adressen %s", host)
+	}
+	return nil
+}
+
+// newWebhookClient skapar en HTTP-klient som bara ansluter till publika
+// adresser. Proxy från miljön används inte, eftersom kontrollen då bara
+// skulle gälla proxyn.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialControl,
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
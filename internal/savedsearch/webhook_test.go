@@ -0,0 +1,57 @@
+package savedsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]/hook", true},
+		{"ftp://93.184.216.34/hook", false},
+		{"https:///hook", false},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://localhost/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://10.1.2.3/hook", false},
+		{"http://172.16.0.1/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateWebhookURL(context.Background(), tt.url); (err == nil) != tt.ok {
+			t.Errorf("ValidateWebhookURL(%q) = %v, vill ha ok=%v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+// Även en adress som passerat kontrollen stoppas när anslutningen öppnas,
+// så att DNS-poster som byts efteråt inte når det interna nätet
+func TestWebhookClientRefusesInternalAddress(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	store, _ := NewStore("")
+	notifier := NewNotifier(store)
+	err := notifier.postWebhook(context.Background(), server.URL, WebhookPayload{Event: "new_jobs"})
+	if err == nil || !strings.Contains(err.Error(), "interna adressen") {
+		t.Errorf("fel = %v, vill ha stoppad anslutning", err)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Error("webhooken anropades")
+	}
+}
@@ -0,0 +1,326 @@
+// Package usage bokför varje AI-anrop (tokens, svarstid, leverantör och
+// funktion) och håller koll på dagliga kvoter per användare och totalt.
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics för AI-förbrukningen. Antal anrop och svarstider per leverantör
+// finns redan i llm-paketets mätvärden.
+var (
+	tokensUsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_usage_tokens_total",
+		Help: "Förbrukade tokens per leverantör, funktion och typ (prompt eller completion)",
+	}, []string{"provider", "feature", "type"})
+	quotaRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_quota_rejections_total",
+		Help: "Antal förfrågningar som nekats för att en dagskvot var förbrukad, per kvot (user, ip eller global)",
+	}, []string{"scope"})
+)
+
+func init() {
+	prometheus.MustRegister(tokensUsed, quotaRejections)
+}
+
+// Record är ett AI-anrop i liggaren
+type Record struct {
+	Time             time.Time `json:"time"`
+	UserID           string    `json:"userId,omitempty"`
+	ClientIP         string    `json:"clientIp,omitempty"`
+	Feature          string    `json:"feature"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model,omitempty"`
+	PromptVersion    string    `json:"promptVersion,omitempty"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	LatencyMs        int64     `json:"latencyMs"`
+	Error            bool      `json:"error,omitempty"`
+}
+
+// Tokens är summan av prompt- och completion-tokens
+func (r Record) Tokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
+
+// Config styr liggaren och kvoterna
+type Config struct {
+	// Path är filen som anropen skrivs till, en JSON-rad per anrop. Tom
+	// betyder att liggaren bara finns i minnet.
+	Path string
+	// Retention är hur länge anropen sparas för rapporter
+	Retention time.Duration
+
+	Quota QuotaConfig
+}
+
+// ConfigFromEnv läser AI_USAGE_FILE, AI_USAGE_RETENTION och kvoterna
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Path:      os.Getenv("AI_USAGE_FILE"),
+		Retention: 30 * 24 * time.Hour,
+		Quota:     QuotaConfigFromEnv(),
+	}
+	if cfg.Path == "" {
+		cfg.Path = "data/ai_usage.jsonl"
+	} else if cfg.Path == "none" {
+		cfg.Path = ""
+	}
+	if val := os.Getenv("AI_USAGE_RETENTION"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.Retention = d
+		}
+	}
+	return cfg
+}
+
+// dayTotals är en användares, en IP-adress eller hela tjänstens förbrukning
+// under en dag
+type dayTotals struct {
+	Requests int
+	Tokens   int
+}
+
+// Ledger håller anropen i minnet och lägger till dem i en fil så att
+// förbrukningen överlever omstarter
+type Ledger struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	records []Record
+	day     string
+	users   map[string]*dayTotals
+	clients map[string]*dayTotals
+	global  dayTotals
+	// reserved är förfrågningar som har passerat Reserve men inte är klara
+	reserved map[string]int
+}
+
+// NewLedger skapar en liggare och läser in de anrop som finns i cfg.Path
+func NewLedger(cfg Config) (*Ledger, error) {
+	l := &Ledger{
+		cfg:      cfg,
+		now:      time.Now,
+		users:    make(map[string]*dayTotals),
+		clients:  make(map[string]*dayTotals),
+		reserved: make(map[string]int),
+	}
+	if cfg.Path == "" {
+		return l, nil
+	}
+
+	f, err := os.Open(cfg.Path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, fmt.Errorf("kunde inte läsa %s: %v", cfg.Path, err)
+	}
+	defer f.Close()
+
+	cutoff := l.now().Add(-cfg.Retention)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("⚠️ Hoppar över trasig rad i %s: %v", cfg.Path, err)
+			continue
+		}
+		if record.Time.After(cutoff) {
+			l.add(record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return l, fmt.Errorf("kunde inte läsa %s: %v", cfg.Path, err)
+	}
+
+	log.Printf("📒 Läste in %d AI-anrop från %s", len(l.records), cfg.Path)
+	return l, nil
+}
+
+// Record bokför ett anrop
+func (l *Ledger) Record(record Record) {
+	if record.Time.IsZero() {
+		record.Time = l.now()
+	}
+
+	tokensUsed.WithLabelValues(record.Provider, record.Feature, "prompt").Add(float64(record.PromptTokens))
+	tokensUsed.WithLabelValues(record.Provider, record.Feature, "completion").Add(float64(record.CompletionTokens))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.add(record)
+	l.prune()
+	l.append(record)
+}
+
+// add lägger till anropet i minnet. Anroparen måste hålla l.mu.
+func (l *Ledger) add(record Record) {
+	l.records = append(l.records, record)
+
+	day := dayKey(record.Time)
+	if day != l.day {
+		if day < l.day {
+			// Äldre än dagens räknare, påverkar bara rapporterna
+			return
+		}
+		l.day = day
+		l.users = make(map[string]*dayTotals)
+		l.clients = make(map[string]*dayTotals)
+		l.global = dayTotals{}
+	}
+
+	addTotals(l.users, record.UserID, record)
+	if record.ClientIP != "" {
+		addTotals(l.clients, record.ClientIP, record)
+	}
+	l.global.Requests++
+	l.global.Tokens += record.Tokens()
+}
+
+func addTotals(totals map[string]*dayTotals, key string, record Record) {
+	t := totals[key]
+	if t == nil {
+		t = &dayTotals{}
+		totals[key] = t
+	}
+	t.Requests++
+	t.Tokens += record.Tokens()
+}
+
+// prune glömmer anrop äldre än Retention. Filen skrivs inte om; gamla rader
+// hoppas över vid nästa start. Anroparen måste hålla l.mu.
+func (l *Ledger) prune() {
+	if l.cfg.Retention <= 0 || len(l.records) == 0 {
+		return
+	}
+	cutoff := l.now().Add(-l.cfg.Retention)
+	i := 0
+	for i < len(l.records) && l.records[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		l.records = append([]Record(nil), l.records[i:]...)
+	}
+}
+
+// append skriver anropet sist i filen. Anroparen måste hålla l.mu.
+func (l *Ledger) append(record Record) {
+	if l.cfg.Path == "" {
+		return
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("⚠️ Kunde inte serialisera AI-anrop: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(l.cfg.Path), 0o755); err != nil {
+		log.Printf("⚠️ Kunde inte skapa katalog för %s: %v", l.cfg.Path, err)
+		return
+	}
+	f, err := os.OpenFile(l.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("⚠️ Kunde inte öppna %s: %v", l.cfg.Path, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		log.Printf("⚠️ Kunde inte skriva till %s: %v", l.cfg.Path, err)
+	}
+}
+
+// Records returnerar anropen mellan from och to
+func (l *Ledger) Records(from, to time.Time) []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var records []Record
+	for _, record := range l.records {
+		if !record.Time.Before(from) && record.Time.Before(to) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// dayKey är dagens datum i lokal tid; kvoterna nollställs vid midnatt
+func dayKey(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+type userKey struct{}
+
+// WithUser returnerar en kontext där AI-anropen bokförs på userID
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFrom läser användaren från kontexten
+func UserFrom(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey{}).(string)
+	return userID
+}
+
+type clientKey struct{}
+
+// WithClient returnerar en kontext där AI-anropen också bokförs på klientens
+// IP-adress
+func WithClient(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientKey{}, clientIP)
+}
+
+// ClientFrom läser klientens IP-adress från kontexten
+func ClientFrom(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientKey{}).(string)
+	return clientIP
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultLedger *Ledger
+)
+
+// Default är liggaren som AI-anropen bokförs i, konfigurerad från miljön
+func Default() *Ledger {
+	defaultOnce.Do(func() {
+		cfg := ConfigFromEnv()
+		ledger, err := NewLedger(cfg)
+		if err != nil {
+			log.Printf("⚠️ %v, bokför bara i minnet", err)
+			cfg.Path = ""
+			ledger, _ = NewLedger(cfg)
+		}
+		defaultLedger = ledger
+	})
+	return defaultLedger
+}
+
+// SetDefault ersätter standardliggaren, t.ex. i tester
+func SetDefault(ledger *Ledger) {
+	defaultOnce.Do(func() {})
+	defaultLedger = ledger
+}
+
+// envInt läser ett icke-negativt heltal från miljön, 0 om det saknas
+func envInt(name string) int {
+	if val := os.Getenv(name); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("⚠️ Ogiltigt värde för %s: %q", name, val)
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerQuota(t *testing.T) {
+	ledger, err := NewLedger(Config{Quota: QuotaConfig{UserDailyRequests: 2, GlobalDailyTokens: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.Record(Record{UserID: "anna", Feature: "cv", PromptTokens: 10, CompletionTokens: 5})
+	if err := ledger.Check("anna", ""); err != nil {
+		t.Fatalf("första anropet ska inte nå kvoten: %v", err)
+	}
+
+	ledger.Record(Record{UserID: "anna", Feature: "cv", PromptTokens: 10, CompletionTokens: 5})
+	var quotaErr *QuotaError
+	if err := ledger.Check("anna", ""); !errors.As(err, &quotaErr) || quotaErr.Scope != "user" || quotaErr.Used != 2 {
+		t.Fatalf("Check(anna) = %v, vill ha användarkvot med 2 använda", err)
+	}
+	if err := ledger.Check("bertil", ""); err != nil {
+		t.Fatalf("en annan användare ska inte påverkas: %v", err)
+	}
+
+	ledger.Record(Record{UserID: "bertil", Feature: "cv", PromptTokens: 60, CompletionTokens: 20})
+	if err := ledger.Check("cecilia", ""); !errors.As(err, &quotaErr) || quotaErr.Scope != "global" || quotaErr.Unit != "tokens" {
+		t.Fatalf("Check(cecilia) = %v, vill ha global tokenkvot", err)
+	}
+
+	// Gårdagens anrop räknas inte mot dagens kvot
+	ledger.now = func() time.Time { return time.Now().AddDate(0, 0, 1) }
+	if err := ledger.Check("anna", ""); err != nil {
+		t.Fatalf("kvoten ska nollställas nästa dag: %v", err)
+	}
+}
+
+// Ett nytt X-User-ID för varje förfrågan ska inte ge en ny kvot
+func TestLedgerQuotaPerIP(t *testing.T) {
+	ledger, _ := NewLedger(Config{Quota: QuotaConfig{UserDailyRequests: 5, IPDailyRequests: 2}})
+
+	ledger.Record(Record{UserID: "slump-1", ClientIP: "192.0.2.1", Feature: "cv"})
+	ledger.Record(Record{UserID: "slump-2", ClientIP: "192.0.2.1", Feature: "cv"})
+
+	var quotaErr *QuotaError
+	if err := ledger.Check("slump-3", "192.0.2.1"); !errors.As(err, &quotaErr) || quotaErr.Scope != "ip" || quotaErr.Used != 2 {
+		t.Fatalf("Check = %v, vill ha IP-kvot med 2 använda", err)
+	}
+	if err := ledger.Check("slump-3", "192.0.2.2"); err != nil {
+		t.Fatalf("en annan IP-adress ska inte påverkas: %v", err)
+	}
+}
+
+// Parallella förfrågningar får inte alla passera innan någon har bokförts
+func TestLedgerReserve(t *testing.T) {
+	ledger, _ := NewLedger(Config{Quota: QuotaConfig{UserDailyRequests: 2}})
+
+	first, err := ledger.Reserve("anna", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ledger.Reserve("anna", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Reserve("anna", "192.0.2.1"); err == nil {
+		t.Fatal("tredje samtidiga förfrågan passerade kvoten")
+	}
+
+	// En förfrågan som blev klar utan att anropa AI:n lämnar tillbaka platsen
+	first()
+	first()
+	third, err := ledger.Reserve("anna", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("platsen lämnades inte tillbaka: %v", err)
+	}
+
+	// Bokförda anrop räknas i stället för reservationen när den släpps
+	ledger.Record(Record{UserID: "anna", ClientIP: "192.0.2.1", Feature: "cv"})
+	second()
+	third()
+	if _, err := ledger.Reserve("anna", "192.0.2.1"); err != nil {
+		t.Fatalf("ett bokfört anrop och en ledig plats: %v", err)
+	}
+	ledger.Record(Record{UserID: "anna", ClientIP: "192.0.2.1", Feature: "cv"})
+	if err := ledger.Check("anna", ""); err == nil {
+		t.Error("två bokförda anrop ska nå kvoten")
+	}
+}
+
+func TestLedgerReport(t *testing.T) {
+	ledger, _ := NewLedger(Config{})
+	now := time.Now()
+	ledger.Record(Record{Time: now, UserID: "anna", Feature: "cv", Provider: "openai", PromptTokens: 10, CompletionTokens: 5, LatencyMs: 100})
+	ledger.Record(Record{Time: now, UserID: "anna", Feature: "search_analysis", Provider: "gemini", PromptTokens: 4, CompletionTokens: 2, LatencyMs: 300})
+	ledger.Record(Record{Time: now, Feature: "cv", Provider: "openai", LatencyMs: 50, Error: true})
+
+	report := ledger.Report(now.Add(-time.Hour), now.Add(time.Hour), "")
+	if report.Totals.Requests != 3 || report.Totals.Errors != 1 || report.Totals.TotalTokens != 21 || report.Totals.AvgLatencyMs != 150 {
+		t.Errorf("totals = %+v", report.Totals)
+	}
+	if cv := report.ByFeature["cv"]; cv == nil || cv.Requests != 2 || cv.TotalTokens != 15 {
+		t.Errorf("byFeature[cv] = %+v", cv)
+	}
+	if unknown := report.ByUser["okänd"]; unknown == nil || unknown.Requests != 1 {
+		t.Errorf("anrop utan användare ska hamna under okänd: %+v", report.ByUser)
+	}
+
+	report = ledger.Report(now.Add(-time.Hour), now.Add(time.Hour), "anna")
+	if report.Totals.Requests != 2 || len(report.ByProvider) != 2 {
+		t.Errorf("rapport för anna = %+v", report)
+	}
+}
+
+func TestLedgerReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	cfg := Config{Path: path, Retention: 24 * time.Hour, Quota: QuotaConfig{UserDailyRequests: 1}}
+
+	ledger, err := NewLedger(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.Record(Record{Time: time.Now().Add(-48 * time.Hour), UserID: "anna", Feature: "cv"})
+	ledger.Record(Record{UserID: "anna", Feature: "cv", PromptTokens: 3})
+
+	// Efter en omstart ska dagens anrop fortfarande räknas, men inte de för gamla
+	ledger, err = NewLedger(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := ledger.Records(time.Time{}, time.Now().Add(time.Hour)); len(records) != 1 || records[0].PromptTokens != 3 {
+		t.Fatalf("inlästa anrop = %+v", records)
+	}
+	if err := ledger.Check("anna", ""); err == nil {
+		t.Fatal("kvoten ska gälla även efter omstart")
+	}
+}
@@ -0,0 +1,171 @@
+package usage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuotaConfig är de dagliga kvoterna. 0 betyder ingen gräns.
+type QuotaConfig struct {
+	UserDailyRequests int `json:"userDailyRequests"`
+	UserDailyTokens   int `json:"userDailyTokens"`
+	// IP-kvoterna gäller alla anrop från samma IP-adress oavsett användare,
+	// eftersom X-User-ID sätts av klienten och kan bytas för varje förfrågan
+	IPDailyRequests     int `json:"ipDailyRequests"`
+	IPDailyTokens       int `json:"ipDailyTokens"`
+	GlobalDailyRequests int `json:"globalDailyRequests"`
+	GlobalDailyTokens   int `json:"globalDailyTokens"`
+}
+
+// QuotaConfigFromEnv läser AI_QUOTA_USER_DAILY_REQUESTS, AI_QUOTA_USER_DAILY_TOKENS,
+// AI_QUOTA_IP_DAILY_REQUESTS, AI_QUOTA_IP_DAILY_TOKENS, AI_QUOTA_GLOBAL_DAILY_REQUESTS
+// och AI_QUOTA_GLOBAL_DAILY_TOKENS. IP-kvoterna är som användarkvoterna om
+// de inte är satta.
+func QuotaConfigFromEnv() QuotaConfig {
+	cfg := QuotaConfig{
+		UserDailyRequests:   envInt("AI_QUOTA_USER_DAILY_REQUESTS"),
+		UserDailyTokens:     envInt("AI_QUOTA_USER_DAILY_TOKENS"),
+		IPDailyRequests:     envInt("AI_QUOTA_IP_DAILY_REQUESTS"),
+		IPDailyTokens:       envInt("AI_QUOTA_IP_DAILY_TOKENS"),
+		GlobalDailyRequests: envInt("AI_QUOTA_GLOBAL_DAILY_REQUESTS"),
+		GlobalDailyTokens:   envInt("AI_QUOTA_GLOBAL_DAILY_TOKENS"),
+	}
+	if cfg.IPDailyRequests == 0 {
+		cfg.IPDailyRequests = cfg.UserDailyRequests
+	}
+	if cfg.IPDailyTokens == 0 {
+		cfg.IPDailyTokens = cfg.UserDailyTokens
+	}
+	return cfg
+}
+
+// QuotaError returneras av Check när en kvot är förbrukad
+type QuotaError struct {
+	// Scope är "user", "ip" eller "global"
+	Scope string `json:"scope"`
+	// Unit är "requests" eller "tokens"
+	Unit    string    `json:"unit"`
+	Limit   int       `json:"limit"`
+	Used    int       `json:"used"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+func (e *QuotaError) Error() string {
+	unit := "AI-anrop"
+	if e.Unit == "tokens" {
+		unit = "tokens"
+	}
+	switch e.Scope {
+	case "global":
+		return fmt.Sprintf("tjänstens dagskvot på %d %s är förbrukad, försök igen efter %s", e.Limit, unit, e.ResetAt.Format("2006-01-02 15:04"))
+	case "ip":
+		return fmt.Sprintf("dagskvoten på %d %s från din IP-adress är förbrukad (%d använda), försök igen efter %s", e.Limit, unit, e.Used, e.ResetAt.Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("din dagskvot på %d %s är förbrukad (%d använda), försök igen efter %s", e.Limit, unit, e.Used, e.ResetAt.Format("2006-01-02 15:04"))
+}
+
+type quotaCheck struct {
+	scope, unit string
+	limit, used int
+}
+
+// Check kontrollerar om userID och clientIP får göra fler AI-anrop i dag.
+// Tomma värden kontrolleras inte mot sina kvoter.
+func (l *Ledger) Check(userID, clientIP string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.check(userID, clientIP)
+}
+
+// Reserve kontrollerar kvoterna som Check och räknar sedan förfrågan som ett
+// anrop tills release anropas, så att parallella förfrågningar inte alla
+// hinner passera kontrollen innan den första har bokförts. Tokenkvoterna
+// kan ändå överskridas med det som pågående förfrågningar förbrukar,
+// eftersom antalet tokens inte är känt i förväg.
+func (l *Ledger) Reserve(userID, clientIP string) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.check(userID, clientIP); err != nil {
+		return nil, err
+	}
+
+	keys := reservationKeys(userID, clientIP)
+	for _, key := range keys {
+		l.reserved[key]++
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			for _, key := range keys {
+				if l.reserved[key]--; l.reserved[key] <= 0 {
+					delete(l.reserved, key)
+				}
+			}
+		})
+	}, nil
+}
+
+// reservationKeys är nycklarna i Ledger.reserved för en förfrågan
+func reservationKeys(userID, clientIP string) []string {
+	keys := []string{"global"}
+	if userID != "" {
+		keys = append(keys, "user:"+userID)
+	}
+	if clientIP != "" {
+		keys = append(keys, "ip:"+clientIP)
+	}
+	return keys
+}
+
+// check gör kontrollen för Check och Reserve. Anroparen måste hålla l.mu.
+func (l *Ledger) check(userID, clientIP string) error {
+	quota := l.cfg.Quota
+	now := l.now()
+
+	var user, client, global dayTotals
+	if l.day == dayKey(now) {
+		global = l.global
+		if totals := l.users[userID]; totals != nil {
+			user = *totals
+		}
+		if totals := l.clients[clientIP]; totals != nil {
+			client = *totals
+		}
+	}
+
+	y, m, d := now.In(time.Local).Date()
+	resetAt := time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+
+	checks := []quotaCheck{
+		{"global", "requests", quota.GlobalDailyRequests, global.Requests + l.reserved["global"]},
+		{"global", "tokens", quota.GlobalDailyTokens, global.Tokens},
+	}
+	if userID != "" {
+		checks = append(checks,
+			quotaCheck{"user", "requests", quota.UserDailyRequests, user.Requests + l.reserved["user:"+userID]},
+			quotaCheck{"user", "tokens", quota.UserDailyTokens, user.Tokens},
+		)
+	}
+	if clientIP != "" {
+		checks = append(checks,
+			quotaCheck{"ip", "requests", quota.IPDailyRequests, client.Requests + l.reserved["ip:"+clientIP]},
+			quotaCheck{"ip", "tokens", quota.IPDailyTokens, client.Tokens},
+		)
+	}
+
+	for _, check := range checks {
+		if check.limit > 0 && check.used >= check.limit {
+			quotaRejections.WithLabelValues(check.scope).Inc()
+			return &QuotaError{Scope: check.scope, Unit: check.unit, Limit: check.limit, Used: check.used, ResetAt: resetAt}
+		}
+	}
+	return nil
+}
+
+// Quota returnerar de konfigurerade kvoterna
+func (l *Ledger) Quota() QuotaConfig {
+	return l.cfg.Quota
+}
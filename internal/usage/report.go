@@ -0,0 +1,77 @@
+package usage
+
+import "time"
+
+// Totals är summerad förbrukning för en grupp av anrop
+type Totals struct {
+	Requests         int   `json:"requests"`
+	Errors           int   `json:"errors"`
+	PromptTokens     int   `json:"promptTokens"`
+	CompletionTokens int   `json:"completionTokens"`
+	TotalTokens      int   `json:"totalTokens"`
+	AvgLatencyMs     int64 `json:"avgLatencyMs"`
+
+	latencyMs int64
+}
+
+func (t *Totals) add(record Record) {
+	t.Requests++
+	if record.Error {
+		t.Errors++
+	}
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	t.TotalTokens += record.Tokens()
+	t.latencyMs += record.LatencyMs
+	t.AvgLatencyMs = t.latencyMs / int64(t.Requests)
+}
+
+// Report är förbrukningen under en period, uppdelad på olika sätt
+type Report struct {
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Totals     Totals             `json:"totals"`
+	ByFeature  map[string]*Totals `json:"byFeature"`
+	ByProvider map[string]*Totals `json:"byProvider"`
+	ByUser     map[string]*Totals `json:"byUser"`
+	ByDay      map[string]*Totals `json:"byDay"`
+	Quota      QuotaConfig        `json:"quota"`
+}
+
+// Report summerar anropen mellan from och to. Om userID inte är tomt
+// tas bara den användarens anrop med.
+func (l *Ledger) Report(from, to time.Time, userID string) *Report {
+	report := &Report{
+		From:       from,
+		To:         to,
+		ByFeature:  make(map[string]*Totals),
+		ByProvider: make(map[string]*Totals),
+		ByUser:     make(map[string]*Totals),
+		ByDay:      make(map[string]*Totals),
+		Quota:      l.Quota(),
+	}
+
+	for _, record := range l.Records(from, to) {
+		if userID != "" && record.UserID != userID {
+			continue
+		}
+		report.Totals.add(record)
+		addTo(report.ByFeature, record.Feature, record)
+		addTo(report.ByProvider, record.Provider, record)
+		addTo(report.ByUser, record.UserID, record)
+		addTo(report.ByDay, dayKey(record.Time), record)
+	}
+	return report
+}
+
+func addTo(groups map[string]*Totals, key string, record Record) {
+	if key == "" {
+		key = "okänd"
+	}
+	totals := groups[key]
+	if totals == nil {
+		totals = &Totals{}
+		groups[key] = totals
+	}
+	totals.add(record)
+}
@@ -1,11 +1,12 @@
 package utils
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"strings"
-	"time"
 	"log"
+
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/prompts"
 )
 
 // CoverLetterPrompt innehåller information för att generera personligt brev
@@ -14,91 +15,118 @@ type CoverLetterPrompt struct {
 	JobTitle     string `json:"job_title"`
 	JobDesc      string `json:"job_desc"`
 	CompanyName  string `json:"company_name"`
+	Language     string `json:"language"`
+}
+
+// PersonalLetter är AI:ns innehåll för ett komplett personligt brev
+type PersonalLetter struct {
+	Namn              string `json:"namn"`
+	Titel             string `json:"titel"`
+	Email             string `json:"email"`
+	Telefon           string `json:"telefon"`
+	Adress            string `json:"adress"`
+	MottagareNamn     string `json:"mottagare_namn"`
+	MottagareForetag  string `json:"mottagare_foretag,omitempty"`
+	MottagarePosition string `json:"mottagare_position"`
+	MottagareAdress   string `json:"mottagare_adress,omitempty"`
+	MottagarePostort  string `json:"mottagare_postort,omitempty"`
+	Datum             string `json:"datum,omitempty"`
+	Inledning         string `json:"inledning"`
+	Huvudtext         string `json:"huvudtext"`
+	Avslutning        string `json:"avslutning"`
+	Halsningsfras     string `json:"halsningsfras"`
+	PromptVersion     string `json:"promptVersion,omitempty" schema:"-"`
+}
+
+// CoverLetterContent är styckena i ett personligt brev för en jobbannons
+type CoverLetterContent struct {
+	Introduction string `json:"introduction"`
+	Experience   string `json:"experience"`
+	Motivation   string `json:"motivation"`
+	Closing      string `json:"closing"`
+	// PromptVersion anger vilken prompt styckena kommer från
+	PromptVersion string `json:"promptVersion,omitempty" schema:"-"`
+}
+
+// CoverLetterContentPrompt är jobbannonsen som brevets stycken ska skrivas för
+type CoverLetterContentPrompt struct {
+	JobTitle       string
+	JobDescription string
+	CompanyName    string
+	// Language väljer promptens språk, t.ex. "en". Tomt ger PROMPT_LANGUAGE.
+	Language string
 }
 
 // GeneratePersonalLetter genererar innehåll för personligt brev med hjälp av AI
-func GeneratePersonalLetter(prompt CoverLetterPrompt) (map[string]interface{}, error) {
+func GeneratePersonalLetter(ctx context.Context, prompt CoverLetterPrompt) (*PersonalLetter, error) {
 	log.Printf("Genererar personligt brev för tjänst: %s hos %s", prompt.JobTitle, prompt.CompanyName)
 
-	// Skapa system prompt för AI
-	systemPrompt := `Du är en expert på att skriva personliga brev. 
-	Din uppgift är att generera ett JSON-objekt som innehåller information för ett personligt brev.
-	Svara ENDAST med ett giltigt JSON-objekt, inget annat.
-	
-	JSON-objektet ska ha följande struktur och fält:
-	{
-		"namn": "Ett lämpligt namn",
-		"titel": "En passande yrkestitel",
-		"email": "En professionell e-postadress",
-		"telefon": "Ett svenskt telefonnummer",
-		"adress": "En svensk adress",
-		"mottagare_namn": "Ett lämpligt namn på rekryteraren",
-		"mottagare_foretag": "` + prompt.CompanyName + `",
-		"mottagare_position": "Rekryteringsansvarig",
-		"datum": "` + time.Now().Format("2006-01-02") + `",
-		"inledning": "En engagerande inledning som refererar till tjänsten",
-		"huvudtext": "En övertygande huvudtext som matchar jobbeskrivningen",
-		"avslutning": "En professionell avslutning",
-		"halsningsfras": "Med vänliga hälsningar"
-	}`
-
-	// Skapa användarmeddelande med jobbinformation
-	userPrompt := fmt.Sprintf(`Generera ett personligt brev för följande tjänst:
-	Titel: %s
-	Företag: %s
-	Beskrivning: %s`, 
-	prompt.JobTitle, 
-	prompt.CompanyName, 
-	prompt.JobDesc)
-
-	log.Printf("=== AI Prompt för Personligt Brev ===")
-	log.Printf("System Prompt:\n%s", systemPrompt)
-	log.Printf("\nUser Prompt:\n%s", userPrompt)
-
-	// Använd den gemensamma AI-tjänsten
-	aiService := GetAIService()
-	log.Printf("\nAnropar AI-tjänst")
-	
-	aiResponse, err := aiService.GenerateContent(CVPrompt{
-		JobTitle:       prompt.JobTitle,
-		JobDescription: prompt.JobDesc,
-		SystemPrompt:   systemPrompt,
-		UserPrompt:     userPrompt,
-	})
+	rendered, err := prompts.Render("personal_letter", prompt.Language, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("=== AI Prompt för Personligt Brev (%s) ===", rendered.Label())
+	log.Printf("System Prompt:\n%s", rendered.System)
+	log.Printf("\nUser Prompt:\n%s", rendered.User)
+
+	// Använd den valda AI-leverantören
+	model := llm.Default()
+	log.Printf("\nAnropar %s", model.Name())
 
+	var letter PersonalLetter
+	_, err = llm.GenerateJSON(ctx, model, llm.Request{
+		Messages:      promptMessages(rendered),
+		Temperature:   0.7,
+		MaxTokens:     2000,
+		Feature:       "cover_letter",
+		PromptVersion: rendered.Label(),
+	}, &letter)
 	if err != nil {
 		log.Printf("❌ Fel vid AI-anrop: %v", err)
 		return nil, fmt.Errorf("AI-anrop misslyckades: %v", err)
 	}
 
-	// Hämta response-strängen direkt från map[string]string
-	responseStr := aiResponse["response"]
-	if responseStr == "" {
-		log.Printf("❌ Tomt svar från AI")
-		return nil, fmt.Errorf("tomt svar från AI")
-	}
+	log.Printf("✅ Personligt brev genererat framgångsrikt")
+	letter.PromptVersion = rendered.Label()
+	return &letter, nil
+}
 
-	log.Printf("✅ AI-svar mottaget, längd: %d tecken", len(responseStr))
+// GenerateCoverLetterContent genererar styckena i ett personligt brev
+// (introduction, experience, motivation och closing) för en jobbannons
+func GenerateCoverLetterContent(ctx context.Context, prompt CoverLetterContentPrompt) (*CoverLetterContent, error) {
+	return generateCoverLetterContent(ctx, prompt, nil)
+}
 
-	// Försök hitta JSON i svaret
-	startIndex := strings.Index(responseStr, "{")
-	endIndex := strings.LastIndex(responseStr, "}")
-	
-	if startIndex == -1 || endIndex == -1 || startIndex > endIndex {
-		log.Printf("❌ Kunde inte hitta giltigt JSON i svaret. Rått svar:\n%s", responseStr)
-		return nil, fmt.Errorf("kunde inte hitta giltigt JSON i svaret")
-	}
+// GenerateCoverLetterContentStream fungerar som GenerateCoverLetterContent
+// men skickar modellens text till callbacks medan brevet skrivs
+func GenerateCoverLetterContentStream(ctx context.Context, prompt CoverLetterContentPrompt, callbacks llm.StreamCallbacks) (*CoverLetterContent, error) {
+	return generateCoverLetterContent(ctx, prompt, &callbacks)
+}
 
-	jsonStr := responseStr[startIndex:endIndex+1]
-	log.Printf("📝 Extraherat JSON:\n%s", jsonStr)
+func generateCoverLetterContent(ctx context.Context, prompt CoverLetterContentPrompt, callbacks *llm.StreamCallbacks) (*CoverLetterContent, error) {
+	rendered, err := prompts.Render("cover_letter_content", prompt.Language, prompt)
+	if err != nil {
+		return nil, err
+	}
 
-	// Konvertera svaret till map
-	var result map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		log.Printf("❌ JSON-parsning misslyckades: %v", err)
-		return nil, fmt.Errorf("kunde inte parsa JSON: %v\nSvar: %s", err, jsonStr)
+	req := llm.Request{
+		Messages:      promptMessages(rendered),
+		Temperature:   0.7,
+		MaxTokens:     2000,
+		Feature:       "cover_letter_content",
+		PromptVersion: rendered.Label(),
 	}
 
-	log.Printf("✅ Personligt brev genererat framgångsrikt")
-	return result, nil
-} 
\ No newline at end of file
+	var content CoverLetterContent
+	if callbacks != nil {
+		_, err = llm.GenerateJSONStream(ctx, llm.Default(), req, &content, *callbacks)
+	} else {
+		_, err = llm.GenerateJSON(ctx, llm.Default(), req, &content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	content.PromptVersion = rendered.Label()
+	return &content, nil
+}
@@ -2,28 +2,23 @@ package utils
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
-	"bytes"
-	"net/http"
 	"unicode"
-	"time"
-	"io"
-	"bufio"
 
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
+	"awesomeProject/internal/llm"
+	"awesomeProject/internal/prompts"
 )
 
 type SearchAnalysis struct {
 	Job                string `json:"job"`
 	Municipality       string `json:"municipality"`
 	RequiresExperience *bool  `json:"requiresExperience"`
-	WorkExtent        string `json:"workExtent"`
-	Remote           string `json:"remote"`
-	DrivingLicense   string `json:"drivingLicense"`
+	WorkExtent         string `json:"workExtent,omitempty"`
+	Remote             string `json:"remote,omitempty"`
+	DrivingLicense     string `json:"drivingLicense,omitempty"`
+	PromptVersion      string `json:"promptVersion,omitempty" schema:"-"`
 }
 
 // normalizeString normaliserar en sträng för jämförelse
@@ -46,191 +41,77 @@ func normalizeString(s string) string {
 		s = strings.ReplaceAll(s, old, new)
 	}
 	
-	// Behåll bara bokstäver och siffror
-	var result strings.Builder
-	for _, r := range s {
-		if unicode.IsLetter(r) || unicode.IsNumber(r) {
-			result.WriteRune(r)
-		}
-	}
-	
-	return result.String()
+	// Behåll bokstäver och siffror, allt annat blir ett mellanslag mellan
+	// orden så att t.ex. "it chef" och "itchef" inte blir samma nyckel
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
 }
 
-func AnalyzeSearchQuery(query string) (*SearchAnalysis, error) {
+// AnalyzeSearchQuery tolkar en fritextsökning med prompten search_analysis.
+// Byte av leverantör vid fel sköts av llm-kedjan; om modellen inte hittar
+// något alls görs ett nytt försök med search_analysis_translated som först
+// översätter frågan.
+func AnalyzeSearchQuery(ctx context.Context, query string) (*SearchAnalysis, error) {
 	log.Printf("\n=== Analyserar sökfråga: %s ===\n", query)
 
-	// Försök med Hugging Face först
-	result, err := tryHuggingFace(query)
+	model := llm.Default()
+	result, err := analyzeQuery(ctx, model, "search_analysis", query)
 	if err != nil {
-		log.Printf("Hugging Face misslyckades: %v, försöker med Gemini istället", err)
-		return tryGemini(query)
+		log.Printf("Sökanalysen misslyckades: %v, försöker med översättande prompt istället", err)
+		return analyzeTranslated(ctx, model, query)
 	}
 
-	// Anropa Gemini endast om ALLA fält är null eller tomma
+	// Försök igen endast om ALLA fält är null eller tomma
 	if result == nil || (result.Job == "" && result.Municipality == "" && result.RequiresExperience == nil) {
-		log.Printf("Hugging Face returnerade alla fält som null, försöker med Gemini istället")
-		return tryGemini(query)
+		log.Printf("Sökanalysen returnerade alla fält som null, försöker med översättande prompt istället")
+		return analyzeTranslated(ctx, model, query)
 	}
 
 	return result, nil
 }
 
-func tryHuggingFace(query string) (*SearchAnalysis, error) {
-	apiKey := getAPIKey(HuggingfaceProvider)
-	if apiKey == "" {
-		return nil, fmt.Errorf("ingen Huggingface API-nyckel tillgänglig")
+func analyzeTranslated(ctx context.Context, model llm.LLM, query string) (*SearchAnalysis, error) {
+	result, err := analyzeQuery(ctx, model, "search_analysis_translated", query)
+	if err != nil {
+		return nil, err
 	}
 
-	url := "https://api-inference.huggingface.co/v1/chat/completions"
-
-	log.Printf("🔍 Skickar förfrågan till HF API med query: %s", query)
-
-	promptText := fmt.Sprintf(`dessa är lista på städer som du ska plocka staden eller kommunen utifrån kundens fråga [
-  ["Blekinge län", "Karlshamn", "Karlskrona", "Olofström", "Ronneby", "Sölvesborg", "Dalarnas län", "Avesta", "Borlänge", "Falun", "Gagnef", "Hedemora", "Leksand", "Ludvika", "Malung-Sälen", "Mora", "Orsa", "Rättvik", "Smedjebacken", "Säter"],
-  ["Vansbro", "Älvdalen", "Gotlands län", "Gotland", "Gävleborgs län", "Bollnäs", "Gävle", "Hofors", "Hudiksvall", "Ljusdal", "Nordanstig", "Ockelbo", "Ovanåker", "Sandviken", "Söderhamn", "Hallands län", "Falkenberg", "Halmstad", "Hylte", "Kungsbacka"],
-  ["Laholm", "Varberg", "Jämtlands län", "Berg", "Bräcke", "Härjedalen", "Krokom", "Ragunda", "Strömsund", "Åre", "Östersund", "Jönköpings län", "Aneby", "Eksjö", "Gislaved", "Gnosjö", "Habo", "Jönköping", "Mullsjö", "Nässjö"],
-  ["Sävsjö", "Tranås", "Vaggeryd", "Vetlanda", "Värnamo", "Kalmar län", "Borgholm", "Emmaboda", "Hultsfred", "Högsby", "Kalmar", "Mönsterås", "Mörbylånga", "Nybro", "Oskarshamn", "Torsås", "Vimmerby", "Västervik", "Kronobergs län", "Alvesta"],
-  ["Lessebo", "Ljungby", "Markaryd", "Tingsryd", "Uppvidinge", "Växjö", "Älmhult", "Norrbottens län", "Arjeplog", "Arvidsjaur", "Boden", "Gällivare", "Haparanda", "Jokkmokk", "Kalix", "Kiruna", "Luleå", "Pajala", "Piteå", "Älvsbyn"],
-  ["Överkalix", "Övertorneå", "Skåne län", "Bjuv", "Bromölla", "Burlöv", "Båstad", "Eslöv", "Helsingborg", "Hässleholm", "Höganäs", "Hörby", "Höör", "Klippan", "Kristianstad", "Kävlinge", "Landskrona", "Lomma", "Lund", "Malmö"],
-  ["Osby", "Perstorp", "Simrishamn", "Sjöbo", "Skurup", "Staffanstorp", "Svalöv", "Svedala", "Tomelilla", "Trelleborg", "Vellinge", "Ystad", "Ängelholm", "Åstorp", "Örkelljunga", "Östra Göinge", "Stockholms län", "Botkyrka", "Danderyd", "Ekerö"],
-  ["Haninge", "Huddinge", "Järfälla", "Lidingö", "Nacka", "Norrtälje", "Nykvarn", "Nynäshamn", "Salem", "Sigtuna", "Sollentuna", "Solna", "Stockholm", "Sundbyberg", "Södertälje", "Tyresö", "Täby", "Upplands Väsby", "Upplands-Bro", "Vallentuna"],
-  ["Vaxholm", "Värmdö", "Österåker", "Södermanlands län", "Eskilstuna", "Flen", "Gnesta", "Katrineholm", "Nyköping", "Oxelösund", "Strängnäs", "Trosa", "Vingåker", "Uppsala län", "Enköping", "Heby", "Håbo", "Knivsta", "Tierp", "Uppsala"],
-  ["Älvkarleby", "Östhammar", "Värmlands län", "Arvika", "Eda", "Filipstad", "Forshaga", "Grums", "Hagfors", "Hammarö", "Karlstad", "Kil", "Kristinehamn", "Munkfors", "Storfors", "Sunne", "Säffle", "Torsby", "Årjäng", "Västerbottens län"],
-  ["Bjurholm", "Dorotea", "Lycksele", "Malå", "Nordmaling", "Norsjö", "Robertsfors", "Skellefteå", "Sorsele", "Storuman", "Umeå", "Vilhelmina", "Vindeln", "Vännäs", "Åsele", "Västernorrlands län", "Härnösand", "Kramfors", "Sollefteå", "Sundsvall"],
-  ["Timrå", "Ånge", "Örnsköldsvik", "Västmanlands län", "Arboga", "Fagersta", "Hallstahammar", "Kungsör", "Köping", "Norberg", "Sala", "Skinnskatteberg", "Surahammar", "Västerås", "Västra Götalands län", "Ale", "Alingsås", "Bengtsfors", "Bollebygd"],
-  ["Borås", "Dals-Ed", "Essunga", "Falköping", "Färgelanda", "Grästorp", "Gullspång", "Göteborg", "Götene", "Herrljunga", "Hjo", "Härryda", "Karlsborg", "Kungälv", "Lerum", "Lidköping", "Lilla Edet", "Lysekil", "Mariestad", "Mark"],
-  ["Mellerud", "Munkedal", "Mölndal", "Orust", "Partille", "Skara", "Skövde", "Sotenäs", "Stenungsund", "Strömstad", "Svenljunga", "Tanum", "Tibro", "Tidaholm", "Tjörn", "Tranemo", "Trollhättan", "Töreboda", "Uddevalla", "Ulricehamn"],
-  ["Vara", "Vänersborg", "Vårgårda", "Åmål", "Öckerö", "Örebro län", "Askersund", "Degerfors", "Hallsberg", "Hällefors", "Karlskoga", "Kumla", "Laxå", "Lekeberg", "Lindesberg", "Ljusnarsberg", "Nora", "Örebro", "Östergötlands län", "Boxholm"],
-  ["Finspång", "Kinda", "Linköping", "Mjölby", "Motala", "Norrköping", "Söderköping", "Vadstena", "Valdemarsvik", "Ydre", "Åtvidaberg", "Ödeshög"]
-]
-
-VIKTIGT: Om användaren nämner ett län (t.ex. "gävleborg", "gävleborgs län"), returnera ALLTID länets fullständiga namn (t.ex. "Gävleborgs län") i municipality-fältet, inte en stad i länet.
-
-Analysera följande jobbsökningsfråga och extrahera information.
-Om personen specifikt nämner att de söker jobb utan erfarenhetskrav eller entry-level/junior-positioner, sätt requiresExperience till false.
-Om personen specifikt söker senior-positioner eller jobb som kräver erfarenhet, sätt requiresExperience till true.
-Om personen inte nämner något om erfarenhet, sätt requiresExperience till null.
-
-För arbetstid (workExtent), använd följande regler:
-- Om personen nämner "heltid" eller "100%%", sätt workExtent till "947z_JGS_Uk2"
-- Om personen nämner "deltid", sätt workExtent till "947z_JGS_Uk3"
-- Om personen inte nämner arbetstid, sätt workExtent till ""
-
-För distansarbete (remote), använd följande regler:
-- Om personen nämner "distans", "remote", "på distans" eller "hemifrån", sätt remote till "true"
-- Om personen inte nämner något om distansarbete, sätt remote till ""
+	if result.Job == "" {
+		return nil, fmt.Errorf("kunde inte identifiera jobb från AI-svar")
+	}
 
-För körkortskrav (drivingLicense), använd följande regler:
-- Om personen nämner "utan körkort", "ej körkort", "inget körkort" eller "körkort krävs ej", sätt drivingLicense till "false"
-- Om personen inte nämner något om körkort, sätt drivingLicense till ""
+	log.Printf("\nExtraherad information:\nJobb: %s\nKommun: %s\nErfarenhetskrav: %v\n",
+		result.Job, result.Municipality, result.RequiresExperience)
 
-Försök att förstå vad kunden söker för yrke och ge bra namn på yrke till jobb-falten samam sak för städer han bor i Sverige.
-Returnera ENDAST ett JSON-objekt med följande struktur:
-{
-    "job": "extraherad jobbtitel",
-    "municipality": "extraherad kommun/län (använd exakt namn från listan)",
-    "requiresExperience": false/true/null (baserat på erfarenhetskrav),
-    "workExtent": "947z_JGS_Uk2"/"947z_JGS_Uk3"/"" (baserat på arbetstid),
-    "remote": "true"/"" (baserat på distansarbete),
-    "drivingLicense": "false"/"" (baserat på körkortskrav)
+	return result, nil
 }
 
-Exempel:
-- Om användaren skriver "jobb i gävleborg" -> municipality: "Gävleborgs län"
-- Om användaren skriver "jobb i gävle" -> municipality: "Gävle"
-- Om användaren skriver "heltidsjobb" -> workExtent: "947z_JGS_Uk2"
-- Om användaren skriver "deltidsjobb" -> workExtent: "947z_JGS_Uk3"
-- Om användaren skriver "distansjobb" -> remote: "true"
-- Om användaren skriver "jobb utan körkort" -> drivingLicense: "false"
-
-Om de är annat språk än svenska då ska alla objekt i JSON-objektet vara null viktigt.
-Sökfråga: %s`, query)
-
-	requestBody := map[string]interface{}{
-		"model": "Qwen/Qwen2.5-Coder-32B-Instruct",
-		"messages": []map[string]string{
-			{"role": "user", "content": promptText},
-		},
-		"temperature": 0.3,
-		"max_tokens": 2048,
-		"top_p": 0.7,
-		"stream": true,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("kunde inte skapa request body: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+func analyzeQuery(ctx context.Context, model llm.LLM, promptName, query string) (*SearchAnalysis, error) {
+	rendered, err := prompts.Render(promptName, "", struct{ Query string }{query})
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("🔍 Skickar sökfråga till AI (prompt %s): %s", rendered.Label(), query)
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	
-	resp, err := client.Do(req)
+	var result SearchAnalysis
+	resp, err := llm.GenerateJSON(ctx, model, llm.Request{
+		Messages:      promptMessages(rendered),
+		Temperature:   0.3,
+		MaxTokens:     2048,
+		Feature:       "search_analysis",
+		PromptVersion: rendered.Label(),
+		// "Jobb i Gävle" och "jobb i gävle!" ger samma analys
+		CacheKey: normalizeString(query),
+	}, &result)
 	if err != nil {
-		return nil, fmt.Errorf("HF API anrop misslyckades: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("❌ HF API fel status %d\nHeaders: %v\nBody: %s", resp.StatusCode, resp.Header, string(body))
-		return nil, fmt.Errorf("HF API returnerade status %d: %s", resp.StatusCode, string(body))
-	}
-
-	log.Printf("✅ HF API status: %d", resp.StatusCode)
-
-	// Hantera streaming response
-	var fullResponse bytes.Buffer
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-			if data == "[DONE]" {
-				break
-			}
-			var streamResponse HFStreamResponse
-			if err := json.Unmarshal([]byte(data), &streamResponse); err != nil {
-				continue
-			}
-
-			if len(streamResponse.Choices) > 0 {
-				content := streamResponse.Choices[0].Delta.Content
-				fullResponse.WriteString(content)
-			}
-		}
+		return nil, err
 	}
-
-	if err := scanner.Err(); err != nil {
-		log.Printf("❌ Fel vid läsning av stream: %v", err)
-		return nil, fmt.Errorf("fel vid läsning av stream: %v", err)
-	}
-
-	// Extrahera JSON från svaret
-	responseStr := fullResponse.String()
-	startIdx := strings.Index(responseStr, "{")
-	endIdx := strings.LastIndex(responseStr, "}")
-	
-	if startIdx == -1 || endIdx == -1 {
-		return nil, fmt.Errorf("kunde inte hitta JSON i svaret: %s", responseStr)
-	}
-
-	jsonStr := responseStr[startIdx : endIdx+1]
-	log.Printf("📥 Extraherat JSON-svar: %s", jsonStr)
-
-	var result SearchAnalysis
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		return nil, fmt.Errorf("kunde inte unmarshalla svar: %v", err)
+	if resp.Cached {
+		log.Printf("🗄️ Sökanalys från cachen: %s", resp.Text)
+	} else {
+		log.Printf("📥 JSON-svar: %s", resp.Text)
 	}
 
 	// Om job är tomt men vi har andra värden, sätt det till "jobb"
@@ -238,103 +119,6 @@ Sökfråga: %s`, query)
 		result.Job = "jobb"
 	}
 
-	return &result, nil
-}
-
-func tryGemini(query string) (*SearchAnalysis, error) {
-	ctx := context.Background()
-
-	client, err := genai.NewClient(ctx, option.WithAPIKey(getAPIKey(GeminiProvider)))
-	if err != nil {
-		return nil, fmt.Errorf("kunde inte skapa Gemini-klient: %v", err)
-	}
-	defer client.Close()
-
-	model := client.GenerativeModel("gemini-1.5-flash-8b")
-	model.SetTemperature(0.2)
-	model.SetTopK(40)
-	model.SetTopP(0.95)
-
-	promptText := fmt.Sprintf(`dessa är lista på städer som du ska plocka staden eller kommunen utifrån kundens fråga [
-  ["Blekinge län", "Karlshamn", "Karlskrona", "Olofström", "Ronneby", "Sölvesborg", "Dalarnas län", "Avesta", "Borlänge", "Falun", "Gagnef", "Hedemora", "Leksand", "Ludvika", "Malung-Sälen", "Mora", "Orsa", "Rättvik", "Smedjebacken", "Säter"],
-  ["Vansbro", "Älvdalen", "Gotlands län", "Gotland", "Gävleborgs län", "Bollnäs", "Gävle", "Hofors", "Hudiksvall", "Ljusdal", "Nordanstig", "Ockelbo", "Ovanåker", "Sandviken", "Söderhamn", "Hallands län", "Falkenberg", "Halmstad", "Hylte", "Kungsbacka"],
-  ["Laholm", "Varberg", "Jämtlands län", "Berg", "Bräcke", "Härjedalen", "Krokom", "Ragunda", "Strömsund", "Åre", "Östersund", "Jönköpings län", "Aneby", "Eksjö", "Gislaved", "Gnosjö", "Habo", "Jönköping", "Mullsjö", "Nässjö"],
-  ["Sävsjö", "Tranås", "Vaggeryd", "Vetlanda", "Värnamo", "Kalmar län", "Borgholm", "Emmaboda", "Hultsfred", "Högsby", "Kalmar", "Mönsterås", "Mörbylånga", "Nybro", "Oskarshamn", "Torsås", "Vimmerby", "Västervik", "Kronobergs län", "Alvesta"],
-  ["Lessebo", "Ljungby", "Markaryd", "Tingsryd", "Uppvidinge", "Växjö", "Älmhult", "Norrbottens län", "Arjeplog", "Arvidsjaur", "Boden", "Gällivare", "Haparanda", "Jokkmokk", "Kalix", "Kiruna", "Luleå", "Pajala", "Piteå", "Älvsbyn"],
-  ["Överkalix", "Övertorneå", "Skåne län", "Bjuv", "Bromölla", "Burlöv", "Båstad", "Eslöv", "Helsingborg", "Hässleholm", "Höganäs", "Hörby", "Höör", "Klippan", "Kristianstad", "Kävlinge", "Landskrona", "Lomma", "Lund", "Malmö"],
-  ["Osby", "Perstorp", "Simrishamn", "Sjöbo", "Skurup", "Staffanstorp", "Svalöv", "Svedala", "Tomelilla", "Trelleborg", "Vellinge", "Ystad", "Ängelholm", "Åstorp", "Örkelljunga", "Östra Göinge", "Stockholms län", "Botkyrka", "Danderyd", "Ekerö"],
-  ["Haninge", "Huddinge", "Järfälla", "Lidingö", "Nacka", "Norrtälje", "Nykvarn", "Nynäshamn", "Salem", "Sigtuna", "Sollentuna", "Solna", "Stockholm", "Sundbyberg", "Södertälje", "Tyresö", "Täby", "Upplands Väsby", "Upplands-Bro", "Vallentuna"],
-  ["Vaxholm", "Värmdö", "Österåker", "Södermanlands län", "Eskilstuna", "Flen", "Gnesta", "Katrineholm", "Nyköping", "Oxelösund", "Strängnäs", "Trosa", "Vingåker", "Uppsala län", "Enköping", "Heby", "Håbo", "Knivsta", "Tierp", "Uppsala"],
-  ["Älvkarleby", "Östhammar", "Värmlands län", "Arvika", "Eda", "Filipstad", "Forshaga", "Grums", "Hagfors", "Hammarö", "Karlstad", "Kil", "Kristinehamn", "Munkfors", "Storfors", "Sunne", "Säffle", "Torsby", "Årjäng", "Västerbottens län"],
-  ["Bjurholm", "Dorotea", "Lycksele", "Malå", "Nordmaling", "Norsjö", "Robertsfors", "Skellefteå", "Sorsele", "Storuman", "Umeå", "Vilhelmina", "Vindeln", "Vännäs", "Åsele", "Västernorrlands län", "Härnösand", "Kramfors", "Sollefteå", "Sundsvall"],
-  ["Timrå", "Ånge", "Örnsköldsvik", "Västmanlands län", "Arboga", "Fagersta", "Hallstahammar", "Kungsör", "Köping", "Norberg", "Sala", "Skinnskatteberg", "Surahammar", "Västerås", "Västra Götalands län", "Ale", "Alingsås", "Bengtsfors", "Bollebygd"],
-  ["Borås", "Dals-Ed", "Essunga", "Falköping", "Färgelanda", "Grästorp", "Gullspång", "Göteborg", "Götene", "Herrljunga", "Hjo", "Härryda", "Karlsborg", "Kungälv", "Lerum", "Lidköping", "Lilla Edet", "Lysekil", "Mariestad", "Mark"],
-  ["Mellerud", "Munkedal", "Mölndal", "Orust", "Partille", "Skara", "Skövde", "Sotenäs", "Stenungsund", "Strömstad", "Svenljunga", "Tanum", "Tibro", "Tidaholm", "Tjörn", "Tranemo", "Trollhättan", "Töreboda", "Uddevalla", "Ulricehamn"],
-  ["Vara", "Vänersborg", "Vårgårda", "Åmål", "Öckerö", "Örebro län", "Askersund", "Degerfors", "Hallsberg", "Hällefors", "Karlskoga", "Kumla", "Laxå", "Lekeberg", "Lindesberg", "Ljusnarsberg", "Nora", "Örebro", "Östergötlands län", "Boxholm"],
-  ["Finspång", "Kinda", "Linköping", "Mjölby", "Motala", "Norrköping", "Söderköping", "Vadstena", "Valdemarsvik", "Ydre", "Åtvidaberg", "Ödeshög"]
-]
-
-VIKTIGT: Om användaren nämner ett län (t.ex. "gävleborg", "gävleborgs län"), returnera ALLTID länets fullständiga namn (t.ex. "Gävleborgs län") i municipality-fältet, inte en stad i länet.
-Försök att översätta till svenska språk från kundens fråga från stad till yrke. Alltid på svenska.
-Analysera följande jobbsökningsfråga och extrahera information.
-Om personen specifikt nämner att de söker jobb utan erfarenhetskrav eller entry-level/junior-positioner, sätt requiresExperience till false.
-Om personen specifikt söker senior-positioner eller jobb som kräver erfarenhet, sätt requiresExperience till true.
-Om personen inte nämner något om erfarenhet, sätt requiresExperience till null.
-
-Returnera ENDAST ett JSON-objekt med följande struktur:
-{
-    "job": "extraherad jobbtitel",
-    "municipality": "extraherad kommun/län (använd exakt namn från listan)",
-    "requiresExperience": false/true/null (baserat på erfarenhetskrav)
-}
-
-Exempel:
-- Om användaren skriver "jobb i gävleborg" -> municipality: "Gävleborgs län"
-- Om användaren skriver "jobb i gävle" -> municipality: "Gävle"
-
-Sökfråga: %s`, query)
-
-	log.Printf("\nSkickar prompt till Gemini:\n%s\n", promptText)
-
-	resp, err := model.GenerateContent(ctx, genai.Text(promptText))
-	if err != nil {
-		return nil, fmt.Errorf("Gemini generering misslyckades: %v", err)
-	}
-
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("inget svar från Gemini")
-	}
-
-	var aiResponse string
-	for _, part := range resp.Candidates[0].Content.Parts {
-		aiResponse += fmt.Sprintf("%v", part)
-	}
-
-	log.Printf("\nGemini-svar:\n%s\n", aiResponse)
-
-	// Extrahera JSON från svaret
-	var cleanedJSON string
-	if jsonStart := strings.Index(aiResponse, "{"); jsonStart != -1 {
-		if jsonEnd := strings.LastIndex(aiResponse, "}"); jsonEnd != -1 {
-			cleanedJSON = aiResponse[jsonStart : jsonEnd+1]
-		}
-	}
-
-	if cleanedJSON == "" {
-		return nil, fmt.Errorf("kunde inte extrahera JSON från Gemini-svar")
-	}
-
-	var result SearchAnalysis
-	if err := json.Unmarshal([]byte(cleanedJSON), &result); err != nil {
-		return nil, fmt.Errorf("kunde inte parsa Gemini-svar: %v", err)
-	}
-
-	if result.Job == "" {
-		return nil, fmt.Errorf("kunde inte identifiera jobb från Gemini-svar")
-	}
-
-	log.Printf("\nGemini extraherad information:\nJobb: %s\nKommun: %s\nErfarenhetskrav: %v\n", 
-		result.Job, result.Municipality, result.RequiresExperience)
-
+	result.PromptVersion = rendered.Label()
 	return &result, nil
 }
@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestNormalizeString(t *testing.T) {
+	tests := map[string]string{
+		"IT-chef i Göteborg":        "it chef i goteborg",
+		"  it   chef\ti  göteborg ": "it chef i goteborg",
+		"itchef":                    "itchef",
+		"Lärare, åk 1–3!":           "larare ak 1 3",
+	}
+	for in, want := range tests {
+		if got := normalizeString(in); got != want {
+			t.Errorf("normalizeString(%q) = %q, vill ha %q", in, got, want)
+		}
+	}
+	if normalizeString("it chef") == normalizeString("itchef") {
+		t.Error(`"it chef" och "itchef" gav samma nyckel`)
+	}
+}